@@ -1,9 +1,39 @@
 package main
 
-import "kursy-kriptovalyut/internal/app"
+import (
+	"flag"
 
+	"kursy-kriptovalyut/internal/app"
+)
+
+// usage:
+//
+//	cryptorate [--config cfg.yaml] [serve|worker|all]
+//	cryptorate [--config cfg.yaml] backfill --titles BTC,ETH --from 2024-01-01 --interval 1h
+//	cryptorate [--config cfg.yaml] compact
+//	cryptorate [--config cfg.yaml] keys issue --name ops --scopes admin
+//
+// без --config путь к конфигу берётся из CRYPTORATE_CONFIG, затем ищется ./config/cfg.yaml или ./cfg.yaml
 func main() {
-	app := app.NewApp()
-	app.Run()
+	configPath := flag.String("config", "", "path to the config file")
+	flag.Parse()
+
+	var mode string
+	args := flag.Args()
+	if len(args) > 0 {
+		mode = args[0]
+	}
+
+	app := app.NewApp(*configPath)
+	switch mode {
+	case "backfill":
+		app.Backfill(args[1:])
+	case "compact":
+		app.Compact()
+	case "keys":
+		app.Keys(args[1:])
+	default:
+		app.Run(mode)
+	}
 
 }
@@ -1,42 +1,379 @@
 package config
 
 import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
 	"github.com/spf13/viper"
-	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"kursy-kriptovalyut/pkg/logger"
 )
 
 var log = logger.NewLogger()
 
+const (
+	// EnvPrefix - префикс переменных окружения, переопределяющих конфиг: srv-port задаётся через CRYPTORATE_SRV_PORT
+	EnvPrefix = "CRYPTORATE"
+	// ConfigEnv - переменная окружения с путём к файлу конфига, если он не передан флагом --config
+	ConfigEnv = EnvPrefix + "_CONFIG"
+)
+
 type Config struct {
 	Cfg struct {
-		Port   string `mapstructure:"srv-port"`
-		PgUser string `mapstructure:"pg-user"`
-		PgPswd string `mapstructure:"pg-pswd"`
-		PgDB   string `mapstructure:"pg-db"`
-		PgHost string `mapstructure:"pg-host"`
-		PgPort int    `mapstructure:"pg-port"`
-		Url    string `mapstructure:"url"`
-		ApiKey string `mapstructure:"api-key"`
+		Mode     string `mapstructure:"mode"`
+		ReadOnly bool   `mapstructure:"read-only"`
+
+		Port     string `mapstructure:"srv-port"`
+		GrpcPort string `mapstructure:"grpc-port"`
+		PgUser   string `mapstructure:"pg-user"`
+		PgPswd   string `mapstructure:"pg-pswd"`
+		PgDB     string `mapstructure:"pg-db"`
+		PgHost   string `mapstructure:"pg-host"`
+		PgPort   int    `mapstructure:"pg-port"`
+		Url      string `mapstructure:"url"`
+		ApiKey   string `mapstructure:"api-key"`
+		Quote    string `mapstructure:"quote"`
+
+		ProviderTimeout time.Duration `mapstructure:"provider-timeout"`
+		ShutdownTimeout time.Duration `mapstructure:"shutdown-timeout"`
+
+		ActualizeSchedule      string        `mapstructure:"actualize-schedule"`
+		LeaderElectionInterval time.Duration `mapstructure:"leader-election-interval"`
+
+		HistoryUrl  string  `mapstructure:"history-url"`
+		BackfillRps float64 `mapstructure:"backfill-rps"`
+
+		AssetsUrl             string        `mapstructure:"assets-url"`
+		AssetsSyncSchedule    string        `mapstructure:"assets-sync-schedule"`
+		AssetsRefreshInterval time.Duration `mapstructure:"assets-refresh-interval"`
+
+		LeaderLockKey int64 `mapstructure:"leader-lock-key"`
+
+		RawRetention    time.Duration `mapstructure:"raw-retention"`
+		HourlyRetention time.Duration `mapstructure:"hourly-retention"`
+		CompactSchedule string        `mapstructure:"compact-schedule"`
+		PartitionsAhead int           `mapstructure:"partitions-ahead"`
+
+		ProviderFailureThreshold int           `mapstructure:"provider-failure-threshold"`
+		ProviderCooldown         time.Duration `mapstructure:"provider-cooldown"`
+		ReadyMaxLag              time.Duration `mapstructure:"ready-max-lag"`
+		StatusMaxLag             time.Duration `mapstructure:"status-max-lag"`
+
+		AuthEnabled   bool          `mapstructure:"auth-enabled"`
+		AuthRateLimit int           `mapstructure:"auth-rate-limit"`
+		AuthCacheTTL  time.Duration `mapstructure:"auth-cache-ttl"`
+
+		RateLimitEnabled       bool                      `mapstructure:"rate-limit-enabled"`
+		RateLimitRps           float64                   `mapstructure:"rate-limit-rps"`
+		RateLimitBurst         int                       `mapstructure:"rate-limit-burst"`
+		RateLimitRoutes        map[string]RouteRateLimit `mapstructure:"rate-limit-routes"`
+		RateLimitProviderRps   float64                   `mapstructure:"rate-limit-provider-rps"`
+		RateLimitProviderBurst int                       `mapstructure:"rate-limit-provider-burst"`
+		RateLimitTrustProxy    bool                      `mapstructure:"rate-limit-trust-proxy"`
+
+		CorsAllowedOrigins []string      `mapstructure:"cors-allowed-origins"`
+		CorsMaxAge         time.Duration `mapstructure:"cors-max-age"`
+
+		MaxQueryRange time.Duration `mapstructure:"max-query-range"`
+
+		TraceExporter    string  `mapstructure:"trace-exporter"`
+		TraceFile        string  `mapstructure:"trace-file"`
+		TraceEndpoint    string  `mapstructure:"trace-endpoint"`
+		TraceSampleRatio float64 `mapstructure:"trace-sample-ratio"`
+
+		LogLevel              string `mapstructure:"log-level"`
+		LogFormat             string `mapstructure:"log-format"`
+		LogOutput             string `mapstructure:"log-output"`
+		LogFile               string `mapstructure:"log-file"`
+		LogMaxSize            int    `mapstructure:"log-max-size"`
+		LogMaxAge             int    `mapstructure:"log-max-age"`
+		LogMaxBackups         int    `mapstructure:"log-max-backups"`
+		LogCompress           bool   `mapstructure:"log-compress"`
+		LogSamplingInitial    int    `mapstructure:"log-sampling-initial"`
+		LogSamplingThereafter int    `mapstructure:"log-sampling-thereafter"`
 	} `mapstructure:"cfg"`
+
+	// File - прочитанный файл конфига; пустой, если конфиг собран из значений по умолчанию и окружения
+	File string `mapstructure:"-"`
+}
+
+// RouteRateLimit - бюджет запросов к одному HTTP-маршруту, например /api/v1/rates/query
+type RouteRateLimit struct {
+	Rps   float64 `mapstructure:"rps"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// defaults - значения, с которыми приложение запускается без файла конфига;
+// переменные окружения подхватываются только для ключей, перечисленных здесь
+var defaults = map[string]interface{}{
+	"mode":      "all",
+	"read-only": false,
+
+	"srv-port":  ":8080",
+	"grpc-port": ":9090",
+	"pg-user":   "user",
+	"pg-pswd":   "",
+	"pg-db":     "crypto_rate",
+	"pg-host":   "localhost",
+	"pg-port":   5432,
+	"url":       "https://min-api.cryptocompare.com/data/pricemultifull",
+	"api-key":   "",
+	"quote":     "USD",
+
+	"provider-timeout": 10 * time.Second,
+	"shutdown-timeout": 10 * time.Second,
+
+	"actualize-schedule":       "@every 1m",
+	"leader-election-interval": 10 * time.Second,
+
+	"history-url":  "https://min-api.cryptocompare.com/data/v2",
+	"backfill-rps": 5.0,
+
+	"assets-url":              "https://min-api.cryptocompare.com/data/all/coinlist",
+	"assets-sync-schedule":    "@every 6h",
+	"assets-refresh-interval": 5 * time.Minute,
+
+	"leader-lock-key": 7310,
+
+	"raw-retention":    168 * time.Hour,
+	"hourly-retention": 2160 * time.Hour,
+	"compact-schedule": "@every 1h",
+	"partitions-ahead": 3,
+
+	"provider-failure-threshold": 5,
+	"provider-cooldown":          30 * time.Second,
+	"ready-max-lag":              time.Duration(0),
+	"status-max-lag":             5 * time.Minute,
+
+	"auth-enabled":    false,
+	"auth-rate-limit": 600,
+	"auth-cache-ttl":  30 * time.Second,
+
+	"rate-limit-enabled":        true,
+	"rate-limit-rps":            10.0,
+	"rate-limit-burst":          20,
+	"rate-limit-routes":         map[string]interface{}{},
+	"rate-limit-provider-rps":   0.1,
+	"rate-limit-provider-burst": 5,
+	"rate-limit-trust-proxy":    false,
+
+	"cors-allowed-origins": []string{},
+	"cors-max-age":         10 * time.Minute,
+
+	"max-query-range": 744 * time.Hour,
+
+	"trace-exporter":     "none",
+	"trace-file":         "./traces.json",
+	"trace-endpoint":     "localhost:4317",
+	"trace-sample-ratio": 1.0,
+
+	"log-level":               "info",
+	"log-format":              logger.FormatConsole,
+	"log-output":              logger.OutputStdout,
+	"log-file":                "./logs/app.log",
+	"log-max-size":            10,
+	"log-max-age":             7,
+	"log-max-backups":         0,
+	"log-compress":            false,
+	"log-sampling-initial":    0,
+	"log-sampling-thereafter": 0,
 }
 
-func LoadCfg() *Config {
+// secrets можно передать файлом: значение api-key читается из файла по пути api-key-file
+// (или CRYPTORATE_API_KEY_FILE), как принято для docker/kubernetes secrets
+var secrets = []string{"api-key", "pg-pswd"}
+
+// LoadCfg читает конфиг из файла path, из CRYPTORATE_CONFIG или из ./config/cfg.yaml и ./cfg.yaml,
+// накладывает переменные окружения CRYPTORATE_* и проверяет результат.
+// Явно указанный файл обязан существовать; без него используются значения по умолчанию.
+// Непустой mode (режим из командной строки) заменяет mode из конфига, чтобы проверялся режим, в котором приложение запускается
+func LoadCfg(path, mode string) (*Config, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault("cfg."+key, value)
+	}
+	for _, key := range secrets {
+		v.SetDefault("cfg."+key+"-file", "")
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	// viper ищет переменную по полному ключу в верхнем регистре: CRYPTORATE_CFG.SRV-PORT -> CRYPTORATE_SRV_PORT
+	v.SetEnvKeyReplacer(strings.NewReplacer("CFG.", "", "-", "_"))
+	v.AutomaticEnv()
+
+	if path == "" {
+		path = os.Getenv(ConfigEnv)
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, errors.Wrapf(err, "failed to read config file %v", path)
+		}
+	} else {
+		v.SetConfigName("cfg")
+		v.SetConfigType("yaml")
+		v.AddConfigPath("./config")
+		v.AddConfigPath(".")
+		if err := v.ReadInConfig(); err != nil {
+			var notFound viper.ConfigFileNotFoundError
+			if !errors.As(err, &notFound) {
+				return nil, errors.Wrap(err, "failed to read config file")
+			}
+
+			log.Info("(LoadCfg) config file not found, using defaults and environment")
+		}
+	}
+
+	if err := readSecrets(v); err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, errors.Wrap(err, "failed to decode config")
+	}
+	cfg.File = v.ConfigFileUsed()
+	if mode != "" {
+		cfg.Cfg.Mode = mode
+	}
 
-	viper.SetConfigFile("D:\\PROGRAMMING\\GO\\go-projects\\kursy-kriptovalyut\\config\\cfg.yaml")
-	// viper.SetConfigFile("./cfg.yaml")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
 
-	err := viper.ReadInConfig()
-	if err != nil {
-		log.Warn("(LoadCfg) failed to find cfg.yaml file:", zap.Any("err", err.Error()))
+func readSecrets(v *viper.Viper) error {
+	for _, key := range secrets {
+		path := v.GetString("cfg." + key + "-file")
+		if path == "" {
+			continue
+		}
+
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %v from file", key)
+		}
+		v.Set("cfg."+key, strings.TrimSpace(string(secret)))
+	}
+
+	return nil
+}
+
+// Validate проверяет конфиг целиком и перечисляет все найденные ошибки, а не только первую
+func (c *Config) Validate() error {
+	cfg := c.Cfg
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, errors.Errorf(format, args...).Error())
+		}
 	}
 
-	err = viper.Unmarshal(cfg)
-	if err != nil {
-		log.Warn("(LoadCfg) failed to load cfg.yaml file:", zap.Any("err", err.Error()))
+	check(cfg.Mode == "serve" || cfg.Mode == "worker" || cfg.Mode == "all", "mode: must be serve, worker or all, got %q", cfg.Mode)
+
+	check(validAddr(cfg.Port), "srv-port: must be a listen address like ':8080', got %q", cfg.Port)
+	check(validAddr(cfg.GrpcPort), "grpc-port: must be a listen address like ':9090', got %q", cfg.GrpcPort)
+
+	check(cfg.PgHost != "", "pg-host: must not be empty")
+	check(cfg.PgPort > 0 && cfg.PgPort < 65536, "pg-port: must be between 1 and 65535, got %d", cfg.PgPort)
+	check(cfg.PgUser != "", "pg-user: must not be empty")
+	check(cfg.PgDB != "", "pg-db: must not be empty")
+
+	// в режиме serve с read-only провайдер не вызывается, и ключ API не нужен
+	if cfg.Mode != "serve" || !cfg.ReadOnly {
+		check(cfg.ApiKey != "", "api-key: must be set (%v_API_KEY or %v_API_KEY_FILE)", EnvPrefix, EnvPrefix)
 	}
+	check(cfg.Url != "", "url: must not be empty")
+	check(cfg.HistoryUrl != "", "history-url: must not be empty")
+	check(cfg.AssetsUrl != "", "assets-url: must not be empty")
+	check(len(cfg.Quote) >= 3 && strings.ToUpper(cfg.Quote) == cfg.Quote, "quote: must be an upper-case currency code, got %q", cfg.Quote)
+
+	check(cfg.ProviderTimeout > 0, "provider-timeout: must be positive, got %v", cfg.ProviderTimeout)
+	check(cfg.ShutdownTimeout > 0, "shutdown-timeout: must be positive, got %v", cfg.ShutdownTimeout)
+
+	check(validSchedule(cfg.ActualizeSchedule), "actualize-schedule: invalid cron spec %q", cfg.ActualizeSchedule)
+	check(validSchedule(cfg.CompactSchedule), "compact-schedule: invalid cron spec %q", cfg.CompactSchedule)
+	check(validSchedule(cfg.AssetsSyncSchedule), "assets-sync-schedule: invalid cron spec %q", cfg.AssetsSyncSchedule)
+	check(cfg.AssetsRefreshInterval > 0, "assets-refresh-interval: must be positive, got %v", cfg.AssetsRefreshInterval)
+	check(cfg.LeaderElectionInterval > 0, "leader-election-interval: must be positive, got %v", cfg.LeaderElectionInterval)
+
+	check(cfg.BackfillRps > 0, "backfill-rps: must be positive, got %v", cfg.BackfillRps)
+	check(cfg.RawRetention > 0, "raw-retention: must be positive, got %v", cfg.RawRetention)
+	check(cfg.HourlyRetention > cfg.RawRetention, "hourly-retention: must be longer than raw-retention (%v), got %v", cfg.RawRetention, cfg.HourlyRetention)
+	check(cfg.PartitionsAhead > 0, "partitions-ahead: must be positive, got %d", cfg.PartitionsAhead)
+
+	check(cfg.ProviderFailureThreshold > 0, "provider-failure-threshold: must be positive, got %d", cfg.ProviderFailureThreshold)
+	check(cfg.ProviderCooldown > 0, "provider-cooldown: must be positive, got %v", cfg.ProviderCooldown)
+	check(cfg.ReadyMaxLag >= 0, "ready-max-lag: must not be negative, got %v", cfg.ReadyMaxLag)
+	check(cfg.StatusMaxLag >= 0, "status-max-lag: must not be negative, got %v", cfg.StatusMaxLag)
+
+	check(cfg.AuthRateLimit > 0, "auth-rate-limit: must be positive, got %d", cfg.AuthRateLimit)
+	check(cfg.AuthCacheTTL >= 0, "auth-cache-ttl: must not be negative, got %v", cfg.AuthCacheTTL)
+
+	check(cfg.RateLimitRps > 0, "rate-limit-rps: must be positive, got %v", cfg.RateLimitRps)
+	check(cfg.RateLimitBurst > 0, "rate-limit-burst: must be positive, got %d", cfg.RateLimitBurst)
+	for route, limit := range cfg.RateLimitRoutes {
+		check(strings.HasPrefix(route, "/"), "rate-limit-routes: route must start with '/', got %q", route)
+		check(limit.Rps > 0 && limit.Burst > 0, "rate-limit-routes: rps and burst for %v must be positive, got %v and %d", route, limit.Rps, limit.Burst)
+	}
+	check(cfg.RateLimitProviderRps > 0, "rate-limit-provider-rps: must be positive, got %v", cfg.RateLimitProviderRps)
+	check(cfg.RateLimitProviderBurst > 0, "rate-limit-provider-burst: must be positive, got %d", cfg.RateLimitProviderBurst)
+
+	for _, origin := range cfg.CorsAllowedOrigins {
+		check(validOrigin(origin), "cors-allowed-origins: must be '*' or an origin like 'https://example.com', got %q", origin)
+	}
+	check(cfg.CorsMaxAge >= 0, "cors-max-age: must not be negative, got %v", cfg.CorsMaxAge)
+	check(cfg.MaxQueryRange >= 0, "max-query-range: must not be negative, got %v", cfg.MaxQueryRange)
+
+	check(cfg.TraceExporter == "none" || cfg.TraceExporter == "stdout" || cfg.TraceExporter == "file" || cfg.TraceExporter == "otlp",
+		"trace-exporter: must be none, stdout, file or otlp, got %q", cfg.TraceExporter)
+	check(cfg.TraceSampleRatio >= 0 && cfg.TraceSampleRatio <= 1, "trace-sample-ratio: must be between 0 and 1, got %v", cfg.TraceSampleRatio)
+
+	_, err := zapcore.ParseLevel(cfg.LogLevel)
+	check(err == nil, "log-level: must be debug, info, warn or error, got %q", cfg.LogLevel)
+	check(cfg.LogFormat == logger.FormatConsole || cfg.LogFormat == logger.FormatJSON, "log-format: must be console or json, got %q", cfg.LogFormat)
+	check(cfg.LogOutput == logger.OutputStdout || cfg.LogOutput == logger.OutputFile || cfg.LogOutput == logger.OutputBoth,
+		"log-output: must be stdout, file or both, got %q", cfg.LogOutput)
+	if cfg.LogOutput != logger.OutputStdout {
+		check(cfg.LogFile != "", "log-file: must be set when log-output is %q", cfg.LogOutput)
+	}
+	check(cfg.LogSamplingInitial >= 0 && cfg.LogSamplingThereafter >= 0,
+		"log-sampling-initial, log-sampling-thereafter: must not be negative, got %d and %d", cfg.LogSamplingInitial, cfg.LogSamplingThereafter)
+	check((cfg.LogSamplingInitial == 0) == (cfg.LogSamplingThereafter == 0),
+		"log-sampling-initial, log-sampling-thereafter: must be both set or both 0, got %d and %d", cfg.LogSamplingInitial, cfg.LogSamplingThereafter)
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid config:\n\t- %s", strings.Join(problems, "\n\t- "))
+	}
+
+	return nil
+}
+
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	return err == nil && port != ""
+}
+
+// validOrigin принимает "*" или источник - схему и хост без пути
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && strings.TrimSuffix(u.Path, "/") == "" && u.RawQuery == ""
+}
 
-	return cfg
+func validSchedule(spec string) bool {
+	_, err := cron.Parse(spec)
+	return err == nil
 }
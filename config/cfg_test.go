@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/config"
+)
+
+func writeCfg(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("cfg:\n"+body), 0o600))
+
+	return path
+}
+
+func TestLoadCfg(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		body     string
+		mode     string
+		wantMode string
+		// wantErr - фрагменты, которые должны быть в тексте ошибки
+		wantErr []string
+	}{
+		{name: "defaults", body: "  api-key: secret\n", wantMode: "all"},
+		{name: "mode from file", body: "  api-key: secret\n  mode: worker\n", wantMode: "worker"},
+		{name: "command line mode wins", body: "  api-key: secret\n  mode: worker\n", mode: "serve", wantMode: "serve"},
+		{name: "read-only serve needs no api key", body: "  read-only: true\n", mode: "serve", wantMode: "serve"},
+		{name: "read-only is ignored outside serve", body: "  read-only: true\n  mode: serve\n", mode: "all", wantErr: []string{"api-key:"}},
+		{name: "unknown command line mode", body: "  api-key: secret\n", mode: "server", wantErr: []string{`mode: must be serve, worker or all, got "server"`}},
+		{name: "hourly retention equal to raw", body: "  api-key: secret\n  raw-retention: 24h\n  hourly-retention: 24h\n", wantErr: []string{"hourly-retention: must be longer than raw-retention"}},
+		{name: "sampling needs both values", body: "  api-key: secret\n  log-sampling-initial: 100\n  log-sampling-thereafter: 0\n", wantErr: []string{"log-sampling-initial, log-sampling-thereafter: must be both set or both 0"}},
+		{
+			name:    "lists all problems",
+			body:    "  api-key: secret\n  pg-port: 0\n  log-level: loud\n  rate-limit-routes:\n    api/v1/rates:\n      rps: 1\n      burst: 1\n",
+			wantErr: []string{"pg-port:", "log-level:", "rate-limit-routes: route must start with '/'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := config.LoadCfg(writeCfg(t, tt.body), tt.mode)
+			if len(tt.wantErr) > 0 {
+				require.Error(t, err)
+				for _, want := range tt.wantErr {
+					require.Contains(t, err.Error(), want)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantMode, cfg.Cfg.Mode)
+		})
+	}
+}
+
+func TestLoadCfg_MissingFile(t *testing.T) {
+	t.Parallel()
+	_, err := config.LoadCfg(filepath.Join(t.TempDir(), "missing.yaml"), "")
+	require.Error(t, err)
+}
+
+func TestLoadCfg_SecretFromFile(t *testing.T) {
+	t.Parallel()
+	secret := filepath.Join(t.TempDir(), "api-key")
+	require.NoError(t, os.WriteFile(secret, []byte("secret\n"), 0o600))
+
+	cfg, err := config.LoadCfg(writeCfg(t, "  api-key-file: "+secret+"\n"), "")
+	require.NoError(t, err)
+	require.Equal(t, "secret", cfg.Cfg.ApiKey)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	valid, err := config.LoadCfg(writeCfg(t, "  api-key: secret\n"), "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *config.Config)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*config.Config) {}},
+		{name: "hourly retention longer than raw", modify: func(cfg *config.Config) { cfg.Cfg.HourlyRetention = cfg.Cfg.RawRetention + 1 }},
+		{name: "hourly retention shorter than raw", modify: func(cfg *config.Config) { cfg.Cfg.HourlyRetention = cfg.Cfg.RawRetention - 1 }, wantErr: "hourly-retention:"},
+		{name: "bad listen address", modify: func(cfg *config.Config) { cfg.Cfg.Port = "8080" }, wantErr: "srv-port:"},
+		{name: "bad schedule", modify: func(cfg *config.Config) { cfg.Cfg.CompactSchedule = "every day" }, wantErr: "compact-schedule:"},
+		{name: "negative ready lag", modify: func(cfg *config.Config) { cfg.Cfg.ReadyMaxLag = -1 }, wantErr: "ready-max-lag:"},
+		{name: "log file required", modify: func(cfg *config.Config) { cfg.Cfg.LogOutput = "file"; cfg.Cfg.LogFile = "" }, wantErr: "log-file:"},
+		{name: "cors origins", modify: func(cfg *config.Config) {
+			cfg.Cfg.CorsAllowedOrigins = []string{"*", "https://app.example.com", "http://localhost:3000/"}
+		}},
+		{name: "cors origin with path", modify: func(cfg *config.Config) { cfg.Cfg.CorsAllowedOrigins = []string{"https://app.example.com/api"} }, wantErr: "cors-allowed-origins:"},
+		{name: "cors origin without scheme", modify: func(cfg *config.Config) { cfg.Cfg.CorsAllowedOrigins = []string{"app.example.com"} }, wantErr: "cors-allowed-origins:"},
+		{name: "negative max query range", modify: func(cfg *config.Config) { cfg.Cfg.MaxQueryRange = -time.Hour }, wantErr: "max-query-range:"},
+		{name: "trace sample ratio out of range", modify: func(cfg *config.Config) { cfg.Cfg.TraceSampleRatio = 2 }, wantErr: "trace-sample-ratio:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := *valid
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// reloadable - ключи, которые применяются без перезапуска: они не затрагивают открытые соединения,
+// пул Postgres и адреса серверов. Изменения остальных ключей при перезагрузке отклоняются.
+// Адреса и ключ провайдера подменяются для следующих запросов; quote требует перезапуска,
+// потому что цены в хранилище записаны в прежней валюте
+var reloadable = map[string]bool{
+	"log-level":               true,
+	"log-format":              true,
+	"log-output":              true,
+	"log-file":                true,
+	"log-max-size":            true,
+	"log-max-age":             true,
+	"log-max-backups":         true,
+	"log-compress":            true,
+	"log-sampling-initial":    true,
+	"log-sampling-thereafter": true,
+
+	"actualize-schedule":   true,
+	"compact-schedule":     true,
+	"assets-sync-schedule": true,
+
+	"url":              true,
+	"history-url":      true,
+	"assets-url":       true,
+	"api-key":          true,
+	"provider-timeout": true,
+
+	"provider-failure-threshold": true,
+	"provider-cooldown":          true,
+	"ready-max-lag":              true,
+	"status-max-lag":             true,
+
+	"rate-limit-rps":            true,
+	"rate-limit-burst":          true,
+	"rate-limit-routes":         true,
+	"rate-limit-provider-rps":   true,
+	"rate-limit-provider-burst": true,
+
+	"cors-allowed-origins": true,
+	"cors-max-age":         true,
+
+	"max-query-range": true,
+}
+
+// watchDebounce - события файловой системы при сохранении файла приходят пачкой
+const watchDebounce = 200 * time.Millisecond
+
+// Change - изменение одного ключа конфига
+type Change struct {
+	Key string
+	Old interface{}
+	New interface{}
+}
+
+// Reloadable сообщает, можно ли применить изменение без перезапуска
+func (c Change) Reloadable() bool {
+	return reloadable[c.Key]
+}
+
+func (c Change) String() string {
+	for _, key := range secrets {
+		if c.Key == key {
+			return c.Key + ": *** -> ***"
+		}
+	}
+
+	return fmt.Sprintf("%v: %v -> %v", c.Key, c.Old, c.New)
+}
+
+// Diff перечисляет ключи, значения которых в next отличаются от cur
+func Diff(cur, next *Config) []Change {
+	curCfg := reflect.ValueOf(cur.Cfg)
+	nextCfg := reflect.ValueOf(next.Cfg)
+
+	var changes []Change
+	for i := 0; i < curCfg.NumField(); i++ {
+		oldValue, newValue := curCfg.Field(i).Interface(), nextCfg.Field(i).Interface()
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, Change{Key: curCfg.Type().Field(i).Tag.Get("mapstructure"), Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes
+}
+
+// Apply возвращает копию cur, в которой изменены только ключи из changes
+func Apply(cur *Config, changes []Change) *Config {
+	next := *cur
+	cfg := reflect.ValueOf(&next.Cfg).Elem()
+	for _, change := range changes {
+		for i := 0; i < cfg.NumField(); i++ {
+			if cfg.Type().Field(i).Tag.Get("mapstructure") == change.Key {
+				cfg.Field(i).Set(reflect.ValueOf(change.New))
+			}
+		}
+	}
+
+	return &next
+}
+
+// Watch вызывает onChange после каждого изменения файла конфига, пока не отменён ctx.
+// Следится каталог, а не сам файл: редакторы и kubernetes заменяют файл, а не пишут в него
+func Watch(ctx context.Context, path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create config watcher")
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		watcher.Close()
+		return errors.Wrapf(err, "failed to resolve config file %v", path)
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return errors.Wrapf(err, "failed to watch config file %v", path)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn("(config.Watch) watcher error", zap.Any("err", err.Error()))
+			case <-debounce:
+				debounce = nil
+				onChange()
+			}
+		}
+	}()
+
+	return nil
+}
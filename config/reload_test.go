@@ -0,0 +1,99 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/config"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	cur := &config.Config{}
+	cur.Cfg.LogLevel = "info"
+	cur.Cfg.PgPort = 5432
+	cur.Cfg.ApiKey = "old"
+	cur.Cfg.RateLimitRoutes = map[string]config.RouteRateLimit{"/api/v1/rates": {Rps: 1, Burst: 2}}
+
+	next := *cur
+	next.Cfg.LogLevel = "debug"
+	next.Cfg.PgPort = 5433
+	next.Cfg.ApiKey = "new"
+	next.Cfg.RateLimitRoutes = map[string]config.RouteRateLimit{"/api/v1/rates": {Rps: 1, Burst: 5}}
+
+	changes := config.Diff(cur, &next)
+	keys := make(map[string]config.Change, len(changes))
+	for _, change := range changes {
+		keys[change.Key] = change
+	}
+
+	require.Len(t, changes, 4)
+	require.Equal(t, "info", keys["log-level"].Old)
+	require.Equal(t, "debug", keys["log-level"].New)
+	require.True(t, keys["log-level"].Reloadable())
+	require.True(t, keys["rate-limit-routes"].Reloadable())
+	require.False(t, keys["pg-port"].Reloadable())
+	require.Equal(t, "pg-port: 5432 -> 5433", keys["pg-port"].String())
+	// значения секретов в лог не попадают
+	require.Equal(t, "api-key: *** -> ***", keys["api-key"].String())
+
+	require.Empty(t, config.Diff(cur, cur))
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+	cur := &config.Config{File: "cfg.yaml"}
+	cur.Cfg.LogLevel = "info"
+	cur.Cfg.PgPort = 5432
+	cur.Cfg.ProviderCooldown = time.Second
+
+	next := *cur
+	next.Cfg.LogLevel = "debug"
+	next.Cfg.PgPort = 5433
+	next.Cfg.ProviderCooldown = time.Minute
+
+	var safe []config.Change
+	for _, change := range config.Diff(cur, &next) {
+		if change.Reloadable() {
+			safe = append(safe, change)
+		}
+	}
+
+	applied := config.Apply(cur, safe)
+	require.Equal(t, "debug", applied.Cfg.LogLevel)
+	require.Equal(t, time.Minute, applied.Cfg.ProviderCooldown)
+	// ключи, требующие перезапуска, остаются прежними
+	require.Equal(t, 5432, applied.Cfg.PgPort)
+	require.Equal(t, "cfg.yaml", applied.File)
+
+	// исходный конфиг не меняется
+	require.Equal(t, "info", cur.Cfg.LogLevel)
+	require.Equal(t, time.Second, cur.Cfg.ProviderCooldown)
+}
+
+func TestChange_Reloadable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "url", want: true},
+		{key: "api-key", want: true},
+		{key: "provider-timeout", want: true},
+		{key: "cors-allowed-origins", want: true},
+		{key: "cors-max-age", want: true},
+		{key: "max-query-range", want: true},
+		// цены в хранилище записаны в прежней валюте
+		{key: "quote", want: false},
+		{key: "srv-port", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, config.Change{Key: tt.key}.Reloadable())
+		})
+	}
+}
@@ -7,133 +7,208 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
+	"kursy-kriptovalyut/internal/tracing"
 	"kursy-kriptovalyut/pkg/logger"
 )
 
 var log = logger.NewLogger()
 
-type CryptoCompare struct {
+var tracer = otel.Tracer("kursy-kriptovalyut/internal/adapters/provider")
+
+// newHTTPClient создаёт клиент, который передаёт провайдеру контекст трассировки (W3C traceparent)
+// и оборачивает каждый запрос в span
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport), Timeout: timeout}
+}
+
+// endpoint - адрес, ключ API и HTTP-клиент провайдера. При перезагрузке конфига заменяется целиком,
+// чтобы запрос не ушёл по новому адресу со старым ключом
+type endpoint struct {
 	baseUrl    string
 	apiKey     string
 	httpClient *http.Client
 }
 
-func NewCryptoCompare(baseUrl string, apiKey string) (*CryptoCompare, error) {
+func newEndpoint(baseUrl string, apiKey string, timeout time.Duration) (*endpoint, error) {
 	if baseUrl == "" || apiKey == "" {
 		return nil, errors.Wrap(entities.ErrInvalidParam, "base-url/api-key is empty")
 	}
 
-	return &CryptoCompare{
+	if timeout <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "timeout must be positive")
+	}
+
+	return &endpoint{
 		baseUrl:    baseUrl,
 		apiKey:     apiKey,
-		httpClient: &http.Client{},
+		httpClient: newHTTPClient(timeout),
 	}, nil
 }
 
+const SourceCryptoCompare = "cryptocompare"
+
+type CryptoCompare struct {
+	endpoint atomic.Pointer[endpoint]
+	quote    string
+}
+
+// NewCryptoCompare - провайдер актуальных курсов; цены запрашиваются в валюте quote,
+// запрос к провайдеру прерывается по истечении timeout
+func NewCryptoCompare(baseUrl string, apiKey string, quote string, timeout time.Duration) (*CryptoCompare, error) {
+	if quote == "" {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "quote is empty")
+	}
+
+	cc := &CryptoCompare{quote: quote}
+	if err := cc.SetEndpoint(baseUrl, apiKey, timeout); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// SetEndpoint меняет адрес, ключ API и таймаут провайдера; запросы, начатые до смены, завершаются с прежними
+func (cc *CryptoCompare) SetEndpoint(baseUrl string, apiKey string, timeout time.Duration) error {
+	ep, err := newEndpoint(baseUrl, apiKey, timeout)
+	if err != nil {
+		return err
+	}
+
+	cc.endpoint.Store(ep)
+	return nil
+}
+
 const (
 	fromSyms = "fsyms"
 	toSyms   = "tsyms"
-	currency = "USD"
 	max      = "MAX"
 	min      = "MIN"
 	avg      = "AVG"
 )
 
 func (cc *CryptoCompare) GetActualRates(ctx context.Context, titles []string, extraArg string) ([]entities.Coin, error) {
-	rawURL, err := url.Parse(cc.baseUrl)
+	ctx, span := tracer.Start(ctx, "CryptoCompare.GetActualRates", trace.WithAttributes(attribute.StringSlice("coin.titles", titles)))
+	start := time.Now()
+	coins, err := cc.getActualRates(ctx, titles, extraArg)
+	metrics.ObserveProviderCall(SourceCryptoCompare, "actual_rates", start, err)
+	tracing.End(span, err)
+
+	return coins, err
+}
+
+func (cc *CryptoCompare) getActualRates(ctx context.Context, titles []string, extraArg string) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	ep := cc.endpoint.Load()
+	rawURL, err := url.Parse(ep.baseUrl)
 	if err != nil {
-		log.Error("(GetActualRates) failed to parse base url:", zap.Any("url", cc.baseUrl))
+		log.Error("(GetActualRates) failed to parse base url:", zap.Any("url", ep.baseUrl), zap.Any("err", err.Error()))
 		return nil, errors.Wrapf(entities.ErrInternal, "failed to parse url: %v", err)
 	}
 
 	// manual raw query
-	// rawQuery := fmt.Sprintf("%s=%s&%s=%s", fromSyms, strings.Join(titles, ","), toSyms, currency)
+	// rawQuery := fmt.Sprintf("%s=%s&%s=%s", fromSyms, strings.Join(titles, ","), toSyms, cc.quote)
 	// rawURL.RawQuery = rawQuery
 
 	queries := rawURL.Query()
 	queries.Add(fromSyms, strings.Join(titles, ","))
-	queries.Add(toSyms, currency)
+	queries.Add(toSyms, cc.quote)
 
 	rawURL.RawQuery, err = url.QueryUnescape(queries.Encode())
 	if err != nil {
-		log.Error("(GetActualRates) failed to encode url:", zap.Any("url", rawURL.String()))
+		log.Error("(GetActualRates) failed to encode url:", zap.Any("url", rawURL.String()), zap.Any("err", err.Error()))
 		return nil, errors.Wrapf(entities.ErrInternal, "failed to encode url: %v", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL.String(), nil)
 	if err != nil {
-		log.Error("(GetActualRates) failed to create new request")
+		log.Error("(GetActualRates) failed to create new request", zap.Any("err", err.Error()))
 		return nil, errors.Wrapf(entities.ErrInternal, "failed to create new request, err: %v", err)
 	}
 
-	req.Header.Set("Authorization", "Apikey "+cc.apiKey)
+	req.Header.Set("Authorization", "Apikey "+ep.apiKey)
 
-	resp, err := cc.httpClient.Do(req)
+	resp, err := ep.httpClient.Do(req)
 	if err != nil {
-		log.Error("(GetActualRates) failed to execute request")
-		return nil, errors.Wrapf(entities.ErrInternal, "failed to execute request, err: %v", err)
+		log.Error("(GetActualRates) failed to execute request", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to execute request, err: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		log.Info("(GetActualRates) unexpected status code:", zap.Any("statusCode", resp.StatusCode))
-		return nil, errors.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "unexpected status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error("(GetActualRates) failed to read response body")
-		return nil, errors.Wrap(err, "failed to read response body")
+		log.Error("(GetActualRates) failed to read response body", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to read response body: %v", err)
 	}
 
 	strBody := string(body)
 	if strings.Contains(strBody, `"Response":"Error"`) {
-		log.Error("(GetActualRates) non-existing coin(s):", zap.Any("coinTitle(s)", titles))
-		return nil, errors.Wrapf(entities.ErrNotFound, "coin %v does not exist", titles)
+		err := errors.Wrapf(entities.ErrUnknownSymbol, "coin %v does not exist", titles)
+		log.Error("(GetActualRates) non-existing coin(s):", zap.Any("coinTitle(s)", titles), zap.Any("err", err.Error()))
+		return nil, err
 	}
 
+	// RAW: монета -> валюта котировки -> цены
 	type CryptoData struct {
-		RAW map[string]struct {
-			USD struct {
-				PRICE   float64 `json:"PRICE"`
-				HIGHDAY float64 `json:"HIGHDAY"`
-				LOWDAY  float64 `json:"LOWDAY"`
-			}
+		RAW map[string]map[string]struct {
+			PRICE   float64 `json:"PRICE"`
+			HIGHDAY float64 `json:"HIGHDAY"`
+			LOWDAY  float64 `json:"LOWDAY"`
 		}
 	}
 
 	var data CryptoData
 	err = json.Unmarshal(body, &data)
 	if err != nil {
-		log.Error("(GetActualRates) failed to parse resp.body")
-		return nil, errors.Wrap(err, "failed to parse response body, invalid JSON format")
+		log.Error("(GetActualRates) failed to parse resp.body", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to parse response body, invalid JSON format: %v", err)
 	}
 
+	now := time.Now()
 	coins := make([]entities.Coin, 0, len(data.RAW))
 	var price float64
-	for coinTitle, info := range data.RAW {
+	for coinTitle, quotes := range data.RAW {
+		info, ok := quotes[cc.quote]
+		if !ok {
+			log.Warn("(GetActualRates) no quote in response:", zap.Any("coinTitle", coinTitle), zap.Any("quote", cc.quote))
+			continue
+		}
+
 		switch extraArg {
 		case max:
-			price = info.USD.HIGHDAY
+			price = info.HIGHDAY
 		case min:
-			price = info.USD.LOWDAY
+			price = info.LOWDAY
 		case avg:
-			price = (info.USD.PRICE + info.USD.HIGHDAY + info.USD.LOWDAY) / 3
+			price = (info.PRICE + info.HIGHDAY + info.LOWDAY) / 3
 		default:
-			price = info.USD.PRICE
+			price = info.PRICE
 		}
 
 		coin, err := entities.NewCoin(coinTitle, price)
 		if err != nil {
-			log.Error("(GetActualRates) failed to create new coin:", zap.Any("newCoin", coin))
+			log.Error("(GetActualRates) failed to create new coin:", zap.Any("newCoin", coin), zap.Any("err", err.Error()))
 			return nil, errors.Wrap(err, "failed to create new coin")
 		}
 
+		coin.CreatedAt = now
+		coin.Source = SourceCryptoCompare
 		coins = append(coins, *coin)
 	}
 
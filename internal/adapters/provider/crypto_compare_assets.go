@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
+	"kursy-kriptovalyut/internal/tracing"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// CryptoCompareAssets - список монет CryptoCompare (all/coinlist) для каталога активов
+type CryptoCompareAssets struct {
+	endpoint atomic.Pointer[endpoint]
+}
+
+func NewCryptoCompareAssets(url string, apiKey string, timeout time.Duration) (*CryptoCompareAssets, error) {
+	cc := &CryptoCompareAssets{}
+	if err := cc.SetEndpoint(url, apiKey, timeout); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// SetEndpoint меняет адрес списка монет, ключ API и таймаут
+func (cc *CryptoCompareAssets) SetEndpoint(url string, apiKey string, timeout time.Duration) error {
+	ep, err := newEndpoint(url, apiKey, timeout)
+	if err != nil {
+		return err
+	}
+
+	cc.endpoint.Store(ep)
+	return nil
+}
+
+func (cc *CryptoCompareAssets) GetAssets(ctx context.Context) ([]entities.Asset, error) {
+	ctx, span := tracer.Start(ctx, "CryptoCompareAssets.GetAssets")
+	start := time.Now()
+	assets, err := cc.getAssets(ctx)
+	metrics.ObserveProviderCall(SourceCryptoCompare, "coin_list", start, err)
+	tracing.End(span, err)
+
+	return assets, err
+}
+
+func (cc *CryptoCompareAssets) getAssets(ctx context.Context) ([]entities.Asset, error) {
+	log := logger.FromContext(ctx)
+	ep := cc.endpoint.Load()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.baseUrl, nil)
+	if err != nil {
+		log.Error("(GetAssets) failed to create new request", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "failed to create new request, err: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Apikey "+ep.apiKey)
+
+	resp, err := ep.httpClient.Do(req)
+	if err != nil {
+		log.Error("(GetAssets) failed to execute request", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to execute request, err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		log.Warn("(GetAssets) provider rate limit exceeded")
+		return nil, errors.Wrap(entities.ErrRateLimited, "provider rate limit exceeded")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		log.Info("(GetAssets) unexpected status code:", zap.Any("statusCode", resp.StatusCode))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error("(GetAssets) failed to read response body", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to read response body: %v", err)
+	}
+
+	type CoinList struct {
+		Response string `json:"Response"`
+		Message  string `json:"Message"`
+		Data     map[string]struct {
+			Id            string `json:"Id"`
+			Symbol        string `json:"Symbol"`
+			CoinName      string `json:"CoinName"`
+			IsTrading     bool   `json:"IsTrading"`
+			DecimalPoints int    `json:"DecimalPoints"`
+		} `json:"Data"`
+	}
+
+	var data CoinList
+	if err := json.Unmarshal(body, &data); err != nil {
+		log.Error("(GetAssets) failed to parse resp.body", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to parse response body, invalid JSON format: %v", err)
+	}
+
+	if data.Response == "Error" {
+		log.Error("(GetAssets) provider error:", zap.Any("msg", data.Message))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to get coin list: %v", data.Message)
+	}
+
+	now := time.Now()
+	assets := make([]entities.Asset, 0, len(data.Data))
+	skipped := 0
+	for _, coin := range data.Data {
+		// монеты, тикер которых нельзя запросить через API, в каталог не попадают;
+		// псевдонимы (XBT) отбрасываются, чтобы не перезаписать основной тикер
+		symbol, err := entities.ParseSymbol(coin.Symbol)
+		if err != nil || string(symbol) != coin.Symbol {
+			skipped++
+			continue
+		}
+
+		status := entities.AssetActive
+		if !coin.IsTrading {
+			status = entities.AssetInactive
+		}
+
+		assets = append(assets, entities.Asset{
+			Symbol:      symbol,
+			Name:        coin.CoinName,
+			ProviderIDs: map[string]string{SourceCryptoCompare: coin.Id},
+			Decimals:    coin.DecimalPoints,
+			Status:      status,
+			UpdatedAt:   now,
+		})
+	}
+
+	if skipped > 0 {
+		log.Info("(GetAssets) coins with unsupported symbols skipped", zap.Any("skipped", skipped))
+	}
+
+	return assets, nil
+}
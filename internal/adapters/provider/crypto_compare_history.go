@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
+	"kursy-kriptovalyut/internal/tracing"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// CryptoCompareHistory - исторические данные CryptoCompare (histominute/histohour/histoday)
+type CryptoCompareHistory struct {
+	endpoint atomic.Pointer[endpoint]
+	quote    string
+}
+
+func NewCryptoCompareHistory(baseUrl string, apiKey string, quote string, timeout time.Duration) (*CryptoCompareHistory, error) {
+	if quote == "" {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "quote is empty")
+	}
+
+	cc := &CryptoCompareHistory{quote: quote}
+	if err := cc.SetEndpoint(baseUrl, apiKey, timeout); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// SetEndpoint меняет адрес, ключ API и таймаут провайдера истории
+func (cc *CryptoCompareHistory) SetEndpoint(baseUrl string, apiKey string, timeout time.Duration) error {
+	ep, err := newEndpoint(baseUrl, apiKey, timeout)
+	if err != nil {
+		return err
+	}
+
+	cc.endpoint.Store(ep)
+	return nil
+}
+
+const historyMaxLimit = 2000
+
+var historyEndpoints = map[entities.Interval]string{
+	entities.IntervalMinute: "histominute",
+	entities.IntervalHour:   "histohour",
+	entities.IntervalDay:    "histoday",
+}
+
+func (cc *CryptoCompareHistory) GetHistory(ctx context.Context, title string, interval entities.Interval, to time.Time, limit int) ([]entities.Coin, error) {
+	ctx, span := tracer.Start(ctx, "CryptoCompareHistory.GetHistory", trace.WithAttributes(
+		attribute.String("coin.title", title),
+		attribute.String("interval", string(interval)),
+	))
+	start := time.Now()
+	coins, err := cc.getHistory(ctx, title, interval, to, limit)
+	metrics.ObserveProviderCall(SourceCryptoCompare, "history", start, err)
+	tracing.End(span, err)
+
+	return coins, err
+}
+
+func (cc *CryptoCompareHistory) getHistory(ctx context.Context, title string, interval entities.Interval, to time.Time, limit int) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	endpoint, ok := historyEndpoints[interval]
+	if !ok {
+		return nil, errors.Wrapf(entities.ErrUnknownInterval, "unsupported interval: %v", interval)
+	}
+
+	if limit < 1 || limit > historyMaxLimit {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "limit must be between 1 and %d", historyMaxLimit)
+	}
+
+	ep := cc.endpoint.Load()
+	rawURL, err := url.Parse(ep.baseUrl)
+	if err != nil {
+		log.Error("(GetHistory) failed to parse base url:", zap.Any("url", ep.baseUrl), zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "failed to parse url: %v", err)
+	}
+	rawURL = rawURL.JoinPath(endpoint)
+
+	queries := rawURL.Query()
+	queries.Add("fsym", title)
+	queries.Add("tsym", cc.quote)
+	queries.Add("limit", strconv.Itoa(limit))
+	queries.Add("toTs", strconv.FormatInt(to.Unix(), 10))
+	rawURL.RawQuery = queries.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL.String(), nil)
+	if err != nil {
+		log.Error("(GetHistory) failed to create new request", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "failed to create new request, err: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Apikey "+ep.apiKey)
+
+	resp, err := ep.httpClient.Do(req)
+	if err != nil {
+		log.Error("(GetHistory) failed to execute request", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to execute request, err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		log.Warn("(GetHistory) provider rate limit exceeded")
+		return nil, errors.Wrap(entities.ErrRateLimited, "provider rate limit exceeded")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		log.Info("(GetHistory) unexpected status code:", zap.Any("statusCode", resp.StatusCode))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error("(GetHistory) failed to read response body", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to read response body: %v", err)
+	}
+
+	type HistoryData struct {
+		Response  string `json:"Response"`
+		Message   string `json:"Message"`
+		RateLimit struct {
+			CallsMade map[string]int `json:"calls_made"`
+		} `json:"RateLimit"`
+		Data struct {
+			Data []struct {
+				Time  int64   `json:"time"`
+				Close float64 `json:"close"`
+			} `json:"Data"`
+		} `json:"Data"`
+	}
+
+	var data HistoryData
+	if err := json.Unmarshal(body, &data); err != nil {
+		log.Error("(GetHistory) failed to parse resp.body", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrProviderUnavailable, "failed to parse response body, invalid JSON format: %v", err)
+	}
+
+	if data.Response == "Error" {
+		// CryptoCompare сообщает о превышении лимита в теле ответа со статусом 200
+		if len(data.RateLimit.CallsMade) > 0 {
+			log.Warn("(GetHistory) provider rate limit exceeded:", zap.Any("msg", data.Message))
+			return nil, errors.Wrap(entities.ErrRateLimited, data.Message)
+		}
+
+		err := errors.Wrapf(entities.ErrUnknownSymbol, "coin %v history not available: %v", title, data.Message)
+		log.Error("(GetHistory) provider error:", zap.Any("coinTitle", title), zap.Any("msg", data.Message), zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	coins := make([]entities.Coin, 0, len(data.Data.Data))
+	for _, point := range data.Data.Data {
+		// до появления монеты на рынке провайдер отдаёт нулевые цены
+		if point.Close <= 0 {
+			continue
+		}
+
+		coins = append(coins, entities.Coin{
+			Title:     title,
+			Price:     point.Close,
+			CreatedAt: time.Unix(point.Time, 0).UTC(),
+			Source:    SourceCryptoCompare,
+		})
+	}
+
+	return coins, nil
+}
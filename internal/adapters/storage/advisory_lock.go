@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// AdvisoryLock - лок лидера на основе pg_try_advisory_lock.
+// Advisory-лок привязан к сессии, поэтому соединение удерживается вне пула,
+// пока реплика остаётся лидером; при обрыве сессии Postgres освобождает лок сам.
+type AdvisoryLock struct {
+	pool *pgxpool.Pool
+	key  int64
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+func NewAdvisoryLock(pg *Postgres, key int64) (*AdvisoryLock, error) {
+	if pg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "postgres not set")
+	}
+
+	return &AdvisoryLock{
+		pool: pg.dbPool,
+		key:  key,
+	}, nil
+}
+
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	log := logger.FromContext(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// лок уже у нас - проверяем, что сессия жива
+	if l.conn != nil {
+		if err := l.conn.Ping(ctx); err != nil {
+			log.Error("(TryLock) leader session is dead", zap.Any("key", l.key), zap.Any("err", err.Error()))
+			_ = l.conn.Conn().Close(ctx)
+			l.conn.Release()
+			l.conn = nil
+			return false, errors.Wrapf(entities.ErrStorageUnavailable, "lost advisory lock session: %v", err)
+		}
+
+		return true, nil
+	}
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		log.Error("(TryLock) failed to acquire connection", zap.Any("err", err.Error()))
+		return false, errors.Wrapf(entities.ErrStorageUnavailable, "failed to acquire connection: %v", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&locked); err != nil {
+		conn.Release()
+		log.Error("(TryLock) failed to execute advisory lock query", zap.Any("key", l.key), zap.Any("err", err.Error()))
+		return false, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	if !locked {
+		conn.Release()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	defer func() {
+		l.conn.Release()
+		l.conn = nil
+	}()
+
+	var unlocked bool
+	if err := l.conn.QueryRow(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&unlocked); err != nil {
+		log.Error("(Unlock) failed to execute advisory unlock query", zap.Any("key", l.key), zap.Any("err", err.Error()))
+		// закрываем сессию, чтобы лок не остался висеть на соединении из пула
+		_ = l.conn.Conn().Close(ctx)
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	if !unlocked {
+		log.Warn("(Unlock) advisory lock was not held", zap.Any("key", l.key))
+	}
+
+	return nil
+}
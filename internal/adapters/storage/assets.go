@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+func (p *Postgres) SaveAssets(ctx context.Context, assets []entities.Asset) error {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("save_assets", time.Now())
+
+	// каталог записывается одним запросом: построчная вставка десятка тысяч монет заняла бы минуты.
+	// provider_ids объединяются, чтобы идентификаторы от разных провайдеров не затирали друг друга;
+	// updated_at сдвигается, только если монета действительно изменилась
+	upsert := `INSERT INTO assets (symbol, name, provider_ids, decimals, status)
+		SELECT symbol, name, provider_ids::jsonb, decimals, status
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::int[], $5::text[]) AS a(symbol, name, provider_ids, decimals, status)
+		ON CONFLICT (symbol) DO UPDATE SET
+			name = EXCLUDED.name,
+			provider_ids = assets.provider_ids || EXCLUDED.provider_ids,
+			decimals = EXCLUDED.decimals,
+			status = EXCLUDED.status,
+			updated_at = now()
+		WHERE (assets.name, assets.provider_ids @> EXCLUDED.provider_ids, assets.decimals, assets.status)
+			IS DISTINCT FROM (EXCLUDED.name, true, EXCLUDED.decimals, EXCLUDED.status)`
+
+	delist := `UPDATE assets SET status = 'delisted', updated_at = now()
+		WHERE status <> 'delisted' AND NOT (symbol = ANY($1::text[]))`
+
+	var (
+		symbols     = make([]string, 0, len(assets))
+		names       = make([]string, 0, len(assets))
+		providerIDs = make([]string, 0, len(assets))
+		decimals    = make([]int32, 0, len(assets))
+		statuses    = make([]string, 0, len(assets))
+	)
+	for _, a := range assets {
+		ids := a.ProviderIDs
+		if ids == nil {
+			ids = map[string]string{}
+		}
+		encoded, err := json.Marshal(ids)
+		if err != nil {
+			log.Error("(SaveAssets) failed to encode provider ids:", zap.Any("symbol", a.Symbol), zap.Any("err", err.Error()))
+			return errors.Wrapf(entities.ErrInternal, "failed to encode provider ids: %v", err)
+		}
+
+		symbols = append(symbols, string(a.Symbol))
+		names = append(names, a.Name)
+		providerIDs = append(providerIDs, string(encoded))
+		decimals = append(decimals, int32(a.Decimals))
+		statuses = append(statuses, string(a.Status))
+	}
+
+	tx, err := p.dbPool.Begin(ctx)
+	if err != nil {
+		log.Error("(SaveAssets) failed to begin transaction", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to begin transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, upsert, symbols, names, providerIDs, decimals, statuses); err != nil {
+		log.Error("(SaveAssets) failed to save assets", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	res, err := tx.Exec(ctx, delist, symbols)
+	if err != nil {
+		log.Error("(SaveAssets) failed to delist assets", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Error("(SaveAssets) failed to commit transaction", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to commit transaction: %v", err)
+	}
+
+	log.Info("(SaveAssets) assets saved", zap.Any("assets", len(assets)), zap.Any("delisted", res.RowsAffected()))
+	return nil
+}
+
+func (p *Postgres) ListAssetSymbols(ctx context.Context) ([]string, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("list_asset_symbols", time.Now())
+
+	query := "SELECT symbol FROM assets WHERE status = 'active'"
+
+	rows, err := p.dbPool.Query(ctx, query)
+	if err != nil {
+		log.Error("(ListAssetSymbols) failed to list asset symbols", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	symbols := make([]string, 0)
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			log.Error("(ListAssetSymbols) failed to copy symbol", zap.Any("err", err.Error()))
+			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy symbol: %v", err)
+		}
+
+		symbols = append(symbols, symbol)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("(ListAssetSymbols) unexpected error:", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "unexpected error: %v", err)
+	}
+
+	return symbols, nil
+}
+
+func (p *Postgres) SearchAssets(ctx context.Context, search string, limit int) ([]entities.Asset, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("search_assets", time.Now())
+
+	// точное совпадение тикера - первым, затем активные монеты
+	query := `SELECT symbol, name, provider_ids, decimals, status, updated_at FROM assets
+		WHERE $1 = '' OR symbol LIKE upper($1) || '%' OR name ILIKE '%' || $1 || '%'
+		ORDER BY symbol = upper($1) DESC, status = 'active' DESC, symbol
+		LIMIT $2`
+
+	rows, err := p.dbPool.Query(ctx, query, escapeLike(strings.TrimSpace(search)), limit)
+	if err != nil {
+		log.Error("(SearchAssets) failed to search assets:", zap.Any("search", search), zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	assets := make([]entities.Asset, 0)
+	for rows.Next() {
+		var (
+			a      entities.Asset
+			symbol string
+			status string
+		)
+		if err := rows.Scan(&symbol, &a.Name, &a.ProviderIDs, &a.Decimals, &status, &a.UpdatedAt); err != nil {
+			log.Error("(SearchAssets) failed to copy asset", zap.Any("err", err.Error()))
+			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy asset: %v", err)
+		}
+		a.Symbol = entities.Symbol(symbol)
+		a.Status = entities.AssetStatus(status)
+
+		assets = append(assets, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("(SearchAssets) unexpected error:", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "unexpected error: %v", err)
+	}
+
+	return assets, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы поиск "%" или "_" не совпадал со всеми монетами
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, rate_limit, created_at, revoked_at"
+
+func (p *Postgres) CreateKey(ctx context.Context, key entities.APIKey) (entities.APIKey, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("create_key", time.Now())
+
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, rate_limit) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	if err := p.dbPool.QueryRow(ctx, query, key.Name, key.Prefix, key.Hash, scopeNames(key.Scopes), key.RateLimit).Scan(&key.ID, &key.CreatedAt); err != nil {
+		log.Error("(CreateKey) failed to insert key:", zap.Any("name", key.Name), zap.Any("err", err.Error()))
+		return entities.APIKey{}, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	return key, nil
+}
+
+func (p *Postgres) GetKeyByHash(ctx context.Context, hash string) (entities.APIKey, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_key", time.Now())
+
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+
+	key, err := scanKey(p.dbPool.QueryRow(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.APIKey{}, errors.Wrap(entities.ErrNotFound, "api key not found")
+		}
+
+		log.Error("(GetKeyByHash) failed to get key", zap.Any("err", err.Error()))
+		return entities.APIKey{}, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	return key, nil
+}
+
+func (p *Postgres) ListKeys(ctx context.Context) ([]entities.APIKey, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("list_keys", time.Now())
+
+	query := "SELECT " + apiKeyColumns + " FROM api_keys ORDER BY id"
+
+	rows, err := p.dbPool.Query(ctx, query)
+	if err != nil {
+		log.Error("(ListKeys) failed to list keys", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make([]entities.APIKey, 0)
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			log.Error("(ListKeys) failed to copy key", zap.Any("err", err.Error()))
+			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy key: %v", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("(ListKeys) unexpected error:", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "unexpected error: %v", err)
+	}
+
+	return keys, nil
+}
+
+func (p *Postgres) RevokeKey(ctx context.Context, id int64) error {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("revoke_key", time.Now())
+
+	// повторный отзыв не сдвигает время первого
+	query := "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1"
+
+	res, err := p.dbPool.Exec(ctx, query, id)
+	if err != nil {
+		log.Error("(RevokeKey) failed to revoke key:", zap.Any("keyID", id), zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.Wrapf(entities.ErrNotFound, "api key %d not found", id)
+	}
+
+	return nil
+}
+
+func (p *Postgres) AddUsage(ctx context.Context, usage []entities.KeyUsage) error {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("add_usage", time.Now())
+
+	query := `INSERT INTO api_key_usage (key_id, day, requests) VALUES ($1, $2, $3)
+		ON CONFLICT (key_id, day) DO UPDATE SET requests = api_key_usage.requests + EXCLUDED.requests`
+
+	batch := &pgx.Batch{}
+	for _, u := range usage {
+		batch.Queue(query, u.KeyID, u.Day, u.Requests)
+	}
+
+	if err := p.dbPool.SendBatch(ctx, batch).Close(); err != nil {
+		log.Error("(AddUsage) failed to save key usage", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetUsage(ctx context.Context, id int64, from, to time.Time) ([]entities.KeyUsage, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_usage", time.Now())
+
+	query := "SELECT day, requests FROM api_key_usage WHERE key_id = $1 AND day BETWEEN $2 AND $3 ORDER BY day"
+
+	rows, err := p.dbPool.Query(ctx, query, id, from, to)
+	if err != nil {
+		log.Error("(GetUsage) failed to get key usage:", zap.Any("keyID", id), zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	usage := make([]entities.KeyUsage, 0)
+	for rows.Next() {
+		u := entities.KeyUsage{KeyID: id}
+		if err := rows.Scan(&u.Day, &u.Requests); err != nil {
+			log.Error("(GetUsage) failed to copy key usage", zap.Any("err", err.Error()))
+			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy key usage: %v", err)
+		}
+
+		usage = append(usage, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("(GetUsage) unexpected error:", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrInternal, "unexpected error: %v", err)
+	}
+
+	return usage, nil
+}
+
+func scanKey(row pgx.Row) (entities.APIKey, error) {
+	var (
+		key    entities.APIKey
+		scopes []string
+	)
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.RateLimit, &key.CreatedAt, &key.RevokedAt); err != nil {
+		return entities.APIKey{}, err
+	}
+
+	for _, s := range scopes {
+		key.Scopes = append(key.Scopes, entities.Scope(s))
+	}
+
+	return key, nil
+}
+
+func scopeNames(scopes []entities.Scope) []string {
+	names := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		names = append(names, string(s))
+	}
+
+	return names
+}
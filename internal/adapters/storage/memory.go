@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+// Memory - in-process хранилище, повторяющее поведение Postgres.
+// Используется в тестах и для локального запуска без БД.
+type Memory struct {
+	mu          sync.RWMutex
+	rows        []entities.Coin
+	locks       map[int64]*MemoryLock
+	checkpoints map[string]time.Time
+	backfilled  map[string]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		locks:       make(map[int64]*MemoryLock),
+		checkpoints: make(map[string]time.Time),
+		backfilled:  make(map[string]struct{}),
+	}
+}
+
+func (m *Memory) Store(_ context.Context, coins []entities.Coin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, coin := range coins {
+		if coin.CreatedAt.IsZero() {
+			coin.CreatedAt = now
+		}
+
+		// как и уникальный индекс в Postgres, не даём дублировать исторические точки
+		if coin.Backfilled {
+			key := coin.Title + "@" + coin.CreatedAt.UTC().Format(time.RFC3339Nano)
+			if _, ok := m.backfilled[key]; ok {
+				continue
+			}
+			m.backfilled[key] = struct{}{}
+		}
+
+		m.rows = append(m.rows, coin)
+	}
+
+	return nil
+}
+
+// Rows возвращает все сохранённые записи в порядке вставки, с проставленным CreatedAt
+func (m *Memory) Rows() []entities.Coin {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.rows)
+}
+
+func (m *Memory) GetCoinsList(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	titles := make([]string, 0)
+	for _, row := range m.rows {
+		if _, ok := seen[row.Title]; ok {
+			continue
+		}
+
+		seen[row.Title] = struct{}{}
+		titles = append(titles, row.Title)
+	}
+
+	sort.Strings(titles)
+	return titles, nil
+}
+
+func (m *Memory) GetActualCoins(_ context.Context, titles []string) ([]entities.Coin, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	coins := make([]entities.Coin, 0, len(titles))
+	for _, title := range titles {
+		rows := m.todayRows(title)
+		if len(rows) == 0 {
+			continue
+		}
+
+		latest := slices.MaxFunc(rows, func(a, b entities.Coin) int { return a.CreatedAt.Compare(b.CreatedAt) })
+		coins = append(coins, entities.Coin{
+			Title:     latest.Title,
+			Price:     latest.Price,
+			CreatedAt: latest.CreatedAt,
+			Source:    entities.SourceStorage,
+		})
+	}
+
+	return coins, nil
+}
+
+func (m *Memory) GetAggregateCoins(ctx context.Context, titles []string, aggFuncName string) ([]entities.Coin, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	return m.GetAggregateCoinsSince(ctx, titles, aggFuncName, today)
+}
+
+func (m *Memory) GetAggregateCoinsSince(_ context.Context, titles []string, aggFuncName string, since time.Time) ([]entities.Coin, error) {
+	aggFunc, ok := memoryAggFuncs[strings.ToUpper(aggFuncName)]
+	if !ok {
+		return nil, errors.Wrapf(entities.ErrUnknownAggregate, "unsupported aggregate function: %v", aggFuncName)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	coins := make([]entities.Coin, 0, len(titles))
+	for _, title := range titles {
+		rows := m.rowsSince(title, since)
+		if len(rows) == 0 {
+			continue
+		}
+
+		prices := make([]float64, 0, len(rows))
+		var latest time.Time
+		for _, row := range rows {
+			prices = append(prices, row.Price)
+			if row.CreatedAt.After(latest) {
+				latest = row.CreatedAt
+			}
+		}
+
+		coins = append(coins, entities.Coin{
+			Title:     title,
+			Price:     aggFunc(prices),
+			CreatedAt: latest,
+			Source:    entities.SourceStorage,
+		})
+	}
+
+	return coins, nil
+}
+
+var memoryAggFuncs = map[string]func(prices []float64) float64{
+	"MAX": func(prices []float64) float64 { return slices.Max(prices) },
+	"MIN": func(prices []float64) float64 { return slices.Min(prices) },
+	"AVG": func(prices []float64) float64 {
+		sum := 0.0
+		for _, price := range prices {
+			sum += price
+		}
+		return sum / float64(len(prices))
+	},
+}
+
+func (m *Memory) GetHistory(_ context.Context, title string, from, to time.Time) ([]entities.Coin, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	coins := make([]entities.Coin, 0)
+	for _, row := range m.rows {
+		if row.Title == title && !row.CreatedAt.Before(from) && !row.CreatedAt.After(to) {
+			row.Source = entities.SourceStorage
+			coins = append(coins, row)
+		}
+	}
+
+	slices.SortStableFunc(coins, func(a, b entities.Coin) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return coins, nil
+}
+
+// GetCandles строит свечи из сырых записей (свёрток в Memory нет)
+func (m *Memory) GetCandles(_ context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error) {
+	step := interval.Duration()
+	if step == 0 {
+		return nil, errors.Wrapf(entities.ErrUnknownInterval, "unsupported interval: %v", interval)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := make([]entities.Coin, 0)
+	for _, row := range m.rows {
+		if row.Title == title && !row.CreatedAt.Before(from) && row.CreatedAt.Before(to) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b entities.Coin) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	candles := make([]entities.Candle, 0)
+	for _, row := range rows {
+		bucket := row.CreatedAt.UTC().Truncate(step)
+		if len(candles) == 0 || !candles[len(candles)-1].Bucket.Equal(bucket) {
+			candles = append(candles, entities.Candle{
+				Title:  title,
+				Bucket: bucket,
+				Open:   row.Price,
+				High:   row.Price,
+				Low:    row.Price,
+			})
+		}
+
+		candle := &candles[len(candles)-1]
+		candle.High = max(candle.High, row.Price)
+		candle.Low = min(candle.Low, row.Price)
+		candle.Close = row.Price
+		candle.Avg = (candle.Avg*float64(candle.Count) + row.Price) / float64(candle.Count+1)
+		candle.Count++
+	}
+
+	return candles, nil
+}
+
+// StreamHistory передаёт историю в fn по одной записи
+func (m *Memory) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error {
+	coins, err := m.GetHistory(ctx, title, from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, coin := range coins {
+		if err := fn(coin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StreamCandles передаёт свечи в fn по одной
+func (m *Memory) StreamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error {
+	candles, err := m.GetCandles(ctx, title, interval, from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, candle := range candles {
+		if err := fn(candle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// todayRows возвращает записи по монете за текущие сутки
+func (m *Memory) todayRows(title string) []entities.Coin {
+	now := time.Now()
+	return m.rowsSince(title, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+}
+
+func (m *Memory) rowsSince(title string, since time.Time) []entities.Coin {
+	rows := make([]entities.Coin, 0)
+	for _, row := range m.rows {
+		if row.Title == title && !row.CreatedAt.Before(since) {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+func (m *Memory) GetCheckpoint(_ context.Context, title string, interval entities.Interval) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ts, ok := m.checkpoints[checkpointKey(title, interval)]
+	if !ok {
+		return time.Time{}, errors.Wrapf(entities.ErrNotFound, "no checkpoint for %v/%v", title, interval)
+	}
+
+	return ts, nil
+}
+
+func (m *Memory) SaveCheckpoint(_ context.Context, title string, interval entities.Interval, ts time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checkpoints[checkpointKey(title, interval)] = ts
+	return nil
+}
+
+func checkpointKey(title string, interval entities.Interval) string {
+	return title + "/" + string(interval)
+}
+
+// MemoryLock - аналог AdvisoryLock для Memory: лок с одним ключом
+// может удерживать только один владелец в пределах процесса.
+type MemoryLock struct {
+	storage *Memory
+	key     int64
+}
+
+func NewMemoryLock(m *Memory, key int64) (*MemoryLock, error) {
+	if m == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "memory storage not set")
+	}
+
+	return &MemoryLock{
+		storage: m,
+		key:     key,
+	}, nil
+}
+
+func (l *MemoryLock) TryLock(_ context.Context) (bool, error) {
+	l.storage.mu.Lock()
+	defer l.storage.mu.Unlock()
+
+	owner, ok := l.storage.locks[l.key]
+	if !ok {
+		l.storage.locks[l.key] = l
+		return true, nil
+	}
+
+	return owner == l, nil
+}
+
+func (l *MemoryLock) Unlock(_ context.Context) error {
+	l.storage.mu.Lock()
+	defer l.storage.mu.Unlock()
+
+	if l.storage.locks[l.key] == l {
+		delete(l.storage.locks, l.key)
+	}
+
+	return nil
+}
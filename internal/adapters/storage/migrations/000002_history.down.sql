@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TABLE IF EXISTS backfill_checkpoints;
+
+DROP INDEX IF EXISTS coins_backfilled_uniq;
+DROP INDEX IF EXISTS coins_title_created_at_idx;
+
+ALTER TABLE IF EXISTS coins DROP COLUMN IF EXISTS backfilled;
+ALTER TABLE IF EXISTS coins ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE IF EXISTS coins ALTER COLUMN created_at SET DEFAULT CURRENT_DATE;
+ALTER TABLE IF EXISTS coins ALTER COLUMN created_at TYPE DATE USING created_at::DATE;
+
+COMMIT;
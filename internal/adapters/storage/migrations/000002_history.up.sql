@@ -0,0 +1,22 @@
+BEGIN;
+
+ALTER TABLE coins ALTER COLUMN title TYPE VARCHAR(10);
+ALTER TABLE coins ALTER COLUMN price TYPE NUMERIC(20, 8);
+ALTER TABLE coins ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at::TIMESTAMPTZ;
+ALTER TABLE coins ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE coins ALTER COLUMN created_at SET NOT NULL;
+ALTER TABLE coins ADD COLUMN backfilled BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX coins_title_created_at_idx ON coins (title, created_at);
+-- повторная загрузка истории не должна дублировать точки
+CREATE UNIQUE INDEX coins_backfilled_uniq ON coins (title, created_at) WHERE backfilled;
+
+CREATE TABLE backfill_checkpoints (
+	title VARCHAR(10) NOT NULL,
+	interval VARCHAR(3) NOT NULL,
+	last_ts TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (title, interval)
+);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS coins_1d;
+DROP TABLE IF EXISTS coins_1h;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+-- свёртки старых тиков: сырые записи старше срока хранения сворачиваются в часовые,
+-- часовые - в дневные
+CREATE TABLE coins_1h (
+	title VARCHAR(10) NOT NULL,
+	bucket TIMESTAMPTZ NOT NULL,
+	open NUMERIC(20, 8) NOT NULL,
+	high NUMERIC(20, 8) NOT NULL,
+	low NUMERIC(20, 8) NOT NULL,
+	close NUMERIC(20, 8) NOT NULL,
+	avg NUMERIC(20, 8) NOT NULL,
+	count INTEGER NOT NULL,
+	PRIMARY KEY (title, bucket)
+);
+
+CREATE TABLE coins_1d (LIKE coins_1h INCLUDING ALL);
+
+COMMIT;
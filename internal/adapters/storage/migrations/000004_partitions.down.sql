@@ -0,0 +1,32 @@
+BEGIN;
+
+DO $$
+BEGIN
+	-- откатываем только секционированную таблицу
+	IF NOT EXISTS (SELECT 1 FROM pg_class WHERE relname = 'coins' AND relkind = 'p') THEN
+		RETURN;
+	END IF;
+
+	ALTER TABLE coins RENAME TO coins_partitioned;
+	ALTER TABLE coins_partitioned RENAME CONSTRAINT coins_pkey TO coins_partitioned_pkey;
+	DROP INDEX coins_title_created_at_idx;
+	DROP INDEX coins_backfilled_uniq;
+
+	CREATE TABLE coins (
+		id SERIAL PRIMARY KEY,
+		title VARCHAR(10) NOT NULL,
+		price NUMERIC(20, 8),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		backfilled BOOLEAN NOT NULL DEFAULT false
+	);
+
+	CREATE INDEX coins_title_created_at_idx ON coins (title, created_at);
+	CREATE UNIQUE INDEX coins_backfilled_uniq ON coins (title, created_at) WHERE backfilled;
+
+	INSERT INTO coins (title, price, created_at, backfilled)
+	SELECT title, price, created_at, backfilled FROM coins_partitioned;
+
+	DROP TABLE coins_partitioned;
+END $$;
+
+COMMIT;
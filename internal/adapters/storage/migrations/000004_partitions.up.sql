@@ -0,0 +1,45 @@
+BEGIN;
+
+-- coins становится таблицей, секционированной по месяцам; будущие секции создаёт
+-- PartitionManager, устаревшие удаляются после свёртки (см. Postgres.Compact)
+ALTER TABLE coins RENAME TO coins_legacy;
+ALTER TABLE coins_legacy RENAME CONSTRAINT coins_pkey TO coins_legacy_pkey;
+DROP INDEX coins_title_created_at_idx;
+DROP INDEX coins_backfilled_uniq;
+
+CREATE TABLE coins (
+	id BIGSERIAL,
+	title VARCHAR(10) NOT NULL,
+	price NUMERIC(20, 8),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	backfilled BOOLEAN NOT NULL DEFAULT false,
+	PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE INDEX coins_title_created_at_idx ON coins (title, created_at);
+CREATE UNIQUE INDEX coins_backfilled_uniq ON coins (title, created_at) WHERE backfilled;
+
+-- сюда попадают записи вне созданных секций (например, загруженная история за давние месяцы)
+CREATE TABLE coins_default PARTITION OF coins DEFAULT;
+
+DO $$
+DECLARE
+	m TIMESTAMPTZ;
+BEGIN
+	FOR m IN
+		SELECT generate_series(
+			date_trunc('month', COALESCE((SELECT min(created_at) FROM coins_legacy), now()), 'UTC'),
+			date_trunc('month', now(), 'UTC') + INTERVAL '2 months',
+			INTERVAL '1 month')
+	LOOP
+		EXECUTE format('CREATE TABLE %I PARTITION OF coins FOR VALUES FROM (%L) TO (%L)',
+			'coins_' || to_char(m AT TIME ZONE 'UTC', 'YYYY_MM'), m, m + INTERVAL '1 month');
+	END LOOP;
+END $$;
+
+INSERT INTO coins (title, price, created_at, backfilled)
+SELECT title, price, created_at, backfilled FROM coins_legacy;
+
+DROP TABLE coins_legacy;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- каталог миграций подключён как docker-entrypoint-initdb.d, и при инициализации базы down-файл
+-- выполняется раньше up-файла той же версии, когда schema_migrations ещё может не существовать
+DO $$
+BEGIN
+	IF to_regclass('schema_migrations') IS NOT NULL THEN
+		UPDATE schema_migrations SET version = 4 WHERE version = 5;
+	END IF;
+END $$;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- миграции применяются и через docker-entrypoint-initdb.d, где их никто не учитывает,
+-- поэтому версию схемы фиксирует сама миграция в таблице в формате golang-migrate;
+-- /readyz сверяет её с последней миграцией, встроенной в бинарник.
+-- Каждая следующая миграция должна так же обновлять версию.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT NOT NULL PRIMARY KEY,
+	dirty BOOLEAN NOT NULL
+);
+
+DELETE FROM schema_migrations WHERE version < 5;
+INSERT INTO schema_migrations (version, dirty) VALUES (5, false) ON CONFLICT (version) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
+
+-- см. 000005_schema_version.down.sql
+DO $$
+BEGIN
+	IF to_regclass('schema_migrations') IS NOT NULL THEN
+		UPDATE schema_migrations SET version = 5 WHERE version = 6;
+	END IF;
+END $$;
+
+COMMIT;
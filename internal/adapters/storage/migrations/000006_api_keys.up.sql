@@ -0,0 +1,24 @@
+BEGIN;
+
+-- ключ хранится только в виде sha256-хеша; prefix - начало ключа, по которому его узнаёт владелец
+CREATE TABLE api_keys (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	prefix VARCHAR(16) NOT NULL,
+	key_hash CHAR(64) NOT NULL UNIQUE,
+	scopes TEXT[] NOT NULL,
+	rate_limit INTEGER NOT NULL CHECK (rate_limit > 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE api_key_usage (
+	key_id BIGINT NOT NULL REFERENCES api_keys (id),
+	day DATE NOT NULL,
+	requests BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (key_id, day)
+);
+
+UPDATE schema_migrations SET version = 6 WHERE version = 5;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TABLE IF EXISTS assets;
+
+-- см. 000005_schema_version.down.sql
+DO $$
+BEGIN
+	IF to_regclass('schema_migrations') IS NOT NULL THEN
+		UPDATE schema_migrations SET version = 6 WHERE version = 7;
+	END IF;
+END $$;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- каталог известных монет; синхронизируется со списками монет провайдеров.
+-- В каталоге порядка десятка тысяч строк, поиск по нему обходится без индексов
+CREATE TABLE assets (
+	symbol TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	provider_ids JSONB NOT NULL DEFAULT '{}',
+	decimals INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL CHECK (status IN ('active', 'inactive', 'delisted')),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+UPDATE schema_migrations SET version = 7 WHERE version = 6;
+
+COMMIT;
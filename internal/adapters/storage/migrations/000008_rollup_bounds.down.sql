@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS coins_1d DROP COLUMN IF EXISTS close_at, DROP COLUMN IF EXISTS open_at;
+ALTER TABLE IF EXISTS coins_1h DROP COLUMN IF EXISTS close_at, DROP COLUMN IF EXISTS open_at;
+
+-- см. 000005_schema_version.down.sql
+DO $$
+BEGIN
+	IF to_regclass('schema_migrations') IS NOT NULL THEN
+		UPDATE schema_migrations SET version = 7 WHERE version = 8;
+	END IF;
+END $$;
+
+COMMIT;
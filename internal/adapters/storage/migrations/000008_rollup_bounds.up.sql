@@ -0,0 +1,16 @@
+BEGIN;
+
+-- моменты первой и последней точки бакета: по ним выбираются open и close, когда в уже свёрнутый
+-- бакет попадают новые точки. Для существующих свёрток точные моменты неизвестны, считаем,
+-- что они покрывают бакет целиком
+ALTER TABLE coins_1h ADD COLUMN open_at TIMESTAMPTZ, ADD COLUMN close_at TIMESTAMPTZ;
+UPDATE coins_1h SET open_at = bucket, close_at = bucket + interval '1 hour' - interval '1 microsecond';
+ALTER TABLE coins_1h ALTER COLUMN open_at SET NOT NULL, ALTER COLUMN close_at SET NOT NULL;
+
+ALTER TABLE coins_1d ADD COLUMN open_at TIMESTAMPTZ, ADD COLUMN close_at TIMESTAMPTZ;
+UPDATE coins_1d SET open_at = bucket, close_at = bucket + interval '1 day' - interval '1 microsecond';
+ALTER TABLE coins_1d ALTER COLUMN open_at SET NOT NULL, ALTER COLUMN close_at SET NOT NULL;
+
+UPDATE schema_migrations SET version = 8 WHERE version = 7;
+
+COMMIT;
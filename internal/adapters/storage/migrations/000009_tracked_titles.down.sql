@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TABLE IF EXISTS tracked_titles;
+
+-- см. 000005_schema_version.down.sql
+DO $$
+BEGIN
+	IF to_regclass('schema_migrations') IS NOT NULL THEN
+		UPDATE schema_migrations SET version = 8 WHERE version = 9;
+	END IF;
+END $$;
+
+COMMIT;
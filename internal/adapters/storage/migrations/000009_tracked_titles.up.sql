@@ -0,0 +1,14 @@
+BEGIN;
+
+-- список отслеживаемых монет. Раньше он собирался через SELECT DISTINCT по всем партициям coins,
+-- а нужен на каждом запросе курсов, в /readyz, /status и при опросе хранилища
+CREATE TABLE tracked_titles (
+	title TEXT PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO tracked_titles (title) SELECT DISTINCT title FROM coins;
+
+UPDATE schema_migrations SET version = 9 WHERE version = 8;
+
+COMMIT;
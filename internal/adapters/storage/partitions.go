@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	partitionPrefix = "coins_"
+	partitionLayout = "2006_01"
+	// defaultPartition - секция для записей вне созданных секций (см. миграцию 000004_partitions)
+	defaultPartition = "coins_default"
+)
+
+// PartitionManager поддерживает помесячные секции таблицы coins:
+// заранее создаёт секции на monthsAhead месяцев вперёд и удаляет устаревшие.
+type PartitionManager struct {
+	pool        *pgxpool.Pool
+	monthsAhead int
+}
+
+func NewPartitionManager(pg *Postgres, monthsAhead int) (*PartitionManager, error) {
+	if pg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "postgres not set")
+	}
+
+	if monthsAhead < 1 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "months ahead must be positive")
+	}
+
+	return &PartitionManager{
+		pool:        pg.dbPool,
+		monthsAhead: monthsAhead,
+	}, nil
+}
+
+// CreateAhead создаёт секции с текущего месяца на monthsAhead месяцев вперёд (уже существующие пропускаются)
+func (pm *PartitionManager) CreateAhead(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= pm.monthsAhead; i++ {
+		from := current.AddDate(0, i, 0)
+		to := from.AddDate(0, 1, 0)
+		name := partitionPrefix + from.Format(partitionLayout)
+
+		if err := pm.createPartition(ctx, name, from, to); err != nil {
+			log.Error("(CreateAhead) failed to create partition", zap.Any("partition", name), zap.Any("err", err.Error()))
+			return errors.Wrapf(entities.ErrStorageUnavailable, "failed to create partition %v: %v", name, err)
+		}
+	}
+
+	log.Info("(CreateAhead) partitions are in place", zap.Any("monthsAhead", pm.monthsAhead))
+	return nil
+}
+
+// createPartition создаёт секцию за месяц [from, to), если её ещё нет. Пока секции не было, записи за этот месяц
+// (например, дозагруженная история) попадали в секцию по умолчанию, и с ними Postgres секцию не создаст:
+// такие записи в той же транзакции переносятся в новую секцию
+func (pm *PartitionManager) createPartition(ctx context.Context, name string, from, to time.Time) error {
+	log := logger.FromContext(ctx)
+
+	tx, err := pm.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE TEMP TABLE coins_moved (LIKE coins) ON COMMIT DROP"); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(ctx, `WITH moved AS (
+			DELETE FROM `+pgx.Identifier{defaultPartition}.Sanitize()+` WHERE created_at >= $1 AND created_at < $2 RETURNING *
+		) INSERT INTO coins_moved SELECT * FROM moved`, from, to)
+	if err != nil {
+		return err
+	}
+	moved := res.RowsAffected()
+
+	query := fmt.Sprintf("CREATE TABLE %s PARTITION OF coins FOR VALUES FROM ('%s') TO ('%s')",
+		pgx.Identifier{name}.Sanitize(), from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if _, err := tx.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	if moved > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO coins SELECT * FROM coins_moved"); err != nil {
+			return err
+		}
+		log.Info("(CreateAhead) rows moved from the default partition", zap.Any("partition", name), zap.Any("rows", moved))
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DropExpired удаляет секции, целиком лежащие раньше before; возвращает количество удалённых секций
+func (pm *PartitionManager) DropExpired(ctx context.Context, before time.Time) (int, error) {
+	return dropExpiredPartitions(ctx, pm.pool, before)
+}
+
+func dropExpiredPartitions(ctx context.Context, pool *pgxpool.Pool, before time.Time) (int, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = 'coins'`
+
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		log.Error("(dropExpiredPartitions) failed to list partitions", zap.Any("err", err.Error()))
+		return 0, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Error("(dropExpiredPartitions) failed to copy partition names", zap.Any("err", err.Error()))
+		return 0, errors.Wrapf(entities.ErrInternal, "failed to copy partition names: %v", err)
+	}
+
+	dropped := 0
+	for _, name := range names {
+		// секция по умолчанию и таблицы с посторонними именами не трогаем
+		month, err := time.Parse(partitionLayout, strings.TrimPrefix(name, partitionPrefix))
+		if err != nil || !strings.HasPrefix(name, partitionPrefix) {
+			continue
+		}
+
+		if month.AddDate(0, 1, 0).After(before) {
+			continue
+		}
+
+		if err := dropPartition(ctx, pool, name); err != nil {
+			log.Error("(dropExpiredPartitions) failed to drop partition", zap.Any("partition", name), zap.Any("err", err.Error()))
+			return dropped, errors.Wrapf(entities.ErrStorageUnavailable, "failed to drop partition %v: %v", name, err)
+		}
+
+		log.Info("(dropExpiredPartitions) partition dropped", zap.Any("partition", name))
+		dropped++
+	}
+
+	return dropped, nil
+}
+
+// dropPartition отсоединяет и удаляет секцию в отдельной транзакции, чтобы блокировка coins держалась недолго
+func dropPartition(ctx context.Context, pool *pgxpool.Pool, name string) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	partition := pgx.Identifier{name}.Sanitize()
+	if _, err := tx.Exec(ctx, "ALTER TABLE coins DETACH PARTITION "+partition); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "DROP TABLE "+partition); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -10,6 +12,7 @@ import (
 	"go.uber.org/zap"
 
 	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
 	"kursy-kriptovalyut/pkg/logger"
 )
 
@@ -24,51 +27,92 @@ func NewPostgres(connStr string) (*Postgres, error) {
 		return nil, errors.Wrap(entities.ErrInvalidParam, "empty connection string")
 	}
 
-	pool, err := pgxpool.New(context.Background(), connStr)
+	poolCfg, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
-		return nil, errors.Wrapf(entities.ErrInternal, "failed to create pool: %v", err)
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "invalid connection string: %v", err)
+	}
+	poolCfg.ConnConfig.Tracer = queryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to create pool: %v", err)
 	}
 
 	if err := pool.Ping(context.Background()); err != nil {
-		return nil, errors.Wrapf(entities.ErrInternal, "failed to ping DB: %v", err)
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to ping DB: %v", err)
 	}
 
 	return &Postgres{dbPool: pool}, nil
 }
 
+// Close закрывает пул соединений, дожидаясь возврата занятых соединений
+func (p *Postgres) Close() {
+	p.dbPool.Close()
+}
+
 func (p *Postgres) Store(ctx context.Context, coins []entities.Coin) error {
-	query := "INSERT INTO coins (title, price) VALUES ($1, $2)" // пересмотреть вторую часть VALUES ($1, $2)
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("store", time.Now())
 
-	log.Info("(Store) inserting coins:", zap.Any("coins", coins))
+	query := "INSERT INTO coins (title, price, created_at, backfilled) VALUES ($1, $2, $3, $4)"
+	// исторические точки могут загружаться повторно (например, после прерванной загрузки)
+	backfillQuery := query + " ON CONFLICT (title, created_at) WHERE backfilled DO NOTHING"
+
+	log.Info("(Store) inserting coins:", zap.Any("count", len(coins)))
 	for _, coin := range coins {
-		res, err := p.dbPool.Exec(ctx, query, coin.Title, coin.Price)
+		createdAt := coin.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		q := query
+		if coin.Backfilled {
+			q = backfillQuery
+		}
+
+		res, err := p.dbPool.Exec(ctx, q, coin.Title, coin.Price, createdAt, coin.Backfilled)
 		if err != nil {
-			log.Error("(Store) failed to insert:", zap.Any("coin", coin))
-			return errors.Wrapf(entities.ErrInternal, "failed to execute query: %v", err)
+			log.Error("(Store) failed to insert:", zap.Any("coin", coin), zap.Any("err", err.Error()))
+			return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
 		}
 
 		rows := res.RowsAffected()
-		if rows != 1 {
-			log.Error("(Store) affected more than one row")
-			return errors.Wrapf(entities.ErrInternal, "expected to affect 1 row, affected %v row(s)", rows)
+		if rows > 1 || (rows == 0 && !coin.Backfilled) {
+			err := errors.Wrapf(entities.ErrInternal, "expected to affect 1 row, affected %v row(s)", rows)
+			log.Error("(Store) unexpected number of affected rows", zap.Any("err", err.Error()))
+			return err
 		}
 	}
 
+	// монета становится отслеживаемой после первой сохранённой цены
+	titles := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		titles = append(titles, coin.Title)
+	}
+	trackQuery := "INSERT INTO tracked_titles (title) SELECT DISTINCT unnest($1::text[]) ON CONFLICT (title) DO NOTHING"
+	if _, err := p.dbPool.Exec(ctx, trackQuery, titles); err != nil {
+		log.Error("(Store) failed to track titles:", zap.Any("titles", titles), zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
 	return nil
 }
 
 func (p *Postgres) GetCoinsList(ctx context.Context) ([]string, error) {
-	query := "SELECT DISTINCT title FROM coins"
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_coins_list", time.Now())
+
+	query := "SELECT title FROM tracked_titles"
 
 	log.Info("(GetCoinsList) getting list of coin titles")
 	rows, err := p.dbPool.Query(ctx, query)
 	if err != nil {
-		log.Error("(GetCoinsList) failed to get list of coin titles")
+		log.Error("(GetCoinsList) failed to get list of coin titles", zap.Any("err", err.Error()))
 		if errors.Is(err, pgx.ErrNoRows) {
-			log.Error("(GetCoinsList) empty result set")
+			log.Error("(GetCoinsList) empty result set", zap.Any("err", err.Error()))
 			return nil, errors.Wrapf(entities.ErrNotFound, "empty result: %v", err.Error())
 		}
-		return nil, errors.Wrapf(entities.ErrInternal, "failed to execute query: %v", err)
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
 	}
 	defer rows.Close()
 
@@ -79,7 +123,7 @@ func (p *Postgres) GetCoinsList(ctx context.Context) ([]string, error) {
 
 	for rows.Next() {
 		if err := rows.Scan(&title); err != nil {
-			log.Error("(GetCoinsList) failed to copy title:", zap.Any("title", title))
+			log.Error("(GetCoinsList) failed to copy title:", zap.Any("title", title), zap.Any("err", err.Error()))
 			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy title: %v", err)
 		}
 
@@ -95,21 +139,24 @@ func (p *Postgres) GetCoinsList(ctx context.Context) ([]string, error) {
 }
 
 func (p *Postgres) GetActualCoins(ctx context.Context, titles []string) ([]entities.Coin, error) {
-	query := "SELECT title, price FROM coins WHERE title = $1 AND created_at = CURRENT_DATE ORDER BY created_at DESC LIMIT 1"
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_actual_coins", time.Now())
 
-	var coin entities.Coin
+	query := "SELECT title, price, created_at FROM coins WHERE title = $1 AND created_at >= CURRENT_DATE ORDER BY created_at DESC LIMIT 1"
+
+	coin := entities.Coin{Source: entities.SourceStorage}
 	coins := make([]entities.Coin, 0, len(titles))
 
 	log.Info("(GetActualCoins) getting coins' last rates", zap.Any("coinTitles", titles))
 	for _, title := range titles {
-		if err := p.dbPool.QueryRow(ctx, query, title).Scan(&coin.Title, &coin.Price); err != nil {
+		if err := p.dbPool.QueryRow(ctx, query, title).Scan(&coin.Title, &coin.Price, &coin.CreatedAt); err != nil {
 			log.Info("(GetActualCoins) failed to get coin:", zap.Any("coin", coin))
 			if errors.Is(err, pgx.ErrNoRows) {
 				log.Info("(GetActualCoins) empty result set")
 				continue
 			}
 
-			log.Error("(GetActualCoins) failed to copy coin:", zap.Any("coin", coin))
+			log.Error("(GetActualCoins) failed to copy coin:", zap.Any("coin", coin), zap.Any("err", err.Error()))
 			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy title/price: %v", err)
 		}
 
@@ -119,21 +166,68 @@ func (p *Postgres) GetActualCoins(ctx context.Context, titles []string) ([]entit
 	return coins, nil
 }
 
+// LastUpdate возвращает время самой свежей цены, полученной в реальном времени. Последняя цена каждой
+// отслеживаемой монеты ищется по индексу (title, created_at), чтобы не читать партиции целиком
+func (p *Postgres) LastUpdate(ctx context.Context) (time.Time, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("last_update", time.Now())
+
+	query := `SELECT max(c.created_at) FROM tracked_titles t
+		CROSS JOIN LATERAL (
+			SELECT created_at FROM coins WHERE title = t.title AND NOT backfilled ORDER BY created_at DESC LIMIT 1
+		) c`
+
+	var lastUpdate *time.Time
+	if err := p.dbPool.QueryRow(ctx, query).Scan(&lastUpdate); err != nil {
+		log.Error("(LastUpdate) failed to get last update time", zap.Any("err", err.Error()))
+		return time.Time{}, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	if lastUpdate == nil {
+		return time.Time{}, nil
+	}
+
+	return *lastUpdate, nil
+}
 func (p *Postgres) GetAggregateCoins(ctx context.Context, titles []string, aggFuncName string) ([]entities.Coin, error) {
-	query := fmt.Sprintf("SELECT title, %v(price) FROM coins WHERE title = $1 AND created_at = CURRENT_DATE GROUP BY title", aggFuncName)
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_aggregate_coins", time.Now())
 
-	var coin entities.Coin
-	coins := make([]entities.Coin, 0, len(titles))
+	query := fmt.Sprintf("SELECT title, %v(price), max(created_at) FROM coins WHERE title = $1 AND created_at >= CURRENT_DATE GROUP BY title", aggFuncName)
 
 	log.Info("(GetAggregateCoins) getting coins' aggregated rates", zap.Any("coinTitles", titles))
+	return p.queryAggregateCoins(ctx, query, titles)
+}
+
+func (p *Postgres) GetAggregateCoinsSince(ctx context.Context, titles []string, aggFuncName string, since time.Time) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_aggregate_coins_since", time.Now())
+
+	if !postgresAggFuncs[strings.ToUpper(aggFuncName)] {
+		return nil, errors.Wrapf(entities.ErrUnknownAggregate, "unsupported aggregate function: %v", aggFuncName)
+	}
+	query := fmt.Sprintf("SELECT title, %v(price), max(created_at) FROM coins WHERE title = $1 AND created_at >= $2 GROUP BY title", aggFuncName)
+
+	log.Info("(GetAggregateCoinsSince) getting coins' aggregated rates", zap.Any("coinTitles", titles), zap.Any("since", since))
+	return p.queryAggregateCoins(ctx, query, titles, since)
+}
+
+// имя функции подставляется в текст запроса, поэтому допускаются только известные агрегаты
+var postgresAggFuncs = map[string]bool{"MAX": true, "MIN": true, "AVG": true}
+
+func (p *Postgres) queryAggregateCoins(ctx context.Context, query string, titles []string, args ...interface{}) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	coin := entities.Coin{Source: entities.SourceStorage}
+	coins := make([]entities.Coin, 0, len(titles))
+
 	for _, title := range titles {
-		if err := p.dbPool.QueryRow(ctx, query, title).Scan(&coin.Title, &coin.Price); err != nil {
-			log.Info("(GetAggregateCoins) failed to get aggregated coin", zap.Any("aggCoin", coin))
+		if err := p.dbPool.QueryRow(ctx, query, append([]interface{}{title}, args...)...).Scan(&coin.Title, &coin.Price, &coin.CreatedAt); err != nil {
+			log.Info("(queryAggregateCoins) failed to get aggregated coin", zap.Any("aggCoin", coin))
 			if errors.Is(err, pgx.ErrNoRows) {
-				log.Info("(GetAggregateCoins) empty result set")
+				log.Info("(queryAggregateCoins) empty result set")
 				continue
 			}
-			log.Error("(GetAggregateCoins) failed to copy coin:", zap.Any("coin", coin))
+			log.Error("(queryAggregateCoins) failed to copy coin:", zap.Any("coin", coin), zap.Any("err", err.Error()))
 			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy title/price: %v", err)
 		}
 
@@ -142,3 +236,309 @@ func (p *Postgres) GetAggregateCoins(ctx context.Context, titles []string, aggFu
 
 	return coins, nil
 }
+
+// старые периоды доступны только в свёртках - отдаём цену закрытия интервала
+const historyQuery = `SELECT title, price, created_at, backfilled FROM coins
+		WHERE title = $1 AND created_at BETWEEN $2 AND $3
+	UNION ALL
+	SELECT title, close, bucket, false FROM coins_1h
+		WHERE title = $1 AND bucket BETWEEN $2 AND $3
+	UNION ALL
+	SELECT title, close, bucket, false FROM coins_1d
+		WHERE title = $1 AND bucket BETWEEN $2 AND $3
+	ORDER BY created_at`
+
+func (p *Postgres) GetHistory(ctx context.Context, title string, from, to time.Time) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_history", time.Now())
+
+	log.Info("(GetHistory) getting coin history", zap.Any("title", title), zap.Any("from", from), zap.Any("to", to))
+	rows, err := p.dbPool.Query(ctx, historyQuery, title, from, to)
+	if err != nil {
+		log.Error("(GetHistory) failed to get coin history", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	coins := make([]entities.Coin, 0)
+	for rows.Next() {
+		coin := entities.Coin{Source: entities.SourceStorage}
+		if err := rows.Scan(&coin.Title, &coin.Price, &coin.CreatedAt, &coin.Backfilled); err != nil {
+			log.Error("(GetHistory) failed to copy coin:", zap.Any("coin", coin), zap.Any("err", err.Error()))
+			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy coin: %v", err)
+		}
+
+		coins = append(coins, coin)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("(GetHistory) unexpected error:", zap.Any("err", err))
+		return nil, errors.Wrapf(entities.ErrInternal, "unexpected error: %v", err)
+	}
+
+	return coins, nil
+}
+
+var candleUnits = map[entities.Interval]string{
+	entities.IntervalMinute: "minute",
+	entities.IntervalHour:   "hour",
+	entities.IntervalDay:    "day",
+}
+
+// сырые тики и свёртки не пересекаются по времени, поэтому объединяем их и агрегируем
+// до нужного шага; для старых периодов свечи строятся из самого подробного доступного разрешения
+const candlesQuery = `WITH src AS (
+		SELECT date_trunc($2::text, created_at, 'UTC') AS bucket, created_at AS first_at, created_at AS last_at,
+			price AS open, price AS high, price AS low, price AS close, price AS total, 1 AS cnt
+		FROM coins WHERE title = $1 AND created_at >= $3 AND created_at < $4
+		UNION ALL
+		SELECT date_trunc($2::text, bucket, 'UTC'), open_at, close_at, open, high, low, close, avg * count, count
+		FROM coins_1h WHERE title = $1 AND bucket >= $3 AND bucket < $4
+		UNION ALL
+		SELECT date_trunc($2::text, bucket, 'UTC'), open_at, close_at, open, high, low, close, avg * count, count
+		FROM coins_1d WHERE title = $1 AND bucket >= $3 AND bucket < $4
+	)
+	SELECT bucket, (array_agg(open ORDER BY first_at))[1], max(high), min(low),
+		(array_agg(close ORDER BY last_at DESC))[1], sum(total) / sum(cnt), sum(cnt)
+	FROM src GROUP BY bucket ORDER BY bucket`
+
+func (p *Postgres) GetCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_candles", time.Now())
+
+	unit, ok := candleUnits[interval]
+	if !ok {
+		return nil, errors.Wrapf(entities.ErrUnknownInterval, "unsupported interval: %v", interval)
+	}
+
+	log.Info("(GetCandles) getting coin candles", zap.Any("title", title), zap.Any("interval", interval))
+	rows, err := p.dbPool.Query(ctx, candlesQuery, title, unit, from, to)
+	if err != nil {
+		log.Error("(GetCandles) failed to get coin candles", zap.Any("err", err.Error()))
+		return nil, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	candles := make([]entities.Candle, 0)
+	for rows.Next() {
+		candle := entities.Candle{Title: title}
+		if err := rows.Scan(&candle.Bucket, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Avg, &candle.Count); err != nil {
+			log.Error("(GetCandles) failed to copy candle:", zap.Any("candle", candle), zap.Any("err", err.Error()))
+			return nil, errors.Wrapf(entities.ErrInternal, "failed to copy candle: %v", err)
+		}
+
+		candles = append(candles, candle)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("(GetCandles) unexpected error:", zap.Any("err", err))
+		return nil, errors.Wrapf(entities.ErrInternal, "unexpected error: %v", err)
+	}
+
+	return candles, nil
+}
+
+// cursorFetchSize - сколько строк выгрузки читается с сервера за один FETCH
+const cursorFetchSize = 1000
+
+// StreamHistory передаёт историю в fn по одной записи, читая её серверным курсором порциями,
+// так что в памяти не держится больше одной порции
+func (p *Postgres) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("stream_history", time.Now())
+
+	log.Info("(StreamHistory) streaming coin history", zap.Any("title", title), zap.Any("from", from), zap.Any("to", to))
+	return p.streamCursor(ctx, historyQuery, []any{title, from, to}, func(rows pgx.Rows) error {
+		coin := entities.Coin{Source: entities.SourceStorage}
+		if err := rows.Scan(&coin.Title, &coin.Price, &coin.CreatedAt, &coin.Backfilled); err != nil {
+			log.Error("(StreamHistory) failed to copy coin:", zap.Any("coin", coin), zap.Any("err", err.Error()))
+			return errors.Wrapf(entities.ErrInternal, "failed to copy coin: %v", err)
+		}
+
+		return fn(coin)
+	})
+}
+
+// StreamCandles - то же для свечей
+func (p *Postgres) StreamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("stream_candles", time.Now())
+
+	unit, ok := candleUnits[interval]
+	if !ok {
+		return errors.Wrapf(entities.ErrUnknownInterval, "unsupported interval: %v", interval)
+	}
+
+	log.Info("(StreamCandles) streaming coin candles", zap.Any("title", title), zap.Any("interval", interval))
+	return p.streamCursor(ctx, candlesQuery, []any{title, unit, from, to}, func(rows pgx.Rows) error {
+		candle := entities.Candle{Title: title}
+		if err := rows.Scan(&candle.Bucket, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Avg, &candle.Count); err != nil {
+			log.Error("(StreamCandles) failed to copy candle:", zap.Any("candle", candle), zap.Any("err", err.Error()))
+			return errors.Wrapf(entities.ErrInternal, "failed to copy candle: %v", err)
+		}
+
+		return fn(candle)
+	})
+}
+
+// streamCursor открывает курсор по query в read-only транзакции и вызывает scan для каждой строки;
+// ошибка scan прерывает выгрузку и возвращается как есть
+func (p *Postgres) streamCursor(ctx context.Context, query string, args []any, scan func(pgx.Rows) error) error {
+	log := logger.FromContext(ctx)
+	tx, err := p.dbPool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		log.Error("(streamCursor) failed to begin transaction", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to begin transaction: %v", err)
+	}
+	// курсор живёт только внутри транзакции, фиксировать нечего
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		log.Error("(streamCursor) failed to declare cursor", zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to declare cursor: %v", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_cursor", cursorFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			log.Error("(streamCursor) failed to fetch rows", zap.Any("err", err.Error()))
+			return errors.Wrapf(entities.ErrStorageUnavailable, "failed to fetch rows: %v", err)
+		}
+
+		n := 0
+		for rows.Next() {
+			n++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			log.Error("(streamCursor) unexpected error:", zap.Any("err", err))
+			return errors.Wrapf(entities.ErrStorageUnavailable, "unexpected error: %v", err)
+		}
+
+		if n < cursorFetchSize {
+			return nil
+		}
+	}
+}
+
+func (p *Postgres) Compact(ctx context.Context, rawBefore, hourlyBefore time.Time) (int64, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("compact", time.Now())
+
+	// в уже свёрнутый бакет могут попасть новые точки (дозагрузка истории за старый период).
+	// Свёртка не хранит исходных точек, поэтому бакет не суммируется со второй свёрткой, а пересчитывается
+	// так, чтобы повторная свёртка тех же точек его не меняла: open и close выбираются по моменту точки,
+	// high и low - по экстремумам, avg и count берутся из свёртки с большим числом точек
+	const onConflict = `ON CONFLICT (title, bucket) DO UPDATE SET
+		open = CASE WHEN EXCLUDED.open_at < %[1]s.open_at THEN EXCLUDED.open ELSE %[1]s.open END,
+		open_at = LEAST(%[1]s.open_at, EXCLUDED.open_at),
+		high = GREATEST(%[1]s.high, EXCLUDED.high),
+		low = LEAST(%[1]s.low, EXCLUDED.low),
+		close = CASE WHEN EXCLUDED.close_at > %[1]s.close_at THEN EXCLUDED.close ELSE %[1]s.close END,
+		close_at = GREATEST(%[1]s.close_at, EXCLUDED.close_at),
+		avg = CASE WHEN EXCLUDED.count > %[1]s.count THEN EXCLUDED.avg ELSE %[1]s.avg END,
+		count = GREATEST(%[1]s.count, EXCLUDED.count)`
+
+	rollupRaw := `INSERT INTO coins_1h (title, bucket, open, open_at, high, low, close, close_at, avg, count)
+		SELECT title, date_trunc('hour', created_at, 'UTC') AS b,
+			(array_agg(price ORDER BY created_at))[1], min(created_at), max(price), min(price),
+			(array_agg(price ORDER BY created_at DESC))[1], max(created_at), avg(price), count(*)
+		FROM coins WHERE created_at < $1 GROUP BY title, b ` + fmt.Sprintf(onConflict, "coins_1h")
+
+	rollupHourly := `INSERT INTO coins_1d (title, bucket, open, open_at, high, low, close, close_at, avg, count)
+		SELECT title, date_trunc('day', bucket, 'UTC') AS b,
+			(array_agg(open ORDER BY open_at))[1], min(open_at), max(high), min(low),
+			(array_agg(close ORDER BY close_at DESC))[1], max(close_at), sum(avg * count) / sum(count), sum(count)
+		FROM coins_1h WHERE bucket < $1 GROUP BY title, b ` + fmt.Sprintf(onConflict, "coins_1d")
+
+	tx, err := p.dbPool.Begin(ctx)
+	if err != nil {
+		log.Error("(Compact) failed to begin transaction", zap.Any("err", err.Error()))
+		return 0, errors.Wrapf(entities.ErrStorageUnavailable, "failed to begin transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	exec := func(query string, arg time.Time) (int64, error) {
+		res, err := tx.Exec(ctx, query, arg)
+		if err != nil {
+			log.Error("(Compact) failed to compact coins", zap.Any("err", err.Error()))
+			return 0, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+		}
+		return res.RowsAffected(), nil
+	}
+
+	if _, err := exec(rollupRaw, rawBefore); err != nil {
+		return 0, err
+	}
+
+	if _, err := exec(rollupHourly, hourlyBefore); err != nil {
+		return 0, err
+	}
+
+	deletedHourly, err := exec("DELETE FROM coins_1h WHERE bucket < $1", hourlyBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Error("(Compact) failed to commit transaction", zap.Any("err", err.Error()))
+		return 0, errors.Wrapf(entities.ErrStorageUnavailable, "failed to commit transaction: %v", err)
+	}
+
+	// свёртка уже сохранена, поэтому секции удаляются после неё, каждая в своей короткой транзакции:
+	// внутри долгой свёртки блокировка coins остановила бы и запись, и чтение курсов.
+	// Секции, целиком попавшие в свёртку, удаляем целиком - это дешевле построчного DELETE
+	if _, err := dropExpiredPartitions(ctx, p.dbPool, rawBefore); err != nil {
+		return 0, err
+	}
+
+	res, err := p.dbPool.Exec(ctx, "DELETE FROM coins WHERE created_at < $1", rawBefore)
+	if err != nil {
+		log.Error("(Compact) failed to compact coins", zap.Any("err", err.Error()))
+		return 0, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+	deleted := res.RowsAffected() + deletedHourly
+
+	log.Info("(Compact) coins compacted", zap.Any("rawBefore", rawBefore), zap.Any("hourlyBefore", hourlyBefore), zap.Any("deleted", deleted))
+	return deleted, nil
+}
+
+func (p *Postgres) GetCheckpoint(ctx context.Context, title string, interval entities.Interval) (time.Time, error) {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("get_checkpoint", time.Now())
+
+	query := "SELECT last_ts FROM backfill_checkpoints WHERE title = $1 AND interval = $2"
+
+	var lastTs time.Time
+	if err := p.dbPool.QueryRow(ctx, query, title, string(interval)).Scan(&lastTs); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, errors.Wrapf(entities.ErrNotFound, "no checkpoint for %v/%v", title, interval)
+		}
+
+		log.Error("(GetCheckpoint) failed to get checkpoint:", zap.Any("title", title), zap.Any("interval", interval), zap.Any("err", err.Error()))
+		return time.Time{}, errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	return lastTs, nil
+}
+
+func (p *Postgres) SaveCheckpoint(ctx context.Context, title string, interval entities.Interval, ts time.Time) error {
+	log := logger.FromContext(ctx)
+	defer metrics.ObserveStorageQuery("save_checkpoint", time.Now())
+
+	query := `INSERT INTO backfill_checkpoints (title, interval, last_ts) VALUES ($1, $2, $3)
+		ON CONFLICT (title, interval) DO UPDATE SET last_ts = EXCLUDED.last_ts, updated_at = now()`
+
+	if _, err := p.dbPool.Exec(ctx, query, title, string(interval), ts); err != nil {
+		log.Error("(SaveCheckpoint) failed to save checkpoint:", zap.Any("title", title), zap.Any("interval", interval), zap.Any("err", err.Error()))
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", err)
+	}
+
+	return nil
+}
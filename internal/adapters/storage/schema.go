@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:embed migrations/*.up.sql
+var migrations embed.FS
+
+// LatestMigration возвращает номер последней миграции, с которой собран бинарник
+func LatestMigration() (int, error) {
+	names, err := fs.Glob(migrations, "migrations/*.up.sql")
+	if err != nil {
+		return 0, errors.Wrapf(entities.ErrInternal, "failed to list migrations: %v", err)
+	}
+
+	latest := 0
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(name, "migrations/"), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return 0, errors.Wrapf(entities.ErrInternal, "invalid migration name %q", name)
+		}
+
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	if err := p.dbPool.Ping(ctx); err != nil {
+		return errors.Wrapf(entities.ErrStorageUnavailable, "failed to ping DB: %v", err)
+	}
+
+	return nil
+}
+
+// SchemaVersion возвращает применённую версию схемы и признак незавершённой миграции
+func (p *Postgres) SchemaVersion(ctx context.Context) (int, bool, error) {
+	var (
+		version int
+		dirty   bool
+	)
+
+	query := "SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1"
+	if err := p.dbPool.QueryRow(ctx, query).Scan(&version, &dirty); err != nil {
+		return 0, false, errors.Wrapf(entities.ErrStorageUnavailable, "failed to get schema version: %v", err)
+	}
+
+	return version, dirty, nil
+}
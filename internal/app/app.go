@@ -4,10 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
 
-	"github.com/robfig/cron"
 	"go.uber.org/zap"
 
 	"kursy-kriptovalyut/config"
@@ -20,16 +17,206 @@ import (
 
 var log = logger.NewLogger()
 
-type App struct{}
+// режимы запуска: serve - только HTTP API, worker - только актуализация курсов, all - всё вместе
+const (
+	ModeServe  = "serve"
+	ModeWorker = "worker"
+	ModeAll    = "all"
+)
+
+type App struct {
+	configPath string
+	// mode - режим из командной строки; пустой означает режим из конфига
+	mode string
+}
+
+// NewApp создаёт приложение; пустой configPath означает поиск конфига по умолчанию (см. config.LoadCfg)
+func NewApp(configPath string) *App {
+	return &App{configPath: configPath}
+}
+
+// Run запускает приложение в указанном режиме; если режим не передан, он берётся из конфига.
+func (a *App) Run(mode string) {
+	a.mode = mode
+	cfg := a.loadCfg()
+	mode = cfg.Cfg.Mode
+
+	stopTracing := setupTracing(cfg)
+	defer stopTracing()
+
+	switch mode {
+	case ModeServe:
+		a.runServe(cfg)
+	case ModeWorker:
+		a.runWorker(cfg)
+	case ModeAll, "":
+		a.runAll(cfg)
+	default:
+		log.Fatal("unknown run mode", zap.Any("mode", mode))
+	}
+}
+
+func (a *App) runServe(cfg *config.Config) {
+	pg := newPostgres(cfg)
+
+	var (
+		service *cases.Service
+		gaps    *cases.GapDetector
+		circuit *cases.CircuitBreaker
+		rates   *provider.CryptoCompare
+		history *provider.CryptoCompareHistory
+		err     error
+	)
+	if cfg.Cfg.ReadOnly {
+		log.Info("API is running in read-only mode, provider fallback disabled")
+		service, err = cases.NewReadOnlyService(pg)
+		if err == nil {
+			gaps, err = cases.NewReadOnlyGapDetector(pg)
+		}
+	} else {
+		rates, history = newCryptoCompare(cfg), newCryptoCompareHistory(cfg)
+		circuit = newCircuitBreaker(cfg, rates)
+		service, err = cases.NewService(circuit, pg)
+		if err == nil {
+			gaps, err = cases.NewGapDetector(pg, history)
+		}
+	}
+	if err != nil {
+		log.Fatal("failed to create service", zap.Any("err", err.Error()))
+	}
+	service.SetQuote(cfg.Cfg.Quote)
+	gaps.SetRawRetention(cfg.Cfg.RawRetention)
+
+	health := newHealthChecker(cfg, pg, circuit)
+	appliers := []func(cfg *config.Config){reloadHealth(health)}
+	if circuit != nil {
+		appliers = append(appliers, reloadCircuit(circuit), reloadProviders(rates, history, nil))
+	}
+
+	lc := newLifecycle(cfg.Cfg.ShutdownTimeout)
+	lc.onStop("postgres", closePostgres(pg))
 
-func NewApp() *App {
-	return &App{}
+	auth := newAuth(cfg, pg)
+	if auth != nil {
+		lc.onStop("api key usage", startUsageFlusher(auth))
+	}
+
+	limiters := newRateLimiters(cfg, service)
+	if limiters != nil {
+		appliers = append(appliers, reloadRateLimits(limiters))
+	}
+
+	catalog := newReadOnlyAssetCatalog(pg)
+	service.SetCatalog(catalog)
+	lc.onStop("asset catalog", startAssetRefresh(catalog, cfg.Cfg.AssetsRefreshInterval))
+
+	st := startStream(pg, service)
+	srv, api := newHTTPServer(cfg, service, gaps, st.hub, health, auth, catalog, limiters)
+	appliers = append(appliers, reloadServer(api))
+	// подписки закрываются, как только сервер перестаёт принимать соединения, иначе долгие SSE-запросы не дадут ему остановиться
+	srv.RegisterOnShutdown(st.stop)
+	grpcSrv := newGRPCServer(service, st.hub, auth, limiters)
+
+	go serveGRPC(grpcSrv, cfg.Cfg.GrpcPort)
+	lc.onStop("grpc", shutdownGRPCServer(grpcSrv))
+	go listenAndServe(srv)
+	lc.onStop("http", srv.Shutdown)
+	lc.onStop("config reload", a.watchConfig(cfg, appliers...))
+
+	lc.wait()
 }
 
-func (a *App) Run() {
-	cfg := config.LoadCfg()
-	baseUrl := cfg.Cfg.Url
-	apiKey := cfg.Cfg.ApiKey
+func (a *App) runWorker(cfg *config.Config) {
+	pg := newPostgres(cfg)
+
+	rates, assets := newCryptoCompare(cfg), newCryptoCompareAssets(cfg)
+	circuit := newCircuitBreaker(cfg, rates)
+	service, err := cases.NewService(circuit, pg)
+	if err != nil {
+		log.Fatal("failed to create service", zap.Any("err", err.Error()))
+	}
+	service.SetQuote(cfg.Cfg.Quote)
+
+	lc := newLifecycle(cfg.Cfg.ShutdownTimeout)
+	lc.onStop("postgres", closePostgres(pg))
+
+	w := startWorker(cfg, pg, service, newAssetCatalog(pg, assets))
+	lc.onStop("worker", w.stop)
+	srv := newMetricsServer(cfg.Cfg.Port)
+	go listenAndServe(srv)
+	lc.onStop("http", srv.Shutdown)
+	lc.onStop("config reload", a.watchConfig(cfg, w.reschedule, reloadCircuit(circuit), reloadProviders(rates, nil, assets)))
+
+	log.Info("Worker started")
+	lc.wait()
+}
+
+func (a *App) runAll(cfg *config.Config) {
+	pg := newPostgres(cfg)
+
+	rates, history, assets := newCryptoCompare(cfg), newCryptoCompareHistory(cfg), newCryptoCompareAssets(cfg)
+	circuit := newCircuitBreaker(cfg, rates)
+	service, err := cases.NewService(circuit, pg)
+	if err != nil {
+		log.Fatal("failed to create service", zap.Any("err", err.Error()))
+	}
+	service.SetQuote(cfg.Cfg.Quote)
+
+	gaps, err := cases.NewGapDetector(pg, history)
+	if err != nil {
+		log.Fatal("failed to create gap detector", zap.Any("err", err.Error()))
+	}
+	gaps.SetRawRetention(cfg.Cfg.RawRetention)
+
+	lc := newLifecycle(cfg.Cfg.ShutdownTimeout)
+	lc.onStop("postgres", closePostgres(pg))
+
+	catalog := newAssetCatalog(pg, assets)
+	service.SetCatalog(catalog)
+	lc.onStop("asset catalog", startAssetRefresh(catalog, cfg.Cfg.AssetsRefreshInterval))
+
+	w := startWorker(cfg, pg, service, catalog)
+	lc.onStop("worker", w.stop)
+	health := newHealthChecker(cfg, pg, circuit)
+
+	auth := newAuth(cfg, pg)
+	if auth != nil {
+		lc.onStop("api key usage", startUsageFlusher(auth))
+	}
+
+	appliers := []func(cfg *config.Config){w.reschedule, reloadCircuit(circuit), reloadHealth(health), reloadProviders(rates, history, assets)}
+	limiters := newRateLimiters(cfg, service)
+	if limiters != nil {
+		appliers = append(appliers, reloadRateLimits(limiters))
+	}
+
+	st := startStream(pg, service)
+	srv, api := newHTTPServer(cfg, service, gaps, st.hub, health, auth, catalog, limiters)
+	appliers = append(appliers, reloadServer(api))
+	srv.RegisterOnShutdown(st.stop)
+	grpcSrv := newGRPCServer(service, st.hub, auth, limiters)
+
+	go serveGRPC(grpcSrv, cfg.Cfg.GrpcPort)
+	lc.onStop("grpc", shutdownGRPCServer(grpcSrv))
+	go listenAndServe(srv)
+	lc.onStop("http", srv.Shutdown)
+	lc.onStop("config reload", a.watchConfig(cfg, appliers...))
+
+	lc.wait()
+}
+
+// loadCfg читает и проверяет конфиг; с неверным конфигом приложение не запускается
+func (a *App) loadCfg() *config.Config {
+	cfg, err := config.LoadCfg(a.configPath, a.mode)
+	if err != nil {
+		log.Fatal("failed to load config", zap.Any("err", err.Error()))
+	}
+	setupLogger(cfg)
+
+	return cfg
+}
+
+func newPostgres(cfg *config.Config) *storage.Postgres {
 	user := cfg.Cfg.PgUser
 	pswd := cfg.Cfg.PgPswd
 	host := cfg.Cfg.PgHost
@@ -37,62 +224,69 @@ func (a *App) Run() {
 	db := cfg.Cfg.PgDB
 	connStr := fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable", user, pswd, host, port, db)
 
-	provider, err := provider.NewCryptoCompare(baseUrl, apiKey)
+	pg, err := storage.NewPostgres(connStr)
 	if err != nil {
-		log.Fatal("failed to create provider", zap.Any("err", err.Error()))
+		log.Fatal("failed to create storage", zap.Any("err", err.Error()))
 	}
 
-	storage, err := storage.NewPostgres(connStr)
+	return pg
+}
+
+func newCryptoCompare(cfg *config.Config) *provider.CryptoCompare {
+	cc, err := provider.NewCryptoCompare(cfg.Cfg.Url, cfg.Cfg.ApiKey, cfg.Cfg.Quote, cfg.Cfg.ProviderTimeout)
 	if err != nil {
-		log.Fatal("failed to create storage", zap.Any("err", err.Error()))
+		log.Fatal("failed to create provider", zap.Any("err", err.Error()))
 	}
 
-	service, err := cases.NewService(provider, storage)
+	return cc
+}
+
+func newCryptoCompareHistory(cfg *config.Config) *provider.CryptoCompareHistory {
+	history, err := provider.NewCryptoCompareHistory(cfg.Cfg.HistoryUrl, cfg.Cfg.ApiKey, cfg.Cfg.Quote, cfg.Cfg.ProviderTimeout)
 	if err != nil {
-		log.Fatal("failed to create service", zap.Any("err", err.Error()))
+		log.Fatal("failed to create history provider", zap.Any("err", err.Error()))
 	}
 
-	ctx := context.Background()
-	cron := SetCron(service, ctx)
-	cron.Start()
+	return history
+}
 
-	server, err := ports.NewServer(service)
+// newHTTPServer создаёт HTTP API; с пустым auth API открыто, с пустым limiters - без лимитов запросов.
+// Вместе с http.Server возвращается обработчик API, чтобы применять к нему перезагруженный конфиг
+func newHTTPServer(cfg *config.Config, service ports.Service, admin ports.AdminService, hub ports.StreamHub, health ports.HealthService, auth *cases.Auth, catalog *cases.AssetCatalog, limiters *rateLimiters) (*http.Server, *ports.Server) {
+	server, err := ports.NewServer(service, admin, hub, health)
 	if err != nil {
 		log.Fatal("failed to create server", zap.Any("err", err.Error()))
 	}
-
-	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: server,
+	if auth != nil {
+		server.SetAuth(auth)
 	}
+	if catalog != nil {
+		server.SetAssets(catalog)
+	}
+	if limiters != nil {
+		server.SetRateLimiter(limiters.requests)
+	}
+	server.SetTrustProxy(cfg.Cfg.RateLimitTrustProxy)
+	server.SetCORS(cfg.Cfg.CorsAllowedOrigins, cfg.Cfg.CorsMaxAge)
+	server.SetMaxQueryRange(cfg.Cfg.MaxQueryRange)
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-
-	go func() {
-		<-quit
-		log.Info("Shutting down server...")
-
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		if err := srv.Shutdown(ctx); err != nil {
-			log.Error("server shutdown", zap.Any("err", err.Error()))
-		}
-	}()
+	return &http.Server{
+		Addr:    cfg.Cfg.Port,
+		Handler: server,
+	}, server
+}
 
-	log.Info("Server running on port :8080")
+func listenAndServe(srv *http.Server) {
+	log.Info("Server running on port " + srv.Addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal("failed to start server", zap.Any("err", err.Error()))
 	}
 }
 
-func SetCron(srvc *cases.Service, ctx context.Context) *cron.Cron {
-	c := cron.New()
-	err := c.AddFunc("@every 1m", func() { _ = srvc.ActualizeRates(ctx) })
-	if err != nil {
-		log.Error("cron job failed", zap.Any("err", err.Error()))
+// closePostgres закрывает пул последним, когда запросы и плановые задачи уже завершены
+func closePostgres(pg *storage.Postgres) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		pg.Close()
+		return nil
 	}
-
-	return c
 }
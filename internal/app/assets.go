@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/adapters/provider"
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+)
+
+func newCryptoCompareAssets(cfg *config.Config) *provider.CryptoCompareAssets {
+	assets, err := provider.NewCryptoCompareAssets(cfg.Cfg.AssetsUrl, cfg.Cfg.ApiKey, cfg.Cfg.ProviderTimeout)
+	if err != nil {
+		log.Fatal("failed to create asset provider", zap.Any("err", err.Error()))
+	}
+
+	return assets
+}
+
+// newAssetCatalog создаёт каталог монет, который синхронизируется со списком монет CryptoCompare
+func newAssetCatalog(pg *storage.Postgres, assets *provider.CryptoCompareAssets) *cases.AssetCatalog {
+	catalog, err := cases.NewAssetCatalog(pg, assets)
+	if err != nil {
+		log.Fatal("failed to create asset catalog", zap.Any("err", err.Error()))
+	}
+
+	return catalog
+}
+
+// newReadOnlyAssetCatalog создаёт каталог для реплик API: список монет загружает worker
+func newReadOnlyAssetCatalog(pg *storage.Postgres) *cases.AssetCatalog {
+	catalog, err := cases.NewReadOnlyAssetCatalog(pg)
+	if err != nil {
+		log.Fatal("failed to create asset catalog", zap.Any("err", err.Error()))
+	}
+
+	return catalog
+}
+
+// startAssetRefresh читает каталог из хранилища и перечитывает его раз в interval, подхватывая синхронизации worker'а
+func startAssetRefresh(catalog *cases.AssetCatalog, interval time.Duration) func(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := catalog.Refresh(ctx); err != nil {
+		log.Warn("asset catalog is not loaded, unknown coins are not rejected until the next refresh", zap.Any("err", err.Error()))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		catalog.Run(ctx, interval)
+		close(done)
+	}()
+
+	return func(ctx context.Context) error {
+		cancel()
+		<-done
+		return nil
+	}
+}
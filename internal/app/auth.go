@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+const usageFlushInterval = time.Minute
+
+// newAuth создаёт проверку API-ключей; без auth-enabled возвращает nil, и API остаётся открытым
+func newAuth(cfg *config.Config, pg *storage.Postgres) *cases.Auth {
+	if !cfg.Cfg.AuthEnabled {
+		log.Warn("API keys are disabled, API is open to everyone")
+		return nil
+	}
+
+	auth, err := cases.NewAuth(pg, cfg.Cfg.AuthCacheTTL, cfg.Cfg.AuthRateLimit)
+	if err != nil {
+		log.Fatal("failed to create auth", zap.Any("err", err.Error()))
+	}
+
+	return auth
+}
+
+// startUsageFlusher периодически сохраняет использование ключей; возвращаемая функция
+// сохраняет оставшиеся счётчики и должна вызываться до закрытия хранилища
+func startUsageFlusher(auth *cases.Auth) func(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		auth.Run(ctx, usageFlushInterval)
+		close(done)
+	}()
+
+	return func(ctx context.Context) error {
+		cancel()
+		<-done
+		return auth.FlushUsage(ctx)
+	}
+}
+
+// Keys управляет API-ключами без HTTP API - так выпускается первый ключ с правом admin:
+// cryptorate keys issue --name ops --scopes admin [--rate-limit 600]
+// cryptorate keys revoke --id 1
+// cryptorate keys list
+func (a *App) Keys(args []string) {
+	if len(args) == 0 {
+		log.Fatal("(keys) missing command: issue, revoke or list")
+	}
+
+	fs := flag.NewFlagSet("keys "+args[0], flag.ExitOnError)
+	nameFlag := fs.String("name", "", "key owner, e.g. partner team name")
+	scopesFlag := fs.String("scopes", string(entities.ScopeRead), "comma-separated list of scopes: read, stream, admin")
+	rateLimitFlag := fs.Int("rate-limit", 0, "requests per minute (default: auth-rate-limit from config)")
+	idFlag := fs.Int64("id", 0, "key id")
+	_ = fs.Parse(args[1:])
+
+	cfg := a.loadCfg()
+	pg := newPostgres(cfg)
+	defer pg.Close()
+
+	auth, err := cases.NewAuth(pg, cfg.Cfg.AuthCacheTTL, cfg.Cfg.AuthRateLimit)
+	if err != nil {
+		log.Fatal("failed to create auth", zap.Any("err", err.Error()))
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "issue":
+		var scopes []entities.Scope
+		for _, raw := range strings.Split(*scopesFlag, ",") {
+			scope, err := entities.ParseScope(raw)
+			if err != nil {
+				log.Fatal("(keys) invalid --scopes flag", zap.Any("err", err.Error()))
+			}
+			scopes = append(scopes, scope)
+		}
+
+		key, token, err := auth.IssueKey(ctx, *nameFlag, scopes, *rateLimitFlag)
+		if err != nil {
+			log.Fatal("(keys) failed to issue key", zap.Any("err", err.Error()))
+		}
+
+		// ключ печатается отдельно от логов, чтобы его можно было забрать из stdout
+		fmt.Fprintf(os.Stdout, "id: %d\nkey: %s\n", key.ID, token)
+	case "revoke":
+		if err := auth.RevokeKey(ctx, *idFlag); err != nil {
+			log.Fatal("(keys) failed to revoke key", zap.Any("err", err.Error()))
+		}
+	case "list":
+		keys, err := auth.ListKeys(ctx)
+		if err != nil {
+			log.Fatal("(keys) failed to list keys", zap.Any("err", err.Error()))
+		}
+
+		for _, key := range keys {
+			state := "active"
+			if key.Revoked() {
+				state = "revoked"
+			}
+			fmt.Fprintf(os.Stdout, "%d\t%s\t%s\t%v\t%d/min\t%s\n", key.ID, key.Prefix, key.Name, key.Scopes, key.RateLimit, state)
+		}
+	default:
+		log.Fatal("(keys) unknown command, expected issue, revoke or list", zap.Any("command", args[0]))
+	}
+}
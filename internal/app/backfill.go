@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"os/signal"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+const backfillPageSize = 2000
+
+// Backfill загружает историю курсов:
+// cryptorate backfill --titles BTC,ETH --from 2024-01-01 --interval 1h
+func (a *App) Backfill(args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	titlesFlag := fs.String("titles", "", "comma-separated list of coin titles")
+	fromFlag := fs.String("from", "", "start date, YYYY-MM-DD")
+	intervalFlag := fs.String("interval", string(entities.IntervalHour), "history interval: 1m, 1h or 1d")
+	_ = fs.Parse(args)
+
+	if *titlesFlag == "" {
+		log.Fatal("(backfill) missing --titles flag")
+	}
+	titles, err := entities.NormalizeTitles(strings.Split(*titlesFlag, ","))
+	if err != nil {
+		log.Fatal("(backfill) invalid --titles flag", zap.Any("err", err.Error()))
+	}
+
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		log.Fatal("(backfill) invalid --from flag", zap.Any("err", err.Error()))
+	}
+
+	interval, err := entities.ParseInterval(*intervalFlag)
+	if err != nil {
+		log.Fatal("(backfill) invalid --interval flag", zap.Any("err", err.Error()))
+	}
+
+	cfg := a.loadCfg()
+	pg := newPostgres(cfg)
+	defer pg.Close()
+
+	backfiller, err := cases.NewBackfiller(newCryptoCompareHistory(cfg), pg, pg, cfg.Cfg.BackfillRps, backfillPageSize)
+	if err != nil {
+		log.Fatal("failed to create backfiller", zap.Any("err", err.Error()))
+	}
+
+	// прерванная загрузка продолжится с последней сохранённой страницы
+	ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals...)
+	defer stop()
+
+	log.Info("(backfill) started", zap.Any("titles", titles), zap.Any("from", from), zap.Any("interval", interval))
+	if err := backfiller.Backfill(ctx, titles, from, interval); err != nil {
+		log.Fatal("(backfill) failed", zap.Any("err", err.Error()))
+	}
+
+	log.Info("(backfill) completed")
+}
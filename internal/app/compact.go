@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"os/signal"
+
+	"go.uber.org/zap"
+)
+
+// Compact однократно создаёт недостающие секции и сворачивает устаревшие данные согласно настройкам хранения:
+// cryptorate compact
+func (a *App) Compact() {
+	cfg := a.loadCfg()
+	pg := newPostgres(cfg)
+	defer pg.Close()
+	retention := newRetention(cfg, pg)
+	partitions := newPartitionManager(cfg, pg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals...)
+	defer stop()
+
+	if err := partitions.CreateAhead(ctx); err != nil {
+		log.Fatal("(compact) failed to create partitions", zap.Any("err", err.Error()))
+	}
+
+	if err := retention.Compact(ctx); err != nil {
+		log.Fatal("(compact) failed", zap.Any("err", err.Error()))
+	}
+
+	log.Info("(compact) completed")
+}
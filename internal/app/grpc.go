@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/ports"
+)
+
+// newGRPCServer создаёт gRPC API; с пустым auth API открыто, с пустым limiters - без лимитов запросов
+func newGRPCServer(service ports.Service, hub ports.StreamHub, auth *cases.Auth, limiters *rateLimiters) *grpc.Server {
+	rates, err := ports.NewGRPCServer(service, hub)
+	if err != nil {
+		log.Fatal("failed to create grpc server", zap.Any("err", err.Error()))
+	}
+
+	var limiter ports.RequestLimiter
+	if limiters != nil {
+		limiter = limiters.requests
+	}
+
+	unary := []grpc.UnaryServerInterceptor{ports.UnaryRequestLogger, ports.UnaryRateLimit(limiter)}
+	stream := []grpc.StreamServerInterceptor{ports.StreamRequestLogger, ports.StreamRateLimit(limiter)}
+	if auth != nil {
+		unary = append(unary, ports.UnaryAuth(auth))
+		stream = append(stream, ports.StreamAuth(auth))
+	}
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	rates.Register(srv)
+	reflection.Register(srv)
+
+	return srv
+}
+
+func serveGRPC(srv *grpc.Server, addr string) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal("failed to listen grpc port", zap.Any("err", err.Error()))
+	}
+
+	log.Info("gRPC server running on port " + addr)
+	if err := srv.Serve(lis); err != nil {
+		log.Fatal("failed to start grpc server", zap.Any("err", err.Error()))
+	}
+}
+
+// shutdownGRPCServer дожидается завершения текущих вызовов, но не дольше ctx, после чего закрывает соединения
+func shutdownGRPCServer(srv *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			log.Warn("grpc graceful stop timed out, closing connections")
+			srv.Stop()
+			return errors.Wrap(ctx.Err(), "grpc graceful stop timed out")
+		}
+	}
+}
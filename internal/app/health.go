@@ -0,0 +1,43 @@
+package app
+
+import (
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/adapters/provider"
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+)
+
+// newCircuitBreaker защищает провайдера курсов предохранителем
+func newCircuitBreaker(cfg *config.Config, rates *provider.CryptoCompare) *cases.CircuitBreaker {
+	circuit, err := cases.NewCircuitBreaker(rates, cfg.Cfg.ProviderFailureThreshold, cfg.Cfg.ProviderCooldown)
+	if err != nil {
+		log.Fatal("failed to create provider circuit breaker", zap.Any("err", err.Error()))
+	}
+
+	return circuit
+}
+
+// newHealthChecker создаёт проверки для /readyz и /status; circuit пустой в режиме read-only
+func newHealthChecker(cfg *config.Config, pg *storage.Postgres, circuit *cases.CircuitBreaker) *cases.HealthChecker {
+	version, err := storage.LatestMigration()
+	if err != nil {
+		log.Fatal("failed to get schema version", zap.Any("err", err.Error()))
+	}
+
+	health, err := cases.NewHealthChecker(pg, version, cfg.Cfg.ReadyMaxLag)
+	if err != nil {
+		log.Fatal("failed to create health checker", zap.Any("err", err.Error()))
+	}
+
+	if err := health.SetStatusMaxLag(cfg.Cfg.StatusMaxLag); err != nil {
+		log.Fatal("failed to set status max lag", zap.Any("err", err.Error()))
+	}
+
+	if circuit != nil {
+		health.SetCircuit(circuit)
+	}
+
+	return health
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// shutdownSignals - сигналы остановки: SIGINT из терминала, SIGTERM от оркестратора
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+type stopHook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// lifecycle останавливает компоненты приложения в порядке, обратном регистрации:
+// сначала перестаём принимать запросы, затем дожидаемся плановых задач и только потом закрываем хранилище.
+// На всю остановку отводится timeout
+type lifecycle struct {
+	timeout time.Duration
+	hooks   []stopHook
+}
+
+func newLifecycle(timeout time.Duration) *lifecycle {
+	return &lifecycle{timeout: timeout}
+}
+
+// onStop регистрирует остановку компонента; регистрировать нужно сразу после запуска
+func (l *lifecycle) onStop(name string, stop func(ctx context.Context) error) {
+	l.hooks = append(l.hooks, stopHook{name: name, stop: stop})
+}
+
+// wait блокируется до сигнала остановки, после чего останавливает компоненты
+func (l *lifecycle) wait() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, shutdownSignals...)
+	sig := <-quit
+	signal.Stop(quit)
+
+	log.Info("Shutting down...", zap.Any("signal", sig.String()))
+	l.shutdown()
+}
+
+func (l *lifecycle) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if err := hook.stop(ctx); err != nil {
+			log.Error("(lifecycle) failed to stop component", zap.Any("component", hook.name), zap.Any("err", err.Error()))
+			continue
+		}
+		log.Info("(lifecycle) component stopped", zap.Any("component", hook.name))
+	}
+
+	log.Info("Shutdown completed")
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// setupLogger применяет настройки логирования из конфига
+func setupLogger(cfg *config.Config) {
+	if err := logger.Configure(loggerOptions(cfg)); err != nil {
+		log.Fatal("failed to configure logger", zap.Any("err", err.Error()))
+	}
+}
+
+// reloadLogger - то же при перезагрузке конфига: с ошибкой продолжаем работать с прежними настройками.
+// Уровень меняется, только если изменился log-level: иначе сбрасывался бы уровень, выставленный через PUT /admin/log-level
+func reloadLogger(cfg *config.Config, levelChanged bool) {
+	opts := loggerOptions(cfg)
+	if !levelChanged {
+		opts.Level = ""
+	}
+
+	if err := logger.Configure(opts); err != nil {
+		log.Error("(reload) failed to reconfigure logger", zap.Any("err", err.Error()))
+	}
+}
+
+func loggerOptions(cfg *config.Config) logger.Options {
+	return logger.Options{
+		Level:              cfg.Cfg.LogLevel,
+		Format:             cfg.Cfg.LogFormat,
+		Output:             cfg.Cfg.LogOutput,
+		File:               cfg.Cfg.LogFile,
+		MaxSizeMB:          cfg.Cfg.LogMaxSize,
+		MaxAgeDays:         cfg.Cfg.LogMaxAge,
+		MaxBackups:         cfg.Cfg.LogMaxBackups,
+		Compress:           cfg.Cfg.LogCompress,
+		SamplingInitial:    cfg.Cfg.LogSamplingInitial,
+		SamplingThereafter: cfg.Cfg.LogSamplingThereafter,
+	}
+}
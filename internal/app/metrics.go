@@ -0,0 +1,18 @@
+package app
+
+import (
+	"net/http"
+
+	"kursy-kriptovalyut/internal/metrics"
+)
+
+// newMetricsServer - HTTP-сервер только с /metrics для режима worker, в котором API не поднимается
+func newMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+// rateLimiters - лимиты запросов к API по IP и маршруту и отдельный, более строгий бюджет
+// запросов, которые приводят к вызову провайдера
+type rateLimiters struct {
+	requests *cases.ClientLimiter
+	provider *cases.ClientLimiter
+}
+
+// newRateLimiters создаёт лимиты и подключает бюджет провайдера к сервису; без rate-limit-enabled возвращает nil
+func newRateLimiters(cfg *config.Config, service *cases.Service) *rateLimiters {
+	if !cfg.Cfg.RateLimitEnabled {
+		log.Warn("rate limiting is disabled, clients may exhaust provider quota")
+		return nil
+	}
+
+	if !cfg.Cfg.RateLimitTrustProxy {
+		log.Info("rate limits are counted per peer address, behind a load balancer enable rate-limit-trust-proxy")
+	}
+
+	requestBudget, routes, providerBudget := rateBudgets(cfg)
+	requests, err := cases.NewClientLimiter(requestBudget, routes)
+	if err != nil {
+		log.Fatal("failed to create rate limiter", zap.Any("err", err.Error()))
+	}
+
+	provider, err := cases.NewClientLimiter(providerBudget, nil)
+	if err != nil {
+		log.Fatal("failed to create provider rate limiter", zap.Any("err", err.Error()))
+	}
+	service.SetProviderLimiter(provider)
+
+	return &rateLimiters{requests: requests, provider: provider}
+}
+
+func rateBudgets(cfg *config.Config) (entities.RateBudget, map[string]entities.RateBudget, entities.RateBudget) {
+	routes := make(map[string]entities.RateBudget, len(cfg.Cfg.RateLimitRoutes))
+	for route, limit := range cfg.Cfg.RateLimitRoutes {
+		routes[route] = entities.RateBudget{RPS: limit.Rps, Burst: limit.Burst}
+	}
+
+	return entities.RateBudget{RPS: cfg.Cfg.RateLimitRps, Burst: cfg.Cfg.RateLimitBurst},
+		routes,
+		entities.RateBudget{RPS: cfg.Cfg.RateLimitProviderRps, Burst: cfg.Cfg.RateLimitProviderBurst}
+}
+
+func reloadRateLimits(limiters *rateLimiters) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		requestBudget, routes, providerBudget := rateBudgets(cfg)
+		if err := limiters.requests.SetBudgets(requestBudget, routes); err != nil {
+			log.Error("(reload) failed to update rate limits", zap.Any("err", err.Error()))
+		}
+
+		if err := limiters.provider.SetBudgets(providerBudget, nil); err != nil {
+			log.Error("(reload) failed to update provider rate limits", zap.Any("err", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/adapters/provider"
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/ports"
+)
+
+// reloader перечитывает конфиг по SIGHUP и при изменении файла. Применяются только ключи,
+// безопасные без перезапуска (config.Change.Reloadable); остальные изменения отклоняются с записью в лог
+type reloader struct {
+	path     string
+	appliers []func(cfg *config.Config)
+
+	mu  sync.Mutex
+	cfg *config.Config
+}
+
+// watchConfig запускает перезагрузку конфига; appliers получают конфиг с уже применёнными изменениями.
+// Возвращаемая функция прекращает наблюдение
+func (a *App) watchConfig(cfg *config.Config, appliers ...func(cfg *config.Config)) func(ctx context.Context) error {
+	r := &reloader{
+		path:     cfg.File,
+		appliers: appliers,
+		cfg:      cfg,
+	}
+	if r.path == "" {
+		r.path = a.configPath
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				r.reload("SIGHUP")
+			}
+		}
+	}()
+
+	if cfg.File != "" {
+		if err := config.Watch(ctx, cfg.File, func() { r.reload("file changed") }); err != nil {
+			log.Warn("(reload) config file is not watched, use SIGHUP to reload", zap.Any("err", err.Error()))
+		}
+	}
+
+	return func(context.Context) error {
+		signal.Stop(hup)
+		cancel()
+		return nil
+	}
+}
+
+func (r *reloader) reload(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.LoadCfg(r.path, r.cfg.Cfg.Mode)
+	if err != nil {
+		log.Error("(reload) new config rejected", zap.Any("reason", reason), zap.Any("err", err.Error()))
+		return
+	}
+
+	var safe []config.Change
+	var unsafe []string
+	for _, change := range config.Diff(r.cfg, next) {
+		if change.Reloadable() {
+			safe = append(safe, change)
+		} else {
+			unsafe = append(unsafe, change.String())
+		}
+	}
+
+	if len(unsafe) > 0 {
+		log.Warn("(reload) changes require restart and are not applied", zap.Any("reason", reason), zap.Strings("diff", unsafe))
+	}
+
+	if len(safe) == 0 {
+		log.Info("(reload) nothing to apply", zap.Any("reason", reason))
+		return
+	}
+
+	r.cfg = config.Apply(r.cfg, safe)
+	if logChanged, levelChanged := logChanges(safe); logChanged {
+		reloadLogger(r.cfg, levelChanged)
+	}
+	for _, apply := range r.appliers {
+		apply(r.cfg)
+	}
+
+	applied := make([]string, 0, len(safe))
+	for _, change := range safe {
+		applied = append(applied, change.String())
+	}
+	log.Info("(reload) config applied", zap.Any("reason", reason), zap.Strings("diff", applied))
+}
+
+// logChanges сообщает, затронуты ли настройки логирования и отдельно - уровень
+func logChanges(changes []config.Change) (logChanged, levelChanged bool) {
+	for _, change := range changes {
+		if strings.HasPrefix(change.Key, "log-") {
+			logChanged = true
+		}
+		if change.Key == "log-level" {
+			levelChanged = true
+		}
+	}
+
+	return logChanged, levelChanged
+}
+
+func reloadCircuit(circuit *cases.CircuitBreaker) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		if err := circuit.SetLimits(cfg.Cfg.ProviderFailureThreshold, cfg.Cfg.ProviderCooldown); err != nil {
+			log.Error("(reload) failed to update circuit breaker", zap.Any("err", err.Error()))
+		}
+	}
+}
+
+func reloadHealth(health *cases.HealthChecker) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		if err := health.SetReadyMaxLag(cfg.Cfg.ReadyMaxLag); err != nil {
+			log.Error("(reload) failed to update readiness lag", zap.Any("err", err.Error()))
+		}
+
+		if err := health.SetStatusMaxLag(cfg.Cfg.StatusMaxLag); err != nil {
+			log.Error("(reload) failed to update status lag", zap.Any("err", err.Error()))
+		}
+	}
+}
+
+// reloadProviders подменяет адреса, ключ API и таймаут провайдера; адаптеры, не созданные в этом режиме, пустые
+func reloadProviders(rates *provider.CryptoCompare, history *provider.CryptoCompareHistory, assets *provider.CryptoCompareAssets) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		if rates != nil {
+			if err := rates.SetEndpoint(cfg.Cfg.Url, cfg.Cfg.ApiKey, cfg.Cfg.ProviderTimeout); err != nil {
+				log.Error("(reload) failed to update rates provider", zap.Any("err", err.Error()))
+			}
+		}
+
+		if history != nil {
+			if err := history.SetEndpoint(cfg.Cfg.HistoryUrl, cfg.Cfg.ApiKey, cfg.Cfg.ProviderTimeout); err != nil {
+				log.Error("(reload) failed to update history provider", zap.Any("err", err.Error()))
+			}
+		}
+
+		if assets != nil {
+			if err := assets.SetEndpoint(cfg.Cfg.AssetsUrl, cfg.Cfg.ApiKey, cfg.Cfg.ProviderTimeout); err != nil {
+				log.Error("(reload) failed to update asset provider", zap.Any("err", err.Error()))
+			}
+		}
+	}
+}
+
+func reloadServer(server *ports.Server) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		server.SetCORS(cfg.Cfg.CorsAllowedOrigins, cfg.Cfg.CorsMaxAge)
+		server.SetMaxQueryRange(cfg.Cfg.MaxQueryRange)
+	}
+}
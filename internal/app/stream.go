@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+)
+
+const (
+	streamReplaySize    = 1024
+	streamBufferSize    = 64
+	streamWatchInterval = 5 * time.Second
+)
+
+// stream - шина событий о новых ценах для подписчиков API. Цены, записанные этим процессом,
+// публикует сервис, а записанные другими репликами подхватывает наблюдатель за хранилищем.
+type stream struct {
+	hub         *cases.Hub
+	cancel      context.CancelFunc
+	watcherDone chan struct{}
+}
+
+func startStream(pg *storage.Postgres, service *cases.Service) *stream {
+	hub, err := cases.NewHub(streamReplaySize, streamBufferSize)
+	if err != nil {
+		log.Fatal("failed to create stream hub", zap.Any("err", err.Error()))
+	}
+	service.SetPublisher(hub)
+
+	watcher, err := cases.NewStorageWatcher(pg, hub, streamWatchInterval)
+	if err != nil {
+		log.Fatal("failed to create storage watcher", zap.Any("err", err.Error()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &stream{
+		hub:         hub,
+		cancel:      cancel,
+		watcherDone: make(chan struct{}),
+	}
+
+	go func() {
+		watcher.Run(ctx)
+		close(st.watcherDone)
+	}()
+
+	return st
+}
+
+// stop останавливает наблюдатель и закрывает подписки, чтобы долгие соединения не мешали остановке сервера
+func (st *stream) stop() {
+	st.cancel()
+	<-st.watcherDone
+	st.hub.Close()
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/tracing"
+)
+
+const (
+	serviceName          = "cryptorate"
+	tracingFlushDeadline = 5 * time.Second
+)
+
+// setupTracing включает экспорт трасс; возвращаемая функция дописывает накопленные span'ы
+func setupTracing(cfg *config.Config) func() {
+	shutdown, err := tracing.Setup(tracing.Config{
+		ServiceName: serviceName,
+		Exporter:    cfg.Cfg.TraceExporter,
+		File:        cfg.Cfg.TraceFile,
+		Endpoint:    cfg.Cfg.TraceEndpoint,
+		SampleRatio: cfg.Cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		log.Fatal("failed to set up tracing", zap.Any("err", err.Error()))
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushDeadline)
+		defer cancel()
+
+		if err := shutdown(ctx); err != nil {
+			log.Error("failed to flush traces", zap.Any("err", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"github.com/robfig/cron"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/config"
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/metrics"
+)
+
+// worker - плановая актуализация курсов, синхронизация каталога монет, обслуживание секций и свёртка старых данных вместе с выборами лидера
+type worker struct {
+	service    *cases.Service
+	catalog    *cases.AssetCatalog
+	elector    *cases.LeaderElector
+	retention  *cases.Retention
+	partitions *storage.PartitionManager
+
+	// задачи планировщика отменяются и дожидаются при остановке, выборы лидера останавливаются после них
+	jobs          *cases.Jobs
+	cancelElector context.CancelFunc
+	electorDone   chan struct{}
+
+	// планировщик пересоздаётся при смене расписаний в конфиге
+	mu                sync.Mutex
+	cron              *cron.Cron
+	actualizeSchedule string
+	compactSchedule   string
+	assetsSchedule    string
+}
+
+func startWorker(cfg *config.Config, pg *storage.Postgres, service *cases.Service, catalog *cases.AssetCatalog) *worker {
+	leaderLock, err := storage.NewAdvisoryLock(pg, cfg.Cfg.LeaderLockKey)
+	if err != nil {
+		log.Fatal("failed to create leader lock", zap.Any("err", err.Error()))
+	}
+
+	elector, err := cases.NewLeaderElector(leaderLock, cfg.Cfg.LeaderElectionInterval)
+	if err != nil {
+		log.Fatal("failed to create leader elector", zap.Any("err", err.Error()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{
+		service:       service,
+		catalog:       catalog,
+		elector:       elector,
+		retention:     newRetention(cfg, pg),
+		partitions:    newPartitionManager(cfg, pg),
+		jobs:          cases.NewJobs(),
+		cancelElector: cancel,
+		electorDone:   make(chan struct{}),
+	}
+
+	go func() {
+		elector.Run(ctx)
+		close(w.electorDone)
+	}()
+
+	w.cron, err = w.newCron(cfg.Cfg.ActualizeSchedule, cfg.Cfg.CompactSchedule, cfg.Cfg.AssetsSyncSchedule)
+	if err != nil {
+		log.Fatal("failed to schedule jobs", zap.Any("err", err.Error()))
+	}
+	w.actualizeSchedule = cfg.Cfg.ActualizeSchedule
+	w.compactSchedule = cfg.Cfg.CompactSchedule
+	w.assetsSchedule = cfg.Cfg.AssetsSyncSchedule
+	w.cron.Start()
+
+	return w
+}
+
+func (w *worker) newCron(actualizeSchedule, compactSchedule, assetsSchedule string) (*cron.Cron, error) {
+	c := SetCron(w.service, w.elector, w.jobs, actualizeSchedule)
+	// на новой базе каталог загружается сразу, не дожидаясь assetsSchedule; дальше задача ничего не делает
+	err := c.AddFunc(actualizeSchedule, func() {
+		if !w.elector.Confirm(context.Background()) || !w.catalog.Empty() {
+			return
+		}
+
+		err := w.jobs.Run("sync_assets", w.catalog.SyncIfEmpty)
+		metrics.ObserveJob("sync_assets", err)
+		if err != nil {
+			log.Error("(cron) failed to load asset catalog", zap.Any("err", err.Error()))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.AddFunc(assetsSchedule, func() {
+		if !w.elector.Confirm(context.Background()) {
+			return
+		}
+
+		err := w.jobs.Run("sync_assets", w.catalog.Sync)
+		metrics.ObserveJob("sync_assets", err)
+		if err != nil {
+			log.Error("(cron) failed to sync asset catalog", zap.Any("err", err.Error()))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.AddFunc(compactSchedule, func() {
+		if !w.elector.Confirm(context.Background()) {
+			return
+		}
+
+		err := w.jobs.Run("create_partitions", w.partitions.CreateAhead)
+		metrics.ObserveJob("create_partitions", err)
+		if err != nil {
+			log.Error("(cron) failed to create partitions", zap.Any("err", err.Error()))
+		}
+
+		err = w.jobs.Run("compact", w.retention.Compact)
+		metrics.ObserveJob("compact", err)
+		if err != nil {
+			log.Error("(cron) failed to compact coins", zap.Any("err", err.Error()))
+		}
+	})
+
+	return c, err
+}
+
+// reschedule применяет новые расписания из конфига: новый планировщик запускается сразу после остановки старого,
+// уже выполняющиеся задачи доработают до конца
+func (w *worker) reschedule(cfg *config.Config) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if cfg.Cfg.ActualizeSchedule == w.actualizeSchedule && cfg.Cfg.CompactSchedule == w.compactSchedule && cfg.Cfg.AssetsSyncSchedule == w.assetsSchedule {
+		return
+	}
+
+	c, err := w.newCron(cfg.Cfg.ActualizeSchedule, cfg.Cfg.CompactSchedule, cfg.Cfg.AssetsSyncSchedule)
+	if err != nil {
+		log.Error("(worker.reschedule) failed to schedule jobs", zap.Any("err", err.Error()))
+		return
+	}
+
+	w.cron.Stop()
+	c.Start()
+	w.cron = c
+	w.actualizeSchedule = cfg.Cfg.ActualizeSchedule
+	w.compactSchedule = cfg.Cfg.CompactSchedule
+	w.assetsSchedule = cfg.Cfg.AssetsSyncSchedule
+	log.Info("(worker.reschedule) jobs rescheduled", zap.Any("actualize", w.actualizeSchedule), zap.Any("compact", w.compactSchedule), zap.Any("assets", w.assetsSchedule))
+}
+
+func newRetention(cfg *config.Config, pg *storage.Postgres) *cases.Retention {
+	retention, err := cases.NewRetention(pg, cfg.Cfg.RawRetention, cfg.Cfg.HourlyRetention)
+	if err != nil {
+		log.Fatal("failed to create retention", zap.Any("err", err.Error()))
+	}
+
+	return retention
+}
+
+func newPartitionManager(cfg *config.Config, pg *storage.Postgres) *storage.PartitionManager {
+	partitions, err := storage.NewPartitionManager(pg, cfg.Cfg.PartitionsAhead)
+	if err != nil {
+		log.Fatal("failed to create partition manager", zap.Any("err", err.Error()))
+	}
+
+	return partitions
+}
+
+// stop останавливает планировщик, отменяет выполняющиеся задачи и дожидается их, но не дольше ctx,
+// после чего отдаёт лидерство другой реплике
+func (w *worker) stop(ctx context.Context) error {
+	w.mu.Lock()
+	w.cron.Stop()
+	w.mu.Unlock()
+
+	err := w.jobs.Shutdown(ctx)
+
+	w.cancelElector()
+	<-w.electorDone
+
+	return err
+}
+
+// SetCron планирует актуализацию курсов по расписанию schedule; задача выполняется только на реплике-лидере,
+// остальные реплики продолжают обслуживать API.
+func SetCron(srvc *cases.Service, elector *cases.LeaderElector, jobs *cases.Jobs, schedule string) *cron.Cron {
+	c := cron.New()
+	err := c.AddFunc(schedule, func() {
+		if !elector.Confirm(context.Background()) {
+			return
+		}
+
+		err := jobs.Run("actualize_rates", srvc.ActualizeRates)
+		metrics.ObserveJob("actualize_rates", err)
+		if err != nil {
+			log.Error("(cron) failed to actualize rates", zap.Any("err", err.Error()))
+		}
+	})
+	if err != nil {
+		log.Error("cron job failed", zap.Any("err", err.Error()))
+	}
+
+	return c
+}
@@ -0,0 +1,14 @@
+package cases
+
+import (
+	"context"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./asset_provider.go -destination=./mocks/gen/mock_asset_provider.go
+type AssetProvider interface {
+	GetAssets(ctx context.Context) ([]entities.Asset, error)
+}
+
+// GetAssets - возвращает полный список монет провайдера
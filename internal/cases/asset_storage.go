@@ -0,0 +1,19 @@
+package cases
+
+import (
+	"context"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./asset_storage.go -destination=./mocks/gen/mock_asset_storage.go
+type AssetStorage interface {
+	SaveAssets(ctx context.Context, assets []entities.Asset) error
+	ListAssetSymbols(ctx context.Context) ([]string, error)
+	SearchAssets(ctx context.Context, search string, limit int) ([]entities.Asset, error)
+}
+
+// SaveAssets - для замены каталога списком провайдера: новые монеты добавляются, известные обновляются,
+// пропавшие из списка помечаются как AssetDelisted
+// ListAssetSymbols - для получения тикеров монет, которые можно запрашивать у провайдера (AssetActive)
+// SearchAssets - для поиска монет по началу тикера или части названия (пустой search - все монеты)
@@ -0,0 +1,180 @@
+package cases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const MaxAssetsLimit = 500
+
+// AssetCatalog - каталог известных монет. Список хранится в хранилище и синхронизируется со списком
+// монет провайдера; тикеры активных монет держатся в памяти, чтобы отклонять неизвестные монеты
+// до запроса к провайдеру.
+type AssetCatalog struct {
+	storage  AssetStorage
+	provider AssetProvider
+
+	mu    sync.RWMutex
+	known map[string]struct{}
+}
+
+func NewAssetCatalog(storage AssetStorage, provider AssetProvider) (*AssetCatalog, error) {
+	if storage == nil || storage == AssetStorage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "asset storage not set")
+	}
+
+	if provider == nil || provider == AssetProvider(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "asset provider not set")
+	}
+
+	return &AssetCatalog{
+		storage:  storage,
+		provider: provider,
+	}, nil
+}
+
+// NewReadOnlyAssetCatalog создаёт каталог, который только читает хранилище; синхронизацию выполняет worker.
+func NewReadOnlyAssetCatalog(storage AssetStorage) (*AssetCatalog, error) {
+	if storage == nil || storage == AssetStorage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "asset storage not set")
+	}
+
+	return &AssetCatalog{
+		storage: storage,
+	}, nil
+}
+
+// Sync загружает список монет провайдера в хранилище и обновляет тикеры в памяти
+func (c *AssetCatalog) Sync(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	if c.provider == nil {
+		err := errors.Wrap(entities.ErrReadOnly, "read-only asset catalog cannot sync")
+		log.Error("(catalog.Sync) catalog is read-only", zap.Any("err", err.Error()))
+		return err
+	}
+
+	assets, err := c.provider.GetAssets(ctx)
+	if err != nil {
+		log.Error("(catalog.Sync) failed to get assets from provider", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to get assets from provider")
+	}
+
+	// пустой ответ провайдера пометил бы весь каталог как delisted
+	if len(assets) == 0 {
+		err := errors.Wrap(entities.ErrProviderUnavailable, "provider returned empty asset list")
+		log.Error("(catalog.Sync) empty asset list", zap.Any("err", err.Error()))
+		return err
+	}
+
+	if err := c.storage.SaveAssets(ctx, assets); err != nil {
+		log.Error("(catalog.Sync) failed to save assets", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to save assets")
+	}
+
+	log.Info("(catalog.Sync) assets synced", zap.Any("assets", len(assets)))
+	return c.Refresh(ctx)
+}
+
+// SyncIfEmpty синхронизирует каталог, только если он ещё пуст (первый запуск на новой базе)
+func (c *AssetCatalog) SyncIfEmpty(ctx context.Context) error {
+	if c.Empty() {
+		if err := c.Refresh(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !c.Empty() {
+		return nil
+	}
+
+	return c.Sync(ctx)
+}
+
+// Refresh перечитывает тикеры активных монет из хранилища
+func (c *AssetCatalog) Refresh(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	symbols, err := c.storage.ListAssetSymbols(ctx)
+	if err != nil {
+		log.Error("(catalog.Refresh) failed to list asset symbols", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to list asset symbols")
+	}
+
+	known := make(map[string]struct{}, len(symbols))
+	for _, symbol := range symbols {
+		known[symbol] = struct{}{}
+	}
+
+	c.mu.Lock()
+	c.known = known
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Run периодически перечитывает каталог из хранилища и блокируется до отмены ctx
+func (c *AssetCatalog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = c.Refresh(ctx)
+		}
+	}
+}
+
+// Empty сообщает, что в памяти нет ни одной монеты: каталог ещё не синхронизирован или не прочитан
+func (c *AssetCatalog) Empty() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.known) == 0
+}
+
+// Unknown возвращает монеты, которых нет среди активных монет каталога. Пока каталог пуст,
+// проверка не выполняется: иначе до первой синхронизации отклонялись бы все новые монеты
+func (c *AssetCatalog) Unknown(titles []string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.known) == 0 {
+		return nil
+	}
+
+	var unknown []string
+	for _, title := range titles {
+		if _, ok := c.known[title]; !ok {
+			unknown = append(unknown, title)
+		}
+	}
+
+	return unknown
+}
+
+// Search ищет монеты каталога по началу тикера или части названия
+func (c *AssetCatalog) Search(ctx context.Context, search string, limit int) ([]entities.Asset, error) {
+	log := logger.FromContext(ctx)
+	if limit <= 0 || limit > MaxAssetsLimit {
+		err := errors.Wrapf(entities.ErrInvalidParam, "limit must be from 1 to %d", MaxAssetsLimit)
+		log.Error("(catalog.Search) wrong limit", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	assets, err := c.storage.SearchAssets(ctx, search, limit)
+	if err != nil {
+		log.Error("(catalog.Search) failed to search assets", zap.Any("err", err.Error()))
+		return nil, errors.Wrap(err, "failed to search assets")
+	}
+
+	return assets, nil
+}
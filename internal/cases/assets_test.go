@@ -0,0 +1,135 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestNewAssetCatalog(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tests := []struct {
+		name     string
+		storage  cases.AssetStorage
+		provider cases.AssetProvider
+		wantErr  bool
+	}{
+		{name: "valid input", storage: mock_cases.NewMockAssetStorage(ctrl), provider: mock_cases.NewMockAssetProvider(ctrl)},
+		{name: "storage not set", provider: mock_cases.NewMockAssetProvider(ctrl), wantErr: true},
+		{name: "provider not set", storage: mock_cases.NewMockAssetStorage(ctrl), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			catalog, err := cases.NewAssetCatalog(tt.storage, tt.provider)
+			if tt.wantErr {
+				require.Nil(t, catalog)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, catalog)
+		})
+	}
+}
+
+func TestAssetCatalog_Sync(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockAssetStorage(ctrl)
+	provider := mock_cases.NewMockAssetProvider(ctrl)
+	catalog, err := cases.NewAssetCatalog(storage, provider)
+	require.NoError(t, err)
+
+	// пока каталог пуст, неизвестных монет нет
+	require.True(t, catalog.Empty())
+	require.Nil(t, catalog.Unknown([]string{"BTC", "NOPE"}))
+
+	ctx := context.Background()
+	assets := []entities.Asset{
+		{Symbol: "BTC", Name: "Bitcoin", Status: entities.AssetActive},
+		{Symbol: "ETH", Name: "Ethereum", Status: entities.AssetActive},
+	}
+	provider.EXPECT().GetAssets(ctx).Return(assets, nil)
+	storage.EXPECT().SaveAssets(ctx, assets).Return(nil)
+	storage.EXPECT().ListAssetSymbols(ctx).Return([]string{"BTC", "ETH"}, nil)
+
+	require.NoError(t, catalog.Sync(ctx))
+	require.False(t, catalog.Empty())
+	require.Equal(t, []string{"NOPE"}, catalog.Unknown([]string{"BTC", "NOPE", "ETH"}))
+	require.Nil(t, catalog.Unknown([]string{"ETH"}))
+}
+
+func TestAssetCatalog_SyncErrors(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockAssetStorage(ctrl)
+	provider := mock_cases.NewMockAssetProvider(ctrl)
+	catalog, err := cases.NewAssetCatalog(storage, provider)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	provider.EXPECT().GetAssets(ctx).Return(nil, errors.Wrap(entities.ErrProviderUnavailable, "timeout"))
+	require.ErrorIs(t, catalog.Sync(ctx), entities.ErrProviderUnavailable)
+
+	// пустой список не сохраняется: иначе все монеты стали бы delisted
+	provider.EXPECT().GetAssets(ctx).Return([]entities.Asset{}, nil)
+	require.ErrorIs(t, catalog.Sync(ctx), entities.ErrProviderUnavailable)
+
+	readOnly, err := cases.NewReadOnlyAssetCatalog(storage)
+	require.NoError(t, err)
+	require.ErrorIs(t, readOnly.Sync(ctx), entities.ErrReadOnly)
+}
+
+func TestAssetCatalog_SyncIfEmpty(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockAssetStorage(ctrl)
+	provider := mock_cases.NewMockAssetProvider(ctrl)
+	catalog, err := cases.NewAssetCatalog(storage, provider)
+	require.NoError(t, err)
+
+	// каталог уже есть в хранилище - к провайдеру не ходим
+	ctx := context.Background()
+	storage.EXPECT().ListAssetSymbols(ctx).Return([]string{"BTC"}, nil)
+	require.NoError(t, catalog.SyncIfEmpty(ctx))
+	require.NoError(t, catalog.SyncIfEmpty(ctx))
+}
+
+func TestAssetCatalog_Search(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockAssetStorage(ctrl)
+	catalog, err := cases.NewReadOnlyAssetCatalog(storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assets := []entities.Asset{{Symbol: "BTC", Name: "Bitcoin", Status: entities.AssetActive}}
+	storage.EXPECT().SearchAssets(ctx, "bit", 10).Return(assets, nil)
+
+	found, err := catalog.Search(ctx, "bit", 10)
+	require.NoError(t, err)
+	require.Equal(t, assets, found)
+
+	_, err = catalog.Search(ctx, "bit", cases.MaxAssetsLimit+1)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
@@ -0,0 +1,325 @@
+package cases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	// ключ выглядит как cr_<48 hex-символов>; первые символы сохраняются, чтобы владелец узнал ключ в списке
+	apiKeyPrefix      = "cr_"
+	apiKeyBytes       = 24
+	apiKeyDisplayed   = len(apiKeyPrefix) + 8
+	rateLimitWindow   = time.Minute
+	usageFlushTimeout = 5 * time.Second
+	// неизвестный ключ помнится invalidKeyTTL, чтобы повторы с ним не доходили до хранилища;
+	// размер кеша ограничен, иначе поток случайных ключей занял бы всю память
+	invalidKeyTTL  = 10 * time.Second
+	maxInvalidKeys = 10000
+)
+
+type cachedKey struct {
+	key     entities.APIKey
+	expires time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type usageKey struct {
+	keyID int64
+	day   time.Time
+}
+
+// Auth проверяет API-ключи, их права и лимиты запросов, и считает запросы по ключам за сутки.
+// Найденные ключи кешируются на cacheTTL, поэтому отзыв ключа на других репликах вступает в силу
+// не позже, чем через cacheTTL. Лимиты и несохранённые счётчики хранятся в памяти реплики
+type Auth struct {
+	storage   KeyStorage
+	cacheTTL  time.Duration
+	rateLimit int
+
+	mu      sync.Mutex
+	cache   map[string]cachedKey
+	invalid map[string]time.Time
+	windows map[int64]*rateWindow
+	usage   map[usageKey]int64
+}
+
+// NewAuth создаёт проверку ключей; rateLimit - лимит запросов в минуту для ключей, выпущенных без своего лимита
+func NewAuth(storage KeyStorage, cacheTTL time.Duration, rateLimit int) (*Auth, error) {
+	if storage == nil || storage == KeyStorage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "key storage not set")
+	}
+
+	if cacheTTL < 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "key cache ttl must not be negative")
+	}
+
+	if rateLimit <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "default rate limit must be positive")
+	}
+
+	return &Auth{
+		storage:   storage,
+		cacheTTL:  cacheTTL,
+		rateLimit: rateLimit,
+		cache:     make(map[string]cachedKey),
+		invalid:   make(map[string]time.Time),
+		windows:   make(map[int64]*rateWindow),
+		usage:     make(map[usageKey]int64),
+	}, nil
+}
+
+// IssueKey создаёт ключ и возвращает его вместе с самим ключом в открытом виде - больше его получить нельзя;
+// нулевой rateLimit заменяется лимитом по умолчанию
+func (a *Auth) IssueKey(ctx context.Context, name string, scopes []entities.Scope, rateLimit int) (entities.APIKey, string, error) {
+	log := logger.FromContext(ctx)
+	if rateLimit == 0 {
+		rateLimit = a.rateLimit
+	}
+
+	if strings.TrimSpace(name) == "" || len(scopes) == 0 || rateLimit < 0 {
+		err := errors.Wrap(entities.ErrInvalidParam, "key name and scopes are required, rate limit must not be negative")
+		log.Warn("(auth.IssueKey) invalid key parameters", zap.Any("err", err.Error()))
+		return entities.APIKey{}, "", err
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		log.Error("(auth.IssueKey) failed to generate key", zap.Any("err", err.Error()))
+		return entities.APIKey{}, "", errors.Wrapf(entities.ErrInternal, "failed to generate key: %v", err)
+	}
+	token := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key, err := a.storage.CreateKey(ctx, entities.APIKey{
+		Name:      strings.TrimSpace(name),
+		Prefix:    token[:apiKeyDisplayed],
+		Hash:      hashKey(token),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+	})
+	if err != nil {
+		log.Error("(auth.IssueKey) failed to save key", zap.Any("err", err.Error()))
+		return entities.APIKey{}, "", errors.Wrap(err, "failed to save key")
+	}
+
+	log.Info("(auth.IssueKey) key issued", zap.Any("keyID", key.ID), zap.Any("name", key.Name), zap.Any("scopes", key.Scopes))
+	return key, token, nil
+}
+
+func (a *Auth) ListKeys(ctx context.Context) ([]entities.APIKey, error) {
+	keys, err := a.storage.ListKeys(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list keys")
+	}
+
+	return keys, nil
+}
+
+func (a *Auth) RevokeKey(ctx context.Context, id int64) error {
+	log := logger.FromContext(ctx)
+	if err := a.storage.RevokeKey(ctx, id); err != nil {
+		log.Error("(auth.RevokeKey) failed to revoke key", zap.Any("keyID", id), zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to revoke key")
+	}
+
+	a.mu.Lock()
+	for hash, cached := range a.cache {
+		if cached.key.ID == id {
+			delete(a.cache, hash)
+		}
+	}
+	a.mu.Unlock()
+
+	log.Warn("(auth.RevokeKey) key revoked", zap.Any("keyID", id))
+	return nil
+}
+
+// Authorize пропускает запрос с ключом token, если ключ действует, выдан со scope и не исчерпал лимит;
+// пропущенный запрос засчитывается в суточное использование ключа
+func (a *Auth) Authorize(ctx context.Context, token string, scope entities.Scope) (entities.APIKey, entities.RateLimit, error) {
+	key, err := a.authenticate(ctx, token)
+	if err != nil {
+		return entities.APIKey{}, entities.RateLimit{}, err
+	}
+
+	if !key.HasScope(scope) {
+		return key, entities.RateLimit{}, errors.Wrapf(entities.ErrForbidden, "api key has no %q scope", scope)
+	}
+
+	now := time.Now()
+	limit, ok := a.allow(key, now)
+	if !ok {
+		err := errors.Wrapf(entities.ErrRateLimited, "api key rate limit of %d requests per minute exceeded", key.RateLimit)
+		return key, limit, entities.NewRetryError(err, limit.Reset.Sub(now))
+	}
+
+	return key, limit, nil
+}
+
+func (a *Auth) authenticate(ctx context.Context, token string) (entities.APIKey, error) {
+	if token == "" {
+		return entities.APIKey{}, errors.Wrap(entities.ErrUnauthorized, "api key required")
+	}
+
+	hash := hashKey(token)
+	now := time.Now()
+
+	a.mu.Lock()
+	cached, ok := a.cache[hash]
+	invalidUntil, invalid := a.invalid[hash]
+	a.mu.Unlock()
+
+	if invalid && now.Before(invalidUntil) {
+		return entities.APIKey{}, errors.Wrap(entities.ErrUnauthorized, "invalid api key")
+	}
+
+	key := cached.key
+	if !ok || now.After(cached.expires) {
+		var err error
+		key, err = a.storage.GetKeyByHash(ctx, hash)
+		if errors.Is(err, entities.ErrNotFound) {
+			a.rememberInvalid(hash, now)
+			return entities.APIKey{}, errors.Wrap(entities.ErrUnauthorized, "invalid api key")
+		}
+		if err != nil {
+			return entities.APIKey{}, errors.Wrap(err, "failed to get api key")
+		}
+
+		a.mu.Lock()
+		a.cache[hash] = cachedKey{key: key, expires: now.Add(a.cacheTTL)}
+		a.mu.Unlock()
+	}
+
+	if key.Revoked() {
+		return entities.APIKey{}, errors.Wrap(entities.ErrUnauthorized, "api key revoked")
+	}
+
+	return key, nil
+}
+
+// rememberInvalid кеширует неизвестный ключ; когда кеш заполнен, из него удаляются истёкшие записи,
+// а если их нет - кеш очищается целиком
+func (a *Auth) rememberInvalid(hash string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.invalid) >= maxInvalidKeys {
+		for h, until := range a.invalid {
+			if now.After(until) {
+				delete(a.invalid, h)
+			}
+		}
+	}
+	if len(a.invalid) >= maxInvalidKeys {
+		a.invalid = make(map[string]time.Time)
+	}
+
+	a.invalid[hash] = now.Add(invalidKeyTTL)
+}
+
+// allow считает запрос в окне фиксированной длины rateLimitWindow
+func (a *Auth) allow(key entities.APIKey, now time.Time) (entities.RateLimit, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	window, ok := a.windows[key.ID]
+	if !ok || now.Sub(window.start) >= rateLimitWindow {
+		window = &rateWindow{start: now.Truncate(rateLimitWindow)}
+		a.windows[key.ID] = window
+	}
+
+	limit := entities.RateLimit{Limit: key.RateLimit, Reset: window.start.Add(rateLimitWindow)}
+	if window.count >= key.RateLimit {
+		return limit, false
+	}
+
+	window.count++
+	limit.Remaining = key.RateLimit - window.count
+	a.usage[usageKey{keyID: key.ID, day: now.UTC().Truncate(24 * time.Hour)}]++
+
+	return limit, true
+}
+
+// GetUsage возвращает суточное использование ключа за дни [from, to] с учётом ещё не сохранённых запросов
+func (a *Auth) GetUsage(ctx context.Context, id int64, from, to time.Time) ([]entities.KeyUsage, error) {
+	if err := a.FlushUsage(ctx); err != nil {
+		return nil, err
+	}
+
+	usage, err := a.storage.GetUsage(ctx, id, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get key usage")
+	}
+
+	return usage, nil
+}
+
+// FlushUsage сохраняет накопленные счётчики; при ошибке они остаются до следующей попытки
+func (a *Auth) FlushUsage(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	a.mu.Lock()
+	pending := a.usage
+	a.usage = make(map[usageKey]int64)
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	usage := make([]entities.KeyUsage, 0, len(pending))
+	for k, requests := range pending {
+		usage = append(usage, entities.KeyUsage{KeyID: k.keyID, Day: k.day, Requests: requests})
+	}
+
+	if err := a.storage.AddUsage(ctx, usage); err != nil {
+		a.mu.Lock()
+		for k, requests := range pending {
+			a.usage[k] += requests
+		}
+		a.mu.Unlock()
+
+		log.Error("(auth.FlushUsage) failed to save key usage", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to save key usage")
+	}
+
+	return nil
+}
+
+// Run периодически сохраняет счётчики использования и блокируется до отмены ctx;
+// при остановке сохраняет оставшиеся
+func (a *Auth) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), usageFlushTimeout)
+			_ = a.FlushUsage(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			_ = a.FlushUsage(ctx)
+		}
+	}
+}
+
+func hashKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,226 @@
+package cases_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestNewAuth(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tests := []struct {
+		name      string
+		storage   cases.KeyStorage
+		cacheTTL  time.Duration
+		rateLimit int
+		wantErr   bool
+	}{
+		{name: "valid input", storage: mock_cases.NewMockKeyStorage(ctrl), cacheTTL: time.Second, rateLimit: 600},
+		{name: "storage not set", cacheTTL: time.Second, rateLimit: 600, wantErr: true},
+		{name: "negative cache ttl", storage: mock_cases.NewMockKeyStorage(ctrl), cacheTTL: -time.Second, rateLimit: 600, wantErr: true},
+		{name: "wrong rate limit", storage: mock_cases.NewMockKeyStorage(ctrl), cacheTTL: time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			auth, err := cases.NewAuth(tt.storage, tt.cacheTTL, tt.rateLimit)
+			if tt.wantErr {
+				require.Nil(t, auth)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, auth)
+		})
+	}
+}
+
+// issueKey выпускает ключ и настраивает хранилище так, чтобы он нашёлся по хешу; дальше ключ берётся из кеша
+func issueKey(t *testing.T, auth *cases.Auth, storage *mock_cases.MockKeyStorage, scopes []entities.Scope, rateLimit int) (entities.APIKey, string) {
+	var stored entities.APIKey
+	storage.EXPECT().CreateKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key entities.APIKey) (entities.APIKey, error) {
+			key.ID = 1
+			stored = key
+			return key, nil
+		})
+
+	key, token, err := auth.IssueKey(context.Background(), "partner", scopes, rateLimit)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, key.Prefix))
+	// в хранилище попадает только хеш
+	require.NotContains(t, stored.Hash, token)
+
+	storage.EXPECT().GetKeyByHash(gomock.Any(), stored.Hash).Return(stored, nil)
+	return key, token
+}
+
+func TestAuth_Authorize(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockKeyStorage(ctrl)
+	auth, err := cases.NewAuth(storage, time.Minute, 600)
+	require.NoError(t, err)
+
+	_, token := issueKey(t, auth, storage, []entities.Scope{entities.ScopeRead}, 10)
+	ctx := context.Background()
+
+	key, limit, err := auth.Authorize(ctx, token, entities.ScopeRead)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), key.ID)
+	require.Equal(t, 10, limit.Limit)
+	require.Equal(t, 9, limit.Remaining)
+
+	_, _, err = auth.Authorize(ctx, token, entities.ScopeAdmin)
+	require.ErrorIs(t, err, entities.ErrForbidden)
+
+	_, _, err = auth.Authorize(ctx, "", entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrUnauthorized)
+
+	storage.EXPECT().GetKeyByHash(gomock.Any(), gomock.Not(key.Hash)).Return(entities.APIKey{}, errors.Wrap(entities.ErrNotFound, "api key not found"))
+	_, _, err = auth.Authorize(ctx, "cr_unknown", entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrUnauthorized)
+
+	// неизвестный ключ запомнен: повтор не доходит до хранилища
+	_, _, err = auth.Authorize(ctx, "cr_unknown", entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrUnauthorized)
+}
+
+func TestAuth_RateLimit(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockKeyStorage(ctrl)
+	auth, err := cases.NewAuth(storage, time.Minute, 600)
+	require.NoError(t, err)
+
+	_, token := issueKey(t, auth, storage, []entities.Scope{entities.ScopeRead}, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, _, err := auth.Authorize(ctx, token, entities.ScopeRead)
+		require.NoError(t, err)
+	}
+
+	_, limit, err := auth.Authorize(ctx, token, entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrRateLimited)
+	require.Equal(t, 0, limit.Remaining)
+	require.True(t, limit.Reset.After(time.Now()))
+
+	// отклонённые запросы в использование не засчитываются
+	storage.EXPECT().AddUsage(ctx, gomock.Len(1)).DoAndReturn(
+		func(ctx context.Context, usage []entities.KeyUsage) error {
+			require.Equal(t, int64(2), usage[0].Requests)
+			return nil
+		})
+	require.NoError(t, auth.FlushUsage(ctx))
+}
+
+func TestAuth_RevokeKey(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockKeyStorage(ctrl)
+	auth, err := cases.NewAuth(storage, time.Hour, 600)
+	require.NoError(t, err)
+
+	key, token := issueKey(t, auth, storage, []entities.Scope{entities.ScopeRead}, 10)
+	ctx := context.Background()
+
+	_, _, err = auth.Authorize(ctx, token, entities.ScopeRead)
+	require.NoError(t, err)
+
+	// отзыв сбрасывает кеш: следующий запрос снова читает ключ из хранилища
+	revokedAt := time.Now()
+	revoked := key
+	revoked.RevokedAt = &revokedAt
+	storage.EXPECT().RevokeKey(ctx, key.ID).Return(nil)
+	storage.EXPECT().GetKeyByHash(ctx, key.Hash).Return(revoked, nil)
+	require.NoError(t, auth.RevokeKey(ctx, key.ID))
+
+	_, _, err = auth.Authorize(ctx, token, entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrUnauthorized)
+}
+
+func TestAuth_FlushUsageRetry(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockKeyStorage(ctrl)
+	auth, err := cases.NewAuth(storage, time.Minute, 600)
+	require.NoError(t, err)
+
+	_, token := issueKey(t, auth, storage, []entities.Scope{entities.ScopeRead}, 10)
+	ctx := context.Background()
+
+	_, _, err = auth.Authorize(ctx, token, entities.ScopeRead)
+	require.NoError(t, err)
+
+	// несохранённые счётчики не теряются и уходят со следующей попыткой
+	storage.EXPECT().AddUsage(ctx, gomock.Any()).Return(errors.Wrap(entities.ErrStorageUnavailable, "conn refused"))
+	require.ErrorIs(t, auth.FlushUsage(ctx), entities.ErrStorageUnavailable)
+
+	_, _, err = auth.Authorize(ctx, token, entities.ScopeRead)
+	require.NoError(t, err)
+
+	storage.EXPECT().AddUsage(ctx, gomock.Len(1)).DoAndReturn(
+		func(ctx context.Context, usage []entities.KeyUsage) error {
+			require.Equal(t, int64(2), usage[0].Requests)
+			require.Equal(t, time.Now().UTC().Truncate(24*time.Hour), usage[0].Day)
+			return nil
+		})
+	require.NoError(t, auth.FlushUsage(ctx))
+
+	// пустой сброс в хранилище не ходит
+	require.NoError(t, auth.FlushUsage(ctx))
+}
+
+func TestAuth_IssueKeyInvalid(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	auth, err := cases.NewAuth(mock_cases.NewMockKeyStorage(ctrl), time.Minute, 600)
+	require.NoError(t, err)
+
+	_, _, err = auth.IssueKey(context.Background(), "partner", nil, 10)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+
+	_, _, err = auth.IssueKey(context.Background(), "partner", []entities.Scope{entities.ScopeRead}, -1)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func TestAuth_IssueKeyDefaultRateLimit(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockKeyStorage(ctrl)
+	auth, err := cases.NewAuth(storage, time.Minute, 600)
+	require.NoError(t, err)
+
+	key, token := issueKey(t, auth, storage, []entities.Scope{entities.ScopeRead}, 0)
+	require.Equal(t, 600, key.RateLimit)
+
+	_, limit, err := auth.Authorize(context.Background(), token, entities.ScopeRead)
+	require.NoError(t, err)
+	require.Equal(t, 600, limit.Limit)
+}
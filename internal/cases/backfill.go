@@ -0,0 +1,156 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	backfillMaxRetries   = 5
+	backfillRetryBackoff = time.Second
+)
+
+// Backfiller загружает исторические курсы постранично от заданной даты до текущего момента.
+// Прогресс сохраняется после каждой страницы, поэтому прерванная загрузка продолжается с последней точки.
+type Backfiller struct {
+	history     HistoryProvider
+	storage     Storage
+	checkpoints CheckpointStorage
+	limiter     *rate.Limiter
+	pageSize    int
+}
+
+func NewBackfiller(history HistoryProvider, storage Storage, checkpoints CheckpointStorage, rps float64, pageSize int) (*Backfiller, error) {
+	if history == nil || history == HistoryProvider(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "history provider not set")
+	}
+
+	if storage == nil || storage == Storage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "storage not set")
+	}
+
+	if checkpoints == nil || checkpoints == CheckpointStorage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "checkpoint storage not set")
+	}
+
+	if rps <= 0 || pageSize <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "rps and page size must be positive")
+	}
+
+	return &Backfiller{
+		history:     history,
+		storage:     storage,
+		checkpoints: checkpoints,
+		limiter:     rate.NewLimiter(rate.Limit(rps), 1),
+		pageSize:    pageSize,
+	}, nil
+}
+
+func (b *Backfiller) Backfill(ctx context.Context, titles []string, from time.Time, interval entities.Interval) error {
+	log := logger.FromContext(ctx)
+	for _, title := range titles {
+		if err := b.backfillTitle(ctx, title, from, interval); err != nil {
+			log.Error("(backfiller.Backfill) failed to backfill coin", zap.Any("title", title), zap.Any("err", err.Error()))
+			return errors.Wrapf(err, "failed to backfill %v", title)
+		}
+	}
+
+	return nil
+}
+
+func (b *Backfiller) backfillTitle(ctx context.Context, title string, from time.Time, interval entities.Interval) error {
+	log := logger.FromContext(ctx)
+	step := interval.Duration()
+	cursor := from.Truncate(step)
+
+	// продолжаем с сохранённой точки, если загрузка уже запускалась
+	checkpoint, err := b.checkpoints.GetCheckpoint(ctx, title, interval)
+	switch {
+	case err == nil && !checkpoint.Before(cursor):
+		cursor = checkpoint.Add(step)
+		log.Info("(backfiller.backfillTitle) resuming from checkpoint", zap.Any("title", title), zap.Any("checkpoint", checkpoint))
+	case err != nil && !errors.Is(err, entities.ErrNotFound):
+		return errors.Wrap(err, "failed to get checkpoint")
+	}
+
+	end := time.Now().Truncate(step)
+	for !cursor.After(end) {
+		to := cursor.Add(time.Duration(b.pageSize-1) * step)
+		if to.After(end) {
+			to = end
+		}
+
+		coins, err := b.fetchPage(ctx, title, interval, cursor, to)
+		if err != nil {
+			return err
+		}
+
+		if len(coins) > 0 {
+			if err := b.storage.Store(ctx, coins); err != nil {
+				return errors.Wrap(err, "failed to write history to storage")
+			}
+		}
+
+		if err := b.checkpoints.SaveCheckpoint(ctx, title, interval, to); err != nil {
+			return errors.Wrap(err, "failed to save checkpoint")
+		}
+
+		log.Info("(backfiller.backfillTitle) page stored", zap.Any("title", title), zap.Any("to", to), zap.Any("rows", len(coins)))
+		cursor = to.Add(step)
+	}
+
+	return nil
+}
+
+// fetchPage запрашивает у провайдера точки в диапазоне [from, to], соблюдая лимит запросов
+// и повторяя запрос с нарастающей паузой, если провайдер ответил ErrRateLimited.
+func (b *Backfiller) fetchPage(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	// провайдер отдаёт limit+1 точек и не принимает limit=0, лишние точки отфильтровываются
+	limit := max(int(to.Sub(from)/interval.Duration()), 1)
+	backoff := backfillRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		if err := b.limiter.Wait(ctx); err != nil {
+			return nil, errors.Wrap(err, "rate limiter wait interrupted")
+		}
+
+		history, err := b.history.GetHistory(ctx, title, interval, to, limit)
+		if err == nil {
+			return filterPage(history, from, to), nil
+		}
+
+		if !errors.Is(err, entities.ErrRateLimited) || attempt == backfillMaxRetries {
+			return nil, errors.Wrap(err, "failed to get history from provider")
+		}
+
+		log.Warn("(backfiller.fetchPage) provider rate limit hit, retrying", zap.Any("title", title), zap.Any("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "backfill interrupted")
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func filterPage(history []entities.Coin, from, to time.Time) []entities.Coin {
+	coins := make([]entities.Coin, 0, len(history))
+	for _, coin := range history {
+		if coin.CreatedAt.Before(from) || coin.CreatedAt.After(to) {
+			continue
+		}
+
+		coin.Backfilled = true
+		coins = append(coins, coin)
+	}
+
+	return coins
+}
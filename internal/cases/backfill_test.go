@@ -0,0 +1,175 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+// historyPage имитирует ответ провайдера: limit+1 точек с шагом interval, заканчивая моментом to
+func historyPage(title string, interval entities.Interval, to time.Time, limit int) []entities.Coin {
+	coins := make([]entities.Coin, 0, limit+1)
+	for i := limit; i >= 0; i-- {
+		coins = append(coins, entities.Coin{
+			Title:     title,
+			Price:     100,
+			CreatedAt: to.Add(-time.Duration(i) * interval.Duration()),
+		})
+	}
+	return coins
+}
+
+func TestNewBackfiller(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+
+	tests := []struct {
+		name        string
+		history     cases.HistoryProvider
+		storage     cases.Storage
+		checkpoints cases.CheckpointStorage
+		rps         float64
+		wantErr     bool
+	}{
+		{name: "valid input", history: history, storage: memory, checkpoints: memory, rps: 1},
+		{name: "history provider not set", storage: memory, checkpoints: memory, rps: 1, wantErr: true},
+		{name: "storage not set", history: history, checkpoints: memory, rps: 1, wantErr: true},
+		{name: "checkpoints not set", history: history, storage: memory, rps: 1, wantErr: true},
+		{name: "wrong rps", history: history, storage: memory, checkpoints: memory, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			backfiller, err := cases.NewBackfiller(tt.history, tt.storage, tt.checkpoints, tt.rps, 10)
+			if tt.wantErr {
+				require.Nil(t, backfiller)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, backfiller)
+		})
+	}
+}
+
+func TestBackfill_Pages(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+
+	backfiller, err := cases.NewBackfiller(history, memory, memory, 1000, 3)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	end := time.Now().Truncate(time.Hour)
+	from := end.Add(-5 * time.Hour)
+
+	history.EXPECT().GetHistory(ctx, "BTC", entities.IntervalHour, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, title string, interval entities.Interval, to time.Time, limit int) ([]entities.Coin, error) {
+			return historyPage(title, interval, to, limit), nil
+		}).Times(2)
+
+	err = backfiller.Backfill(ctx, []string{"BTC"}, from, entities.IntervalHour)
+	require.NoError(t, err)
+
+	rows := memory.Rows()
+	require.Len(t, rows, 6)
+	for _, row := range rows {
+		require.True(t, row.Backfilled)
+	}
+
+	checkpoint, err := memory.GetCheckpoint(ctx, "BTC", entities.IntervalHour)
+	require.NoError(t, err)
+	require.True(t, checkpoint.Equal(end))
+}
+
+func TestBackfill_ResumeFromCheckpoint(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+
+	backfiller, err := cases.NewBackfiller(history, memory, memory, 1000, 100)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	end := time.Now().Truncate(time.Hour)
+	require.NoError(t, memory.SaveCheckpoint(ctx, "ETH", entities.IntervalHour, end.Add(-time.Hour)))
+
+	// загружается только последняя точка после чекпоинта
+	history.EXPECT().GetHistory(ctx, "ETH", entities.IntervalHour, end, 1).
+		Return(historyPage("ETH", entities.IntervalHour, end, 1), nil)
+
+	err = backfiller.Backfill(ctx, []string{"ETH"}, end.Add(-48*time.Hour), entities.IntervalHour)
+	require.NoError(t, err)
+	require.Len(t, memory.Rows(), 1)
+}
+
+func TestBackfill_RetryOnRateLimit(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+
+	backfiller, err := cases.NewBackfiller(history, memory, memory, 1000, 100)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	end := time.Now().Truncate(24 * time.Hour)
+
+	gomock.InOrder(
+		history.EXPECT().GetHistory(ctx, "BTC", entities.IntervalDay, end, 1).
+			Return(nil, errors.Wrap(entities.ErrRateLimited, "GetHistory error")),
+		history.EXPECT().GetHistory(ctx, "BTC", entities.IntervalDay, end, 1).
+			Return(historyPage("BTC", entities.IntervalDay, end, 1), nil),
+	)
+
+	err = backfiller.Backfill(ctx, []string{"BTC"}, end, entities.IntervalDay)
+	require.NoError(t, err)
+	require.Len(t, memory.Rows(), 1)
+}
+
+func TestBackfill_ProviderError(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+
+	backfiller, err := cases.NewBackfiller(history, memory, memory, 1000, 100)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	end := time.Now().Truncate(24 * time.Hour)
+
+	history.EXPECT().GetHistory(ctx, "BTC", entities.IntervalDay, end, 1).Return(nil, errors.New("GetHistory error"))
+
+	err = backfiller.Backfill(ctx, []string{"BTC"}, end, entities.IntervalDay)
+	require.ErrorContains(t, err, "failed to get history from provider")
+
+	_, err = memory.GetCheckpoint(ctx, "BTC", entities.IntervalDay)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
@@ -0,0 +1,17 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./checkpoint_storage.go -destination=./mocks/gen/mock_checkpoint_storage.go
+type CheckpointStorage interface {
+	GetCheckpoint(ctx context.Context, title string, interval entities.Interval) (time.Time, error)
+	SaveCheckpoint(ctx context.Context, title string, interval entities.Interval, ts time.Time) error
+}
+
+// GetCheckpoint - для получения момента, до которого история уже загружена (ErrNotFound, если загрузки не было)
+// SaveCheckpoint - для сохранения прогресса загрузки истории
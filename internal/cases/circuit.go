@@ -0,0 +1,132 @@
+package cases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// CircuitBreaker - предохранитель перед провайдером курсов: после threshold отказов подряд
+// запросы к провайдеру не выполняются в течение cooldown, затем пропускается один пробный запрос.
+// Отказом считается только недоступность провайдера, а не, например, неизвестная монета.
+type CircuitBreaker struct {
+	provider  CryptoProvider
+	threshold int
+	cooldown  time.Duration
+
+	mu          sync.Mutex
+	state       entities.CircuitState
+	failures    int
+	openedAt    time.Time
+	trial       bool
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+func NewCircuitBreaker(provider CryptoProvider, threshold int, cooldown time.Duration) (*CircuitBreaker, error) {
+	if provider == nil || provider == CryptoProvider(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "provider not set")
+	}
+
+	if threshold < 1 || cooldown <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "threshold and cooldown must be positive")
+	}
+
+	return &CircuitBreaker{
+		provider:  provider,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     entities.CircuitClosed,
+	}, nil
+}
+
+func (cb *CircuitBreaker) GetActualRates(ctx context.Context, titles []string, extraArg string) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	if err := cb.allow(); err != nil {
+		log.Warn("(CircuitBreaker.GetActualRates) provider call rejected")
+		return nil, err
+	}
+
+	coins, err := cb.provider.GetActualRates(ctx, titles, extraArg)
+	cb.record(ctx, err)
+
+	return coins, err
+}
+
+// SetLimits меняет порог отказов и время остывания на лету; набранные отказы и состояние сохраняются
+func (cb *CircuitBreaker) SetLimits(threshold int, cooldown time.Duration) error {
+	if threshold < 1 || cooldown <= 0 {
+		return errors.Wrap(entities.ErrInvalidParam, "threshold and cooldown must be positive")
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.threshold = threshold
+	cb.cooldown = cooldown
+	return nil
+}
+
+// State возвращает текущее состояние предохранителя
+func (cb *CircuitBreaker) State() entities.ProviderStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return entities.ProviderStatus{
+		State:               cb.state,
+		ConsecutiveFailures: cb.failures,
+		LastSuccess:         cb.lastSuccess,
+		LastFailure:         cb.lastFailure,
+	}
+}
+
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == entities.CircuitOpen {
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return errors.Wrap(entities.ErrProviderUnavailable, "provider circuit is open")
+		}
+
+		cb.state = entities.CircuitHalfOpen
+		cb.trial = false
+	}
+
+	if cb.state == entities.CircuitHalfOpen {
+		if cb.trial {
+			return errors.Wrap(entities.ErrProviderUnavailable, "provider circuit is half-open, trial request in progress")
+		}
+		cb.trial = true
+	}
+
+	return nil
+}
+
+func (cb *CircuitBreaker) record(ctx context.Context, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trial = false
+	switch {
+	case err == nil || !errors.Is(err, entities.ErrProviderUnavailable):
+		// провайдер ответил, пусть и ошибкой клиента
+		cb.state = entities.CircuitClosed
+		cb.failures = 0
+		cb.lastSuccess = time.Now()
+	case ctx.Err() != nil:
+		// запрос отменил клиент - о провайдере это ничего не говорит
+	default:
+		cb.failures++
+		cb.lastFailure = time.Now()
+		if cb.state == entities.CircuitHalfOpen || cb.failures >= cb.threshold {
+			cb.state = entities.CircuitOpen
+			cb.openedAt = cb.lastFailure
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestNewCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tests := []struct {
+		name      string
+		provider  cases.CryptoProvider
+		threshold int
+		cooldown  time.Duration
+		wantErr   bool
+	}{
+		{name: "valid input", provider: mock_cases.NewMockCryptoProvider(ctrl), threshold: 3, cooldown: time.Second},
+		{name: "provider not set", threshold: 3, cooldown: time.Second, wantErr: true},
+		{name: "wrong threshold", provider: mock_cases.NewMockCryptoProvider(ctrl), cooldown: time.Second, wantErr: true},
+		{name: "wrong cooldown", provider: mock_cases.NewMockCryptoProvider(ctrl), threshold: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			circuit, err := cases.NewCircuitBreaker(tt.provider, tt.threshold, tt.cooldown)
+			if tt.wantErr {
+				require.Nil(t, circuit)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, circuit)
+		})
+	}
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	circuit, err := cases.NewCircuitBreaker(provider, 2, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	titles := []string{"BTC"}
+	unavailable := errors.Wrap(entities.ErrProviderUnavailable, "unexpected status code: 503")
+
+	// два отказа подряд размыкают цепь, третий запрос до провайдера не доходит
+	provider.EXPECT().GetActualRates(ctx, titles, "PRICE").Return(nil, unavailable).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := circuit.GetActualRates(ctx, titles, "PRICE")
+		require.ErrorIs(t, err, entities.ErrProviderUnavailable)
+	}
+	require.Equal(t, entities.CircuitOpen, circuit.State().State)
+	require.Equal(t, 2, circuit.State().ConsecutiveFailures)
+
+	_, err = circuit.GetActualRates(ctx, titles, "PRICE")
+	require.ErrorIs(t, err, entities.ErrProviderUnavailable)
+
+	// после паузы пробный запрос проходит и замыкает цепь
+	time.Sleep(60 * time.Millisecond)
+	coins := []entities.Coin{{Title: "BTC", Price: 100}}
+	provider.EXPECT().GetActualRates(ctx, titles, "PRICE").Return(coins, nil)
+
+	got, err := circuit.GetActualRates(ctx, titles, "PRICE")
+	require.NoError(t, err)
+	require.Equal(t, coins, got)
+
+	state := circuit.State()
+	require.Equal(t, entities.CircuitClosed, state.State)
+	require.Zero(t, state.ConsecutiveFailures)
+	require.False(t, state.LastSuccess.IsZero())
+}
+
+func TestCircuitBreaker_FailedTrialReopens(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	circuit, err := cases.NewCircuitBreaker(provider, 1, 20*time.Millisecond)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	unavailable := errors.Wrap(entities.ErrProviderUnavailable, "failed to execute request")
+	provider.EXPECT().GetActualRates(ctx, []string{"BTC"}, "PRICE").Return(nil, unavailable).Times(2)
+
+	_, err = circuit.GetActualRates(ctx, []string{"BTC"}, "PRICE")
+	require.ErrorIs(t, err, entities.ErrProviderUnavailable)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = circuit.GetActualRates(ctx, []string{"BTC"}, "PRICE")
+	require.ErrorIs(t, err, entities.ErrProviderUnavailable)
+	require.Equal(t, entities.CircuitOpen, circuit.State().State)
+}
+
+func TestCircuitBreaker_ClientErrorsDoNotOpen(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	circuit, err := cases.NewCircuitBreaker(provider, 1, time.Minute)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	unknown := errors.Wrapf(entities.ErrUnknownSymbol, "coin %v does not exist", []string{"XYZ"})
+	provider.EXPECT().GetActualRates(ctx, []string{"XYZ"}, "PRICE").Return(nil, unknown).Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, err := circuit.GetActualRates(ctx, []string{"XYZ"}, "PRICE")
+		require.ErrorIs(t, err, entities.ErrUnknownSymbol)
+	}
+	require.Equal(t, entities.CircuitClosed, circuit.State().State)
+}
+
+func TestCircuitBreaker_SetLimits(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	circuit, err := cases.NewCircuitBreaker(provider, 3, time.Minute)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, circuit.SetLimits(0, time.Minute), entities.ErrInvalidParam)
+	require.ErrorIs(t, circuit.SetLimits(1, 0), entities.ErrInvalidParam)
+
+	// после снижения порога цепь размыкается уже на первом отказе
+	require.NoError(t, circuit.SetLimits(1, time.Minute))
+
+	ctx := context.Background()
+	unavailable := errors.Wrap(entities.ErrProviderUnavailable, "failed to execute request")
+	provider.EXPECT().GetActualRates(ctx, []string{"BTC"}, "PRICE").Return(nil, unavailable)
+
+	_, err = circuit.GetActualRates(ctx, []string{"BTC"}, "PRICE")
+	require.ErrorIs(t, err, entities.ErrProviderUnavailable)
+	require.Equal(t, entities.CircuitOpen, circuit.State().State)
+}
@@ -0,0 +1,205 @@
+package cases
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const repairPageSize = 2000
+
+// GapDetector ищет пропуски в сохранённых рядах и при необходимости
+// дозаполняет их историческими данными провайдера.
+type GapDetector struct {
+	storage  Storage
+	history  HistoryProvider
+	readOnly bool
+	// срок хранения сырых точек; старше него данные свёрнуты, и пропуски в них не ищутся
+	rawRetention time.Duration
+}
+
+func NewGapDetector(storage Storage, history HistoryProvider) (*GapDetector, error) {
+	if storage == nil || storage == Storage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "storage not set")
+	}
+
+	if history == nil || history == HistoryProvider(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "history provider not set")
+	}
+
+	return &GapDetector{
+		storage: storage,
+		history: history,
+	}, nil
+}
+
+// NewReadOnlyGapDetector создаёт детектор, который только сообщает о пропусках, но не дозаполняет их.
+func NewReadOnlyGapDetector(storage Storage) (*GapDetector, error) {
+	if storage == nil || storage == Storage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "storage not set")
+	}
+
+	return &GapDetector{
+		storage:  storage,
+		readOnly: true,
+	}, nil
+}
+
+// SetRawRetention ограничивает поиск и дозаполнение пропусков сроком хранения сырых точек: в свёрнутые
+// периоды дозаполненные точки попали бы второй раз при следующей свёртке
+func (gd *GapDetector) SetRawRetention(rawRetention time.Duration) {
+	gd.rawRetention = rawRetention
+}
+
+// FindGaps возвращает пропуски в ряду монеты за период [from, to] относительно ожидаемого шага interval.
+// Соседние точки считаются пропуском, если между ними больше полутора шагов.
+func (gd *GapDetector) FindGaps(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Gap, error) {
+	log := logger.FromContext(ctx)
+	if !from.Before(to) {
+		return nil, errors.Wrap(entities.ErrInvalidRange, "'from' must be before 'to'")
+	}
+
+	if gd.rawRetention > 0 {
+		boundary := time.Now().Add(-gd.rawRetention)
+		if !to.After(boundary) {
+			return nil, errors.Wrapf(entities.ErrInvalidRange, "range is older than raw retention (%v), old periods are stored as rollups", gd.rawRetention)
+		}
+		from = maxTime(from, boundary)
+	}
+
+	titles, err := gd.storage.GetCoinsList(ctx)
+	if err != nil {
+		log.Error("(gaps.FindGaps) failed to get list of coin titles", zap.Any("err", err.Error()))
+		return nil, errors.Wrap(err, "failed to get list of coin titles")
+	}
+
+	if !slices.Contains(titles, title) {
+		return nil, errors.Wrapf(entities.ErrUnknownSymbol, "coin %v is not tracked", title)
+	}
+
+	coins, err := gd.storage.GetHistory(ctx, title, from, to)
+	if err != nil {
+		log.Error("(gaps.FindGaps) failed to get coin history from storage", zap.Any("err", err.Error()))
+		return nil, errors.Wrap(err, "failed to get coin history from storage")
+	}
+
+	step := interval.Duration()
+	tolerance := step + step/2
+
+	// границы периода участвуют как опорные точки, чтобы найти пропуски в начале и в конце
+	points := make([]time.Time, 0, len(coins)+2)
+	points = append(points, from.Add(-step))
+	for _, coin := range coins {
+		points = append(points, coin.CreatedAt)
+	}
+	points = append(points, to.Add(step))
+
+	gaps := make([]entities.Gap, 0)
+	for i := 1; i < len(points); i++ {
+		diff := points[i].Sub(points[i-1])
+		if diff <= tolerance {
+			continue
+		}
+
+		// опорные точки на границах не выровнены по шагу ряда, поэтому пропуск обрезается по [from, to];
+		// промежуток, в который не помещается ни одна точка, пропуском не считается
+		gap := entities.Gap{
+			From: maxTime(points[i-1].Add(step), from),
+			To:   minTime(points[i].Add(-step), to),
+		}
+		if gap.To.Before(gap.From) {
+			continue
+		}
+
+		gap.Missing = int(gap.To.Sub(gap.From)/step) + 1
+		gaps = append(gaps, gap)
+	}
+
+	return gaps, nil
+}
+
+// RepairGaps дозаполняет найденные пропуски историческими точками; возвращает количество сохранённых точек.
+func (gd *GapDetector) RepairGaps(ctx context.Context, title string, interval entities.Interval, from, to time.Time) (int, error) {
+	log := logger.FromContext(ctx)
+	if gd.readOnly {
+		err := errors.Wrap(entities.ErrReadOnly, "read-only gap detector cannot repair gaps")
+		log.Error("(gaps.RepairGaps) gap detector is read-only", zap.Any("err", err.Error()))
+		return 0, err
+	}
+
+	gaps, err := gd.FindGaps(ctx, title, interval, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	repaired := 0
+	for _, gap := range gaps {
+		n, err := gd.repairGap(ctx, title, interval, gap)
+		if err != nil {
+			log.Error("(gaps.RepairGaps) failed to repair gap", zap.Any("title", title), zap.Any("gap", gap), zap.Any("err", err.Error()))
+			return repaired, errors.Wrap(err, "failed to repair gap")
+		}
+
+		repaired += n
+	}
+
+	log.Info("(gaps.RepairGaps) gaps repaired", zap.Any("title", title), zap.Any("gaps", len(gaps)), zap.Any("rows", repaired))
+	return repaired, nil
+}
+
+func (gd *GapDetector) repairGap(ctx context.Context, title string, interval entities.Interval, gap entities.Gap) (int, error) {
+	step := interval.Duration()
+	first := gap.From.Truncate(step)
+	last := gap.To.Truncate(step)
+	if now := time.Now().Truncate(step); last.After(now) {
+		last = now
+	}
+
+	stored := 0
+	for cursor := first; !cursor.After(last); {
+		to := cursor.Add(time.Duration(repairPageSize-1) * step)
+		if to.After(last) {
+			to = last
+		}
+
+		limit := max(int(to.Sub(cursor)/step), 1)
+		history, err := gd.history.GetHistory(ctx, title, interval, to, limit)
+		if err != nil {
+			return stored, errors.Wrap(err, "failed to get history from provider")
+		}
+
+		coins := filterPage(history, cursor, to)
+		if len(coins) > 0 {
+			if err := gd.storage.Store(ctx, coins); err != nil {
+				return stored, errors.Wrap(err, "failed to write history to storage")
+			}
+		}
+
+		stored += len(coins)
+		cursor = to.Add(step)
+	}
+
+	return stored, nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
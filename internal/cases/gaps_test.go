@@ -0,0 +1,161 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+// seedSeries сохраняет минутные точки с указанными смещениями (в минутах) от start
+func seedSeries(t *testing.T, memory *storage.Memory, title string, start time.Time, offsets ...int) {
+	t.Helper()
+
+	coins := make([]entities.Coin, 0, len(offsets))
+	for _, offset := range offsets {
+		coins = append(coins, entities.Coin{Title: title, Price: 100, CreatedAt: start.Add(time.Duration(offset) * time.Minute)})
+	}
+
+	require.NoError(t, memory.Store(context.Background(), coins))
+}
+
+func TestFindGaps(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	gd, err := cases.NewGapDetector(memory, mock_cases.NewMockHistoryProvider(ctrl))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	seedSeries(t, memory, "BTC", start, 0, 1, 5, 6)
+
+	gaps, err := gd.FindGaps(ctx, "BTC", entities.IntervalMinute, start, start.Add(6*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []entities.Gap{{
+		From:    start.Add(2 * time.Minute),
+		To:      start.Add(4 * time.Minute),
+		Missing: 3,
+	}}, gaps)
+}
+
+func TestFindGaps_UnalignedBounds(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	gd, err := cases.NewGapDetector(memory, mock_cases.NewMockHistoryProvider(ctrl))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute).Add(40 * time.Second)
+	seedSeries(t, memory, "BTC", start, 0, 1, 2)
+
+	// границы между точками ряда: до первой и после последней точки не помещается ни одна пропущенная
+	gaps, err := gd.FindGaps(ctx, "BTC", entities.IntervalMinute, start.Add(-50*time.Second), start.Add(2*time.Minute+50*time.Second))
+	require.NoError(t, err)
+	require.Empty(t, gaps)
+
+	// на минуту раньше начала ряда пропущена ровно одна точка, и пропуск не выходит за from
+	from := start.Add(-110 * time.Second)
+	gaps, err = gd.FindGaps(ctx, "BTC", entities.IntervalMinute, from, start.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []entities.Gap{{From: from, To: start.Add(-time.Minute), Missing: 1}}, gaps)
+}
+
+func TestFindGaps_UntrackedCoin(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gd, err := cases.NewGapDetector(storage.NewMemory(), mock_cases.NewMockHistoryProvider(ctrl))
+	require.NoError(t, err)
+
+	now := time.Now()
+	gaps, err := gd.FindGaps(context.Background(), "BTC", entities.IntervalMinute, now.Add(-time.Hour), now)
+	require.Nil(t, gaps)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func TestRepairGaps(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+	gd, err := cases.NewGapDetector(memory, history)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	end := start.Add(6 * time.Minute)
+	seedSeries(t, memory, "BTC", start, 0, 1, 5, 6)
+
+	history.EXPECT().GetHistory(ctx, "BTC", entities.IntervalMinute, start.Add(4*time.Minute), 2).
+		Return(historyPage("BTC", entities.IntervalMinute, start.Add(4*time.Minute), 2), nil)
+
+	repaired, err := gd.RepairGaps(ctx, "BTC", entities.IntervalMinute, start, end)
+	require.NoError(t, err)
+	require.Equal(t, 3, repaired)
+
+	coins, err := memory.GetHistory(ctx, "BTC", start, end)
+	require.NoError(t, err)
+	require.Len(t, coins, 7)
+	for _, coin := range coins[2:5] {
+		require.True(t, coin.Backfilled)
+	}
+
+	gaps, err := gd.FindGaps(ctx, "BTC", entities.IntervalMinute, start, end)
+	require.NoError(t, err)
+	require.Empty(t, gaps)
+}
+
+func TestRepairGaps_RawRetention(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := storage.NewMemory()
+	history := mock_cases.NewMockHistoryProvider(ctrl)
+	gd, err := cases.NewGapDetector(memory, history)
+	require.NoError(t, err)
+	gd.SetRawRetention(30 * time.Minute)
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	seedSeries(t, memory, "BTC", start, 0, 1, 5, 6)
+
+	// период целиком свёрнут - к провайдеру не ходим
+	_, err = gd.RepairGaps(ctx, "BTC", entities.IntervalMinute, start, start.Add(6*time.Minute))
+	require.ErrorIs(t, err, entities.ErrInvalidRange)
+
+	// пропуски ищутся только в пределах срока хранения сырых точек
+	seedSeries(t, memory, "BTC", start, 40, 41, 42)
+	gaps, err := gd.FindGaps(ctx, "BTC", entities.IntervalMinute, start, start.Add(42*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, gaps, 1)
+	require.False(t, gaps[0].From.Before(time.Now().Add(-30*time.Minute).Add(-time.Second)))
+	require.Equal(t, start.Add(39*time.Minute), gaps[0].To)
+}
+
+func TestRepairGaps_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	gd, err := cases.NewReadOnlyGapDetector(storage.NewMemory())
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = gd.RepairGaps(context.Background(), "BTC", entities.IntervalMinute, now.Add(-time.Hour), now)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
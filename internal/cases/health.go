@@ -0,0 +1,204 @@
+package cases
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	CheckPostgres   = "postgres"
+	CheckMigrations = "migrations"
+	CheckIngestion  = "ingestion"
+)
+
+// HealthChecker проверяет готовность реплики обслуживать запросы и собирает сведения о её состоянии
+type HealthChecker struct {
+	storage       HealthStorage
+	schemaVersion int
+	// допустимые отставания в наносекундах; меняются на лету через SetReadyMaxLag и SetStatusMaxLag
+	readyMaxLag  atomic.Int64
+	statusMaxLag atomic.Int64
+	circuit      *CircuitBreaker
+}
+
+// NewHealthChecker создаёт проверку готовности; schemaVersion - версия схемы, с которой собран бинарник,
+// readyMaxLag - сколько может пройти с последней полученной цены, прежде чем реплика станет не готова (0 - не проверять)
+func NewHealthChecker(storage HealthStorage, schemaVersion int, readyMaxLag time.Duration) (*HealthChecker, error) {
+	if storage == nil || storage == HealthStorage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "storage not set")
+	}
+
+	if schemaVersion < 1 || readyMaxLag < 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "invalid schema version or max lag")
+	}
+
+	h := &HealthChecker{
+		storage:       storage,
+		schemaVersion: schemaVersion,
+	}
+	h.readyMaxLag.Store(int64(readyMaxLag))
+
+	return h, nil
+}
+
+// SetReadyMaxLag меняет допустимое отставание для готовности без перезапуска (0 - не проверять)
+func (h *HealthChecker) SetReadyMaxLag(maxLag time.Duration) error {
+	if maxLag < 0 {
+		return errors.Wrap(entities.ErrInvalidParam, "max lag must not be negative")
+	}
+
+	h.readyMaxLag.Store(int64(maxLag))
+	return nil
+}
+
+// SetStatusMaxLag задаёт отставание данных по монете, после которого статус реплики считается degraded (0 - не проверять)
+func (h *HealthChecker) SetStatusMaxLag(maxLag time.Duration) error {
+	if maxLag < 0 {
+		return errors.Wrap(entities.ErrInvalidParam, "max lag must not be negative")
+	}
+
+	h.statusMaxLag.Store(int64(maxLag))
+	return nil
+}
+
+// SetCircuit включает в статус состояние предохранителя провайдера
+func (h *HealthChecker) SetCircuit(circuit *CircuitBreaker) {
+	h.circuit = circuit
+}
+
+// Ready выполняет все проверки готовности; реплика готова, если ни одна не вернула ошибку
+func (h *HealthChecker) Ready(ctx context.Context) []entities.Check {
+	log := logger.FromContext(ctx)
+	checks := []entities.Check{
+		{Name: CheckPostgres, Err: h.storage.Ping(ctx)},
+		{Name: CheckMigrations, Err: h.checkMigrations(ctx)},
+		{Name: CheckIngestion, Err: h.checkIngestion(ctx)},
+	}
+
+	for _, check := range checks {
+		if check.Err != nil {
+			log.Warn("(HealthChecker.Ready) check failed", zap.Any("check", check.Name), zap.Any("err", check.Err.Error()))
+		}
+	}
+
+	return checks
+}
+
+func (h *HealthChecker) Status(ctx context.Context) (entities.Status, error) {
+	coins, err := h.coinLags(ctx)
+	if err != nil {
+		return entities.Status{}, err
+	}
+
+	// время берётся из хранилища: на репликах serve актуализация не выполняется
+	lastUpdate, err := h.storage.LastUpdate(ctx)
+	if err != nil {
+		return entities.Status{}, errors.Wrap(err, "failed to get last update time")
+	}
+
+	status := entities.Status{Coins: coins, LastActualize: lastUpdate}
+	for i := range status.Coins {
+		if h.lagging(status.Coins[i]) {
+			status.Coins[i].Lagging = true
+			status.Degraded = true
+		}
+	}
+
+	if h.circuit != nil {
+		provider := h.circuit.State()
+		status.Provider = &provider
+	}
+
+	return status, nil
+}
+
+func (h *HealthChecker) checkMigrations(ctx context.Context) error {
+	version, dirty, err := h.storage.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return errors.Wrapf(entities.ErrStorageUnavailable, "schema migration %d is dirty", version)
+	}
+
+	// более новая схема допустима: во время выкатки старые реплики работают с уже обновлённой БД
+	if version < h.schemaVersion {
+		return errors.Wrapf(entities.ErrStorageUnavailable, "schema version %d, expected %d", version, h.schemaVersion)
+	}
+
+	return nil
+}
+
+// checkIngestion проверяет, что цены продолжают поступать. Берётся самая свежая цена по всем монетам:
+// отставание одной монеты (например, снятой с торгов) не должно выводить из ротации все реплики
+func (h *HealthChecker) checkIngestion(ctx context.Context) error {
+	maxLag := time.Duration(h.readyMaxLag.Load())
+	if maxLag == 0 {
+		return nil
+	}
+
+	lastUpdate, err := h.storage.LastUpdate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if lastUpdate.IsZero() {
+		return errors.Wrap(entities.ErrStaleData, "no prices ingested yet")
+	}
+
+	if lag := time.Since(lastUpdate); lag > maxLag {
+		return errors.Wrapf(entities.ErrStaleData, "last price ingested %v ago, max %v", lag.Round(time.Second), maxLag)
+	}
+
+	return nil
+}
+
+// lagging сообщает, что данные по монете отстают больше допустимого или за сегодня их нет
+func (h *HealthChecker) lagging(coin entities.CoinLag) bool {
+	maxLag := time.Duration(h.statusMaxLag.Load())
+	if maxLag == 0 {
+		return false
+	}
+
+	return coin.LastUpdate.IsZero() || coin.Lag > maxLag
+}
+
+// coinLags считает отставание данных по каждой отслеживаемой монете
+func (h *HealthChecker) coinLags(ctx context.Context) ([]entities.CoinLag, error) {
+	titles, err := h.storage.GetCoinsList(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get list of coin titles")
+	}
+
+	coins, err := h.storage.GetActualCoins(ctx, titles)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get coin data from storage")
+	}
+
+	updates := make(map[string]time.Time, len(coins))
+	for _, coin := range coins {
+		updates[coin.Title] = coin.CreatedAt
+	}
+
+	now := time.Now()
+	lags := make([]entities.CoinLag, 0, len(titles))
+	for _, title := range titles {
+		lag := entities.CoinLag{Title: title}
+		if updated, ok := updates[title]; ok {
+			lag.LastUpdate = updated
+			lag.Lag = now.Sub(updated)
+		}
+
+		lags = append(lags, lag)
+	}
+
+	return lags, nil
+}
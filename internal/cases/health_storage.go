@@ -0,0 +1,22 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./health_storage.go -destination=./mocks/gen/mock_health_storage.go
+type HealthStorage interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int, bool, error)
+	GetCoinsList(ctx context.Context) ([]string, error)
+	GetActualCoins(ctx context.Context, titles []string) ([]entities.Coin, error)
+	LastUpdate(ctx context.Context) (time.Time, error)
+}
+
+// Ping - проверка соединения с хранилищем
+// SchemaVersion - применённая версия схемы и признак незавершённой (dirty) миграции
+// GetCoinsList, GetActualCoins - как в Storage, для расчёта отставания данных по монетам
+// LastUpdate - время самой свежей цены, полученной не из исторических данных; пустое, если цен нет
@@ -0,0 +1,189 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestHealthChecker_Ready(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	tests := []struct {
+		name      string
+		pingErr   error
+		version   int
+		dirty     bool
+		updated   time.Time
+		failed    string
+		wantError error
+	}{
+		{
+			name:    "ready",
+			version: 5,
+			updated: now.Add(-time.Minute),
+		},
+		{
+			name:    "newer schema",
+			version: 6,
+			updated: now,
+		},
+		{
+			name:      "postgres down",
+			updated:   now,
+			pingErr:   errors.Wrap(entities.ErrStorageUnavailable, "failed to ping DB"),
+			version:   5,
+			failed:    cases.CheckPostgres,
+			wantError: entities.ErrStorageUnavailable,
+		},
+		{
+			name:      "outdated schema",
+			updated:   now,
+			version:   4,
+			failed:    cases.CheckMigrations,
+			wantError: entities.ErrStorageUnavailable,
+		},
+		{
+			name:      "dirty schema",
+			updated:   now,
+			version:   5,
+			dirty:     true,
+			failed:    cases.CheckMigrations,
+			wantError: entities.ErrStorageUnavailable,
+		},
+		{
+			name:      "ingestion stopped",
+			version:   5,
+			updated:   now.Add(-10 * time.Minute),
+			failed:    cases.CheckIngestion,
+			wantError: entities.ErrStaleData,
+		},
+		{
+			name:      "no prices yet",
+			version:   5,
+			failed:    cases.CheckIngestion,
+			wantError: entities.ErrStaleData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := mock_cases.NewMockHealthStorage(ctrl)
+			health, err := cases.NewHealthChecker(storage, 5, 5*time.Minute)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			storage.EXPECT().Ping(ctx).Return(tt.pingErr)
+			storage.EXPECT().SchemaVersion(ctx).Return(tt.version, tt.dirty, nil)
+			storage.EXPECT().LastUpdate(ctx).Return(tt.updated, nil)
+
+			for _, check := range health.Ready(ctx) {
+				if check.Name == tt.failed {
+					require.ErrorIs(t, check.Err, tt.wantError)
+					continue
+				}
+
+				require.NoError(t, check.Err, check.Name)
+			}
+		})
+	}
+}
+
+func TestHealthChecker_Status(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockHealthStorage(ctrl)
+	health, err := cases.NewHealthChecker(storage, 5, 0)
+	require.NoError(t, err)
+
+	circuit, err := cases.NewCircuitBreaker(mock_cases.NewMockCryptoProvider(ctrl), 3, time.Minute)
+	require.NoError(t, err)
+	health.SetCircuit(circuit)
+
+	ctx := context.Background()
+	updated := time.Now().Add(-time.Minute)
+	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC", "ETH"}, nil)
+	storage.EXPECT().GetActualCoins(ctx, []string{"BTC", "ETH"}).Return([]entities.Coin{{Title: "BTC", CreatedAt: updated}}, nil)
+	storage.EXPECT().LastUpdate(ctx).Return(updated, nil)
+
+	status, err := health.Status(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, status.Provider)
+	require.Equal(t, entities.CircuitClosed, status.Provider.State)
+	// время последней цены берётся из хранилища, а не из актуализации на этой реплике
+	require.Equal(t, updated, status.LastActualize)
+	require.Len(t, status.Coins, 2)
+	require.Equal(t, updated, status.Coins[0].LastUpdate)
+	require.GreaterOrEqual(t, status.Coins[0].Lag, time.Minute)
+	require.True(t, status.Coins[1].LastUpdate.IsZero())
+	require.False(t, status.Degraded)
+}
+
+func TestHealthChecker_StatusDegraded(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	tests := []struct {
+		name     string
+		coins    []entities.Coin
+		lagging  []bool
+		degraded bool
+	}{
+		{
+			name:    "fresh",
+			coins:   []entities.Coin{{Title: "BTC", CreatedAt: now.Add(-time.Minute)}, {Title: "ETH", CreatedAt: now}},
+			lagging: []bool{false, false},
+		},
+		{
+			name:     "lagging coin",
+			coins:    []entities.Coin{{Title: "BTC", CreatedAt: now.Add(-10 * time.Minute)}, {Title: "ETH", CreatedAt: now}},
+			lagging:  []bool{true, false},
+			degraded: true,
+		},
+		{
+			name:     "no data today",
+			coins:    []entities.Coin{{Title: "BTC", CreatedAt: now}},
+			lagging:  []bool{false, true},
+			degraded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := mock_cases.NewMockHealthStorage(ctrl)
+			health, err := cases.NewHealthChecker(storage, 5, 0)
+			require.NoError(t, err)
+			require.NoError(t, health.SetStatusMaxLag(5*time.Minute))
+
+			ctx := context.Background()
+			titles := []string{"BTC", "ETH"}
+			storage.EXPECT().GetCoinsList(ctx).Return(titles, nil)
+			storage.EXPECT().GetActualCoins(ctx, titles).Return(tt.coins, nil)
+			storage.EXPECT().LastUpdate(ctx).Return(now, nil)
+
+			status, err := health.Status(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.degraded, status.Degraded)
+			for i, coin := range status.Coins {
+				require.Equal(t, tt.lagging[i], coin.Lagging, coin.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./history_provider.go -destination=./mocks/gen/mock_history_provider.go
+type HistoryProvider interface {
+	GetHistory(ctx context.Context, title string, interval entities.Interval, to time.Time, limit int) ([]entities.Coin, error)
+}
+
+// GetHistory - возвращает до limit+1 исторических точек с шагом interval, заканчивая моментом to (включительно)
@@ -0,0 +1,145 @@
+package cases
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+// Hub - внутрипроцессная шина событий о новых ценах между записью в хранилище и подписчиками API.
+// Последние события хранятся в кольцевом буфере, чтобы переподключившийся клиент мог дочитать пропущенное.
+// Идентификатор события - время цены в микросекундах Unix (точность Postgres): на всех репликах одна и та же
+// цена получает один id, поэтому клиент может переподключиться к любой реплике. У цен разных монет из одного
+// опроса провайдера id совпадают, поэтому при переподключении события с id, равным последнему полученному, повторяются
+type Hub struct {
+	mu         sync.Mutex
+	replay     []entities.PriceEvent
+	replaySize int
+	bufferSize int
+	latest     map[string]time.Time
+	subs       map[*subscriber]struct{}
+	closed     bool
+}
+
+type subscriber struct {
+	titles map[string]struct{}
+	ch     chan entities.PriceEvent
+}
+
+func NewHub(replaySize, bufferSize int) (*Hub, error) {
+	if replaySize <= 0 || bufferSize <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "replay and buffer sizes must be positive")
+	}
+
+	return &Hub{
+		replay:     make([]entities.PriceEvent, 0, replaySize),
+		replaySize: replaySize,
+		bufferSize: bufferSize,
+		latest:     make(map[string]time.Time),
+		subs:       make(map[*subscriber]struct{}),
+	}, nil
+}
+
+// Publish рассылает подписчикам цены, которые новее уже разосланных по этой монете.
+// Подписчик, не успевающий вычитывать события, отключается: блокировать запись из-за одного клиента нельзя.
+func (h *Hub) Publish(coins []entities.Coin) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	for _, coin := range coins {
+		if last, ok := h.latest[coin.Title]; ok && !coin.CreatedAt.After(last) {
+			continue
+		}
+		h.latest[coin.Title] = coin.CreatedAt
+
+		event := entities.PriceEvent{ID: uint64(coin.CreatedAt.UnixMicro()), Coin: coin}
+		if len(h.replay) == h.replaySize {
+			h.replay = append(h.replay[:0], h.replay[1:]...)
+		}
+		h.replay = append(h.replay, event)
+
+		for sub := range h.subs {
+			if _, ok := sub.titles[coin.Title]; !ok {
+				continue
+			}
+
+			select {
+			case sub.ch <- event:
+			default:
+				log.Warn("(hub.Publish) subscriber is too slow, disconnecting")
+				h.remove(sub)
+			}
+		}
+	}
+}
+
+// Subscribe подписывает на события по монетам titles. Если lastEventID > 0, сначала отдаются
+// сохранённые события начиная с него (не больше размера буфера подписчика).
+// Канал закрывается при отписке, отключении медленного подписчика или остановке шины.
+func (h *Hub) Subscribe(titles []string, lastEventID uint64) (<-chan entities.PriceEvent, func()) {
+	sub := &subscriber{
+		titles: make(map[string]struct{}, len(titles)),
+		ch:     make(chan entities.PriceEvent, h.bufferSize),
+	}
+	for _, title := range titles {
+		sub.titles[title] = struct{}{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	if lastEventID > 0 {
+		missed := make([]entities.PriceEvent, 0)
+		for _, event := range h.replay {
+			if _, ok := sub.titles[event.Coin.Title]; ok && event.ID >= lastEventID {
+				missed = append(missed, event)
+			}
+		}
+		if len(missed) > h.bufferSize {
+			missed = missed[len(missed)-h.bufferSize:]
+		}
+		for _, event := range missed {
+			sub.ch <- event
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+
+	return sub.ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+}
+
+// Close отключает всех подписчиков; новые подписки сразу получают закрытый канал
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) remove(sub *subscriber) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+
+	delete(h.subs, sub)
+	close(sub.ch)
+}
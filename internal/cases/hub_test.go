@@ -0,0 +1,161 @@
+package cases_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestNewHub(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		replaySize int
+		bufferSize int
+		wantErr    bool
+		resErr     error
+	}{
+		{
+			name:       "valid input",
+			replaySize: 10,
+			bufferSize: 2,
+		},
+		{
+			name:       "zero replay size",
+			bufferSize: 2,
+			wantErr:    true,
+			resErr:     entities.ErrInvalidParam,
+		},
+		{
+			name:       "zero buffer size",
+			replaySize: 10,
+			wantErr:    true,
+			resErr:     entities.ErrInvalidParam,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			hub, err := cases.NewHub(tt.replaySize, tt.bufferSize)
+			if tt.wantErr {
+				require.Nil(t, hub)
+				require.ErrorIs(t, err, tt.resErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, hub)
+		})
+	}
+}
+
+func TestHub_PublishFiltersTitlesAndRepeats(t *testing.T) {
+	t.Parallel()
+	hub, err := cases.NewHub(10, 10)
+	require.NoError(t, err)
+
+	events, unsubscribe := hub.Subscribe([]string{"BTC"}, 0)
+	defer unsubscribe()
+
+	now := time.Now()
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: now}, {Title: "ETH", Price: 10, CreatedAt: now}})
+	// та же цена, пришедшая повторно от наблюдателя за хранилищем
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: now}})
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 101, CreatedAt: now.Add(time.Minute)}})
+
+	first := <-events
+	require.Equal(t, "BTC", first.Coin.Title)
+	require.Equal(t, float64(100), first.Coin.Price)
+
+	second := <-events
+	require.Equal(t, float64(101), second.Coin.Price)
+	require.Greater(t, second.ID, first.ID)
+	require.Empty(t, events)
+}
+
+func TestHub_SubscribeResumesAfterLastEventID(t *testing.T) {
+	t.Parallel()
+	hub, err := cases.NewHub(10, 10)
+	require.NoError(t, err)
+
+	now := time.Now()
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: now}})
+	hub.Publish([]entities.Coin{{Title: "ETH", Price: 10, CreatedAt: now}})
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 101, CreatedAt: now.Add(time.Minute)}})
+
+	events, unsubscribe := hub.Subscribe([]string{"BTC"}, uint64(now.Add(time.Second).UnixMicro()))
+	defer unsubscribe()
+
+	missed := <-events
+	require.Equal(t, uint64(now.Add(time.Minute).UnixMicro()), missed.ID)
+	require.Equal(t, float64(101), missed.Coin.Price)
+	require.Empty(t, events)
+}
+
+func TestHub_EventIDsMatchAcrossHubs(t *testing.T) {
+	t.Parallel()
+	// на репликах цены приходят из хранилища в разном порядке и с разной историей
+	first, err := cases.NewHub(10, 10)
+	require.NoError(t, err)
+	second, err := cases.NewHub(10, 10)
+	require.NoError(t, err)
+
+	now := time.Now()
+	btc := entities.Coin{Title: "BTC", Price: 100, CreatedAt: now}
+	eth := entities.Coin{Title: "ETH", Price: 10, CreatedAt: now}
+	first.Publish([]entities.Coin{{Title: "BTC", Price: 99, CreatedAt: now.Add(-time.Minute)}})
+	first.Publish([]entities.Coin{btc, eth})
+	second.Publish([]entities.Coin{eth})
+	second.Publish([]entities.Coin{btc})
+
+	// клиент получил BTC на первой реплике и переподключился ко второй
+	events, unsubscribe := second.Subscribe([]string{"BTC", "ETH"}, uint64(now.UnixMicro()))
+	defer unsubscribe()
+
+	// ETH с тем же id не теряется, BTC приходит повторно
+	titles := []string{(<-events).Coin.Title, (<-events).Coin.Title}
+	require.ElementsMatch(t, []string{"BTC", "ETH"}, titles)
+	require.Empty(t, events)
+}
+
+func TestHub_DisconnectsSlowSubscriber(t *testing.T) {
+	t.Parallel()
+	hub, err := cases.NewHub(10, 1)
+	require.NoError(t, err)
+
+	events, unsubscribe := hub.Subscribe([]string{"BTC"}, 0)
+	defer unsubscribe()
+
+	now := time.Now()
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: now}})
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 101, CreatedAt: now.Add(time.Minute)}})
+
+	event, ok := <-events
+	require.True(t, ok)
+	require.Equal(t, uint64(now.UnixMicro()), event.ID)
+
+	_, ok = <-events
+	require.False(t, ok)
+}
+
+func TestHub_Close(t *testing.T) {
+	t.Parallel()
+	hub, err := cases.NewHub(10, 1)
+	require.NoError(t, err)
+
+	events, unsubscribe := hub.Subscribe([]string{"BTC"}, 0)
+	hub.Close()
+	unsubscribe()
+
+	_, ok := <-events
+	require.False(t, ok)
+
+	events, _ = hub.Subscribe([]string{"BTC"}, 0)
+	_, ok = <-events
+	require.False(t, ok)
+}
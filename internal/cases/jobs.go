@@ -0,0 +1,74 @@
+package cases
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// Jobs учитывает выполняющиеся плановые задачи, чтобы при остановке приложения
+// отменить их и дождаться завершения до закрытия хранилища
+type Jobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+}
+
+func NewJobs() *Jobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Jobs{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Context возвращает контекст, который отменяется при остановке
+func (j *Jobs) Context() context.Context {
+	return j.ctx
+}
+
+// Run выполняет задачу name; после начала остановки новые задачи не запускаются
+func (j *Jobs) Run(name string, job func(ctx context.Context) error) error {
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return errors.Wrapf(entities.ErrShutdown, "job %v not started", name)
+	}
+	j.running.Add(1)
+	j.mu.Unlock()
+	defer j.running.Done()
+
+	return job(logger.With(j.ctx, zap.String("job", name)))
+}
+
+// Shutdown запрещает запуск новых задач, отменяет выполняющиеся и ждёт их завершения, но не дольше ctx
+func (j *Jobs) Shutdown(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	j.mu.Lock()
+	j.closed = true
+	j.mu.Unlock()
+	j.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		j.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		err := errors.Wrap(ctx.Err(), "running jobs did not finish in time")
+		log.Error("(jobs.Shutdown) failed to wait for running jobs", zap.Any("err", err.Error()))
+		return err
+	}
+}
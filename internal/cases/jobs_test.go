@@ -0,0 +1,95 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestJobs_RunAfterShutdown(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// моки без ожиданий: любое обращение к хранилищу или провайдеру провалит тест
+	srv, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), mock_cases.NewMockStorage(ctrl))
+	require.NoError(t, err)
+
+	jobs := cases.NewJobs()
+	require.NoError(t, jobs.Shutdown(context.Background()))
+
+	err = jobs.Run("actualize_rates", srv.ActualizeRates)
+	require.ErrorIs(t, err, entities.ErrShutdown)
+}
+
+func TestJobs_ShutdownCancelsRunningJob(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	// провайдер отвечает уже после начала остановки; Store не ожидается - запись после остановки провалит тест
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"BTC"}, "PRICE").DoAndReturn(
+		func(ctx context.Context, titles []string, extraArg string) ([]entities.Coin, error) {
+			close(started)
+			<-ctx.Done()
+			return []entities.Coin{{Title: "BTC", Price: 100}}, nil
+		})
+
+	jobs := cases.NewJobs()
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- jobs.Run("actualize_rates", srv.ActualizeRates)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, jobs.Shutdown(ctx))
+
+	// Shutdown вернулся только после завершения задачи
+	select {
+	case err := <-runErr:
+		require.ErrorIs(t, err, context.Canceled)
+	default:
+		t.Fatal("job is still running after shutdown")
+	}
+}
+
+func TestJobs_ShutdownDeadline(t *testing.T) {
+	t.Parallel()
+
+	jobs := cases.NewJobs()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = jobs.Run("stuck", func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := jobs.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, jobs.Context().Err(), context.Canceled)
+}
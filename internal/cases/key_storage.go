@@ -0,0 +1,25 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./key_storage.go -destination=./mocks/gen/mock_key_storage.go
+type KeyStorage interface {
+	CreateKey(ctx context.Context, key entities.APIKey) (entities.APIKey, error)
+	GetKeyByHash(ctx context.Context, hash string) (entities.APIKey, error)
+	ListKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeKey(ctx context.Context, id int64) error
+	AddUsage(ctx context.Context, usage []entities.KeyUsage) error
+	GetUsage(ctx context.Context, id int64, from, to time.Time) ([]entities.KeyUsage, error)
+}
+
+// CreateKey - для сохранения нового ключа; возвращает ключ с присвоенными ID и CreatedAt
+// GetKeyByHash - для поиска ключа по хешу (ErrNotFound, если ключа нет)
+// ListKeys - для получения всех ключей, включая отозванные
+// RevokeKey - для отзыва ключа (ErrNotFound, если ключа нет)
+// AddUsage - для прибавления числа запросов к суточным счётчикам ключей
+// GetUsage - для получения суточных счётчиков ключа за дни [from, to]
@@ -0,0 +1,109 @@
+package cases
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	unlockTimeout = 5 * time.Second
+	// confirmTimeout - сколько ждать проверки лока перед плановой задачей
+	confirmTimeout = 5 * time.Second
+)
+
+// LeaderElector следит за тем, чтобы плановые задачи выполняла только одна реплика.
+// Лидерство удерживается, пока жив лок; при падении лидера лок освобождается
+// и следующая реплика захватывает его на очередной попытке.
+type LeaderElector struct {
+	lock     LeaderLock
+	interval time.Duration
+	isLeader atomic.Bool
+}
+
+func NewLeaderElector(lock LeaderLock, interval time.Duration) (*LeaderElector, error) {
+	if lock == nil || lock == LeaderLock(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "leader lock not set")
+	}
+
+	if interval <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "election interval must be positive")
+	}
+
+	return &LeaderElector{
+		lock:     lock,
+		interval: interval,
+	}, nil
+}
+
+func (le *LeaderElector) IsLeader() bool {
+	return le.isLeader.Load()
+}
+
+// Confirm перепроверяет лок перед плановой задачей. Между попытками Run сессия лока может оборваться,
+// а IsLeader оставался бы true до следующей попытки, и задачу выполнили бы две реплики сразу.
+// Захватить лок Confirm не пытается: это делает только Run
+func (le *LeaderElector) Confirm(ctx context.Context) bool {
+	if !le.isLeader.Load() {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+
+	le.elect(ctx)
+	return le.isLeader.Load()
+}
+
+// Run блокируется до отмены ctx, периодически пытаясь захватить или продлить лидерство.
+func (le *LeaderElector) Run(ctx context.Context) {
+	ticker := time.NewTicker(le.interval)
+	defer ticker.Stop()
+
+	for {
+		le.elect(ctx)
+
+		select {
+		case <-ctx.Done():
+			le.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (le *LeaderElector) elect(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	locked, err := le.lock.TryLock(ctx)
+	if err != nil {
+		log.Error("(elector.elect) failed to acquire leader lock", zap.Any("err", err.Error()))
+		locked = false
+	}
+
+	wasLeader := le.isLeader.Swap(locked)
+	switch {
+	case locked && !wasLeader:
+		log.Info("(elector.elect) became leader")
+	case !locked && wasLeader:
+		log.Warn("(elector.elect) lost leadership")
+	}
+}
+
+func (le *LeaderElector) resign() {
+	ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+	defer cancel()
+
+	le.isLeader.Store(false)
+	if err := le.lock.Unlock(ctx); err != nil {
+		log.Error("(elector.resign) failed to release leader lock", zap.Any("err", err.Error()))
+		return
+	}
+
+	log.Info("(elector.resign) leader lock released")
+}
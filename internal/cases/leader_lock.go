@@ -0,0 +1,14 @@
+package cases
+
+import (
+	"context"
+)
+
+//go:generate mockgen -source=./leader_lock.go -destination=./mocks/gen/mock_leader_lock.go
+type LeaderLock interface {
+	TryLock(ctx context.Context) (bool, error)
+	Unlock(ctx context.Context) error
+}
+
+// TryLock - пытается захватить лок лидера (или подтвердить, что он всё ещё удерживается этой репликой)
+// Unlock - освобождает лок, если он был захвачен
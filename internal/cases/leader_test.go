@@ -0,0 +1,187 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+const (
+	testElectionInterval = 10 * time.Millisecond
+	testWaitFor          = time.Second
+)
+
+func TestNewLeaderElector(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tests := []struct {
+		name     string
+		lock     cases.LeaderLock
+		interval time.Duration
+		wantErr  bool
+		resErr   error
+	}{
+		{
+			name:     "valid input",
+			lock:     mock_cases.NewMockLeaderLock(ctrl),
+			interval: time.Second,
+		},
+		{
+			name:     "lock not set",
+			lock:     nil,
+			interval: time.Second,
+			wantErr:  true,
+			resErr:   entities.ErrInvalidParam,
+		},
+		{
+			name:     "wrong interval",
+			lock:     mock_cases.NewMockLeaderLock(ctrl),
+			interval: 0,
+			wantErr:  true,
+			resErr:   entities.ErrInvalidParam,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			elector, err := cases.NewLeaderElector(tt.lock, tt.interval)
+			if tt.wantErr {
+				require.Nil(t, elector)
+				require.ErrorIs(t, err, tt.resErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, elector)
+		})
+	}
+}
+
+func TestLeaderElector_SingleLeader(t *testing.T) {
+	t.Parallel()
+
+	memory := storage.NewMemory()
+	first := newMemoryElector(t, memory)
+	second := newMemoryElector(t, memory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go first.Run(ctx)
+	require.Eventually(t, first.IsLeader, testWaitFor, testElectionInterval)
+
+	go second.Run(ctx)
+	require.Never(t, second.IsLeader, 10*testElectionInterval, testElectionInterval)
+	require.True(t, first.IsLeader())
+}
+
+func TestLeaderElector_Failover(t *testing.T) {
+	t.Parallel()
+
+	memory := storage.NewMemory()
+	first := newMemoryElector(t, memory)
+	second := newMemoryElector(t, memory)
+
+	firstCtx, stopFirst := context.WithCancel(context.Background())
+	secondCtx, stopSecond := context.WithCancel(context.Background())
+	defer stopSecond()
+
+	firstDone := make(chan struct{})
+	go func() {
+		first.Run(firstCtx)
+		close(firstDone)
+	}()
+	require.Eventually(t, first.IsLeader, testWaitFor, testElectionInterval)
+
+	go second.Run(secondCtx)
+
+	// лидер "умирает" и освобождает лок
+	stopFirst()
+	<-firstDone
+	require.False(t, first.IsLeader())
+
+	require.Eventually(t, second.IsLeader, testWaitFor, testElectionInterval)
+}
+
+func TestLeaderElector_LockError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lock := mock_cases.NewMockLeaderLock(ctrl)
+	elector, err := cases.NewLeaderElector(lock, time.Hour)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lock.EXPECT().TryLock(gomock.Any()).DoAndReturn(func(context.Context) (bool, error) {
+		cancel()
+		return false, errors.New("TryLock error")
+	})
+	lock.EXPECT().Unlock(gomock.Any()).Return(nil)
+
+	elector.Run(ctx)
+	require.False(t, elector.IsLeader())
+}
+
+func TestLeaderElector_ConfirmLostSession(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lock := mock_cases.NewMockLeaderLock(ctrl)
+	elector, err := cases.NewLeaderElector(lock, time.Hour)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	// без лидерства лок не проверяется
+	require.False(t, elector.Confirm(ctx))
+
+	gomock.InOrder(
+		lock.EXPECT().TryLock(gomock.Any()).Return(true, nil),
+		lock.EXPECT().TryLock(gomock.Any()).Return(true, nil),
+		// сессия лока оборвалась до следующей попытки Run
+		lock.EXPECT().TryLock(gomock.Any()).Return(false, errors.New("connection reset")),
+	)
+	lock.EXPECT().Unlock(gomock.Any()).Return(nil)
+
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+	require.Eventually(t, elector.IsLeader, testWaitFor, testElectionInterval)
+
+	require.True(t, elector.Confirm(ctx))
+	require.False(t, elector.Confirm(ctx))
+	require.False(t, elector.IsLeader())
+
+	cancel()
+	<-done
+}
+
+func newMemoryElector(t *testing.T, memory *storage.Memory) *cases.LeaderElector {
+	t.Helper()
+
+	lock, err := storage.NewMemoryLock(memory, 1)
+	require.NoError(t, err)
+
+	elector, err := cases.NewLeaderElector(lock, testElectionInterval)
+	require.NoError(t, err)
+
+	return elector
+}
@@ -0,0 +1,129 @@
+package cases
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+const (
+	// limiterSweepInterval - как часто из памяти удаляются корзины клиентов, переставших присылать запросы
+	limiterSweepInterval = time.Minute
+	// providerRoute - корзина для вызовов провайдера в лимитере сервиса
+	providerRoute = "provider"
+)
+
+type bucketKey struct {
+	client string
+	route  string
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// ClientLimiter ограничивает частоту запросов каждого клиента к каждому маршруту token bucket'ом:
+// для маршрутов из routes - своим бюджетом, для остальных - общим. Корзины хранятся в памяти реплики
+type ClientLimiter struct {
+	mu      sync.Mutex
+	budget  entities.RateBudget
+	routes  map[string]entities.RateBudget
+	buckets map[bucketKey]*bucket
+	swept   time.Time
+}
+
+func NewClientLimiter(budget entities.RateBudget, routes map[string]entities.RateBudget) (*ClientLimiter, error) {
+	l := &ClientLimiter{buckets: make(map[bucketKey]*bucket), swept: time.Now()}
+	if err := l.SetBudgets(budget, routes); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// SetBudgets меняет бюджеты на лету. Сбрасываются только корзины маршрутов, бюджет которых изменился:
+// перезагрузка конфига по другим ключам не должна выдавать всем клиентам полный запас
+func (l *ClientLimiter) SetBudgets(budget entities.RateBudget, routes map[string]entities.RateBudget) error {
+	if !budget.Valid() {
+		return errors.Wrap(entities.ErrInvalidParam, "rate limit rps and burst must be positive")
+	}
+
+	for route, b := range routes {
+		if !b.Valid() {
+			return errors.Wrapf(entities.ErrInvalidParam, "rate limit rps and burst for route %v must be positive", route)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prevBudget, prevRoutes := l.budget, l.routes
+	l.budget = budget
+	l.routes = routes
+	for key := range l.buckets {
+		if routeBudget(prevBudget, prevRoutes, key.route) != l.budgetFor(key.route) {
+			delete(l.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+// Allow забирает токен из корзины клиента для маршрута; если токенов нет, возвращает,
+// через сколько появится следующий
+func (l *ClientLimiter) Allow(client, route string) (time.Duration, bool) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= limiterSweepInterval {
+		l.sweep(now)
+	}
+
+	key := bucketKey{client: client, route: route}
+	b, ok := l.buckets[key]
+	if !ok {
+		budget := l.budgetFor(route)
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(budget.RPS), budget.Burst)}
+		l.buckets[key] = b
+	}
+	b.seen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+
+	return 0, true
+}
+
+func (l *ClientLimiter) budgetFor(route string) entities.RateBudget {
+	return routeBudget(l.budget, l.routes, route)
+}
+
+func routeBudget(budget entities.RateBudget, routes map[string]entities.RateBudget, route string) entities.RateBudget {
+	if b, ok := routes[route]; ok {
+		return b
+	}
+
+	return budget
+}
+
+// sweep удаляет корзины, которые успели наполниться: новая корзина для того же клиента ничем от них не отличается
+func (l *ClientLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		budget := l.budgetFor(key.route)
+		refill := time.Duration(float64(budget.Burst) / budget.RPS * float64(time.Second))
+		if now.Sub(b.seen) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
@@ -0,0 +1,100 @@
+package cases_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestNewClientLimiter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		budget  entities.RateBudget
+		routes  map[string]entities.RateBudget
+		wantErr bool
+	}{
+		{name: "valid input", budget: entities.RateBudget{RPS: 1, Burst: 1}, routes: map[string]entities.RateBudget{"/rates/last": {RPS: 2, Burst: 3}}},
+		{name: "wrong rps", budget: entities.RateBudget{Burst: 1}, wantErr: true},
+		{name: "wrong burst", budget: entities.RateBudget{RPS: 1}, wantErr: true},
+		{name: "wrong route budget", budget: entities.RateBudget{RPS: 1, Burst: 1}, routes: map[string]entities.RateBudget{"/rates/last": {RPS: 1}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			limiter, err := cases.NewClientLimiter(tt.budget, tt.routes)
+			if tt.wantErr {
+				require.Nil(t, limiter)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, limiter)
+		})
+	}
+}
+
+func TestClientLimiter_Allow(t *testing.T) {
+	t.Parallel()
+	limiter, err := cases.NewClientLimiter(entities.RateBudget{RPS: 1, Burst: 2},
+		map[string]entities.RateBudget{"/api/v1/rates/query": {RPS: 0.5, Burst: 1}})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, ok := limiter.Allow("10.0.0.1", "/rates/last")
+		require.True(t, ok)
+	}
+
+	retryAfter, ok := limiter.Allow("10.0.0.1", "/rates/last")
+	require.False(t, ok)
+	require.Greater(t, retryAfter, time.Duration(0))
+	require.LessOrEqual(t, retryAfter, time.Second)
+
+	// корзины раздельные для клиентов и маршрутов, у маршрута из routes - свой бюджет
+	_, ok = limiter.Allow("10.0.0.2", "/rates/last")
+	require.True(t, ok)
+
+	_, ok = limiter.Allow("10.0.0.1", "/api/v1/rates/query")
+	require.True(t, ok)
+	retryAfter, ok = limiter.Allow("10.0.0.1", "/api/v1/rates/query")
+	require.False(t, ok)
+	require.Greater(t, retryAfter, time.Second)
+}
+
+func TestClientLimiter_SetBudgets(t *testing.T) {
+	t.Parallel()
+	limiter, err := cases.NewClientLimiter(entities.RateBudget{RPS: 0.001, Burst: 1}, nil)
+	require.NoError(t, err)
+
+	_, ok := limiter.Allow("10.0.0.1", "/rates/last")
+	require.True(t, ok)
+	_, ok = limiter.Allow("10.0.0.1", "/rates/last")
+	require.False(t, ok)
+
+	require.ErrorIs(t, limiter.SetBudgets(entities.RateBudget{}, nil), entities.ErrInvalidParam)
+
+	require.NoError(t, limiter.SetBudgets(entities.RateBudget{RPS: 0.001, Burst: 3}, nil))
+	for i := 0; i < 3; i++ {
+		_, ok = limiter.Allow("10.0.0.1", "/rates/last")
+		require.True(t, ok)
+	}
+	_, ok = limiter.Allow("10.0.0.1", "/rates/last")
+	require.False(t, ok)
+
+	// те же бюджеты: корзины сохраняются
+	require.NoError(t, limiter.SetBudgets(entities.RateBudget{RPS: 0.001, Burst: 3}, nil))
+	_, ok = limiter.Allow("10.0.0.1", "/rates/last")
+	require.False(t, ok)
+
+	// изменился бюджет другого маршрута: корзина /rates/last тоже сохраняется
+	routes := map[string]entities.RateBudget{"/rates/query": {RPS: 0.001, Burst: 1}}
+	require.NoError(t, limiter.SetBudgets(entities.RateBudget{RPS: 0.001, Burst: 3}, routes))
+	_, ok = limiter.Allow("10.0.0.1", "/rates/last")
+	require.False(t, ok)
+}
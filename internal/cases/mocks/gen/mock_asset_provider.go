@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./asset_provider.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./asset_provider.go -destination=./mocks/gen/mock_asset_provider.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	entities "kursy-kriptovalyut/internal/entities"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAssetProvider is a mock of AssetProvider interface.
+type MockAssetProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssetProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockAssetProviderMockRecorder is the mock recorder for MockAssetProvider.
+type MockAssetProviderMockRecorder struct {
+	mock *MockAssetProvider
+}
+
+// NewMockAssetProvider creates a new mock instance.
+func NewMockAssetProvider(ctrl *gomock.Controller) *MockAssetProvider {
+	mock := &MockAssetProvider{ctrl: ctrl}
+	mock.recorder = &MockAssetProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssetProvider) EXPECT() *MockAssetProviderMockRecorder {
+	return m.recorder
+}
+
+// GetAssets mocks base method.
+func (m *MockAssetProvider) GetAssets(ctx context.Context) ([]entities.Asset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssets", ctx)
+	ret0, _ := ret[0].([]entities.Asset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssets indicates an expected call of GetAssets.
+func (mr *MockAssetProviderMockRecorder) GetAssets(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssets", reflect.TypeOf((*MockAssetProvider)(nil).GetAssets), ctx)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./asset_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./asset_storage.go -destination=./mocks/gen/mock_asset_storage.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	entities "kursy-kriptovalyut/internal/entities"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAssetStorage is a mock of AssetStorage interface.
+type MockAssetStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssetStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockAssetStorageMockRecorder is the mock recorder for MockAssetStorage.
+type MockAssetStorageMockRecorder struct {
+	mock *MockAssetStorage
+}
+
+// NewMockAssetStorage creates a new mock instance.
+func NewMockAssetStorage(ctrl *gomock.Controller) *MockAssetStorage {
+	mock := &MockAssetStorage{ctrl: ctrl}
+	mock.recorder = &MockAssetStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssetStorage) EXPECT() *MockAssetStorageMockRecorder {
+	return m.recorder
+}
+
+// ListAssetSymbols mocks base method.
+func (m *MockAssetStorage) ListAssetSymbols(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAssetSymbols", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAssetSymbols indicates an expected call of ListAssetSymbols.
+func (mr *MockAssetStorageMockRecorder) ListAssetSymbols(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAssetSymbols", reflect.TypeOf((*MockAssetStorage)(nil).ListAssetSymbols), ctx)
+}
+
+// SaveAssets mocks base method.
+func (m *MockAssetStorage) SaveAssets(ctx context.Context, assets []entities.Asset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAssets", ctx, assets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAssets indicates an expected call of SaveAssets.
+func (mr *MockAssetStorageMockRecorder) SaveAssets(ctx, assets any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAssets", reflect.TypeOf((*MockAssetStorage)(nil).SaveAssets), ctx, assets)
+}
+
+// SearchAssets mocks base method.
+func (m *MockAssetStorage) SearchAssets(ctx context.Context, search string, limit int) ([]entities.Asset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchAssets", ctx, search, limit)
+	ret0, _ := ret[0].([]entities.Asset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchAssets indicates an expected call of SearchAssets.
+func (mr *MockAssetStorageMockRecorder) SearchAssets(ctx, search, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchAssets", reflect.TypeOf((*MockAssetStorage)(nil).SearchAssets), ctx, search, limit)
+}
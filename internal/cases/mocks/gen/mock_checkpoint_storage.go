@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./checkpoint_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./checkpoint_storage.go -destination=./mocks/gen/mock_checkpoint_storage.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	entities "kursy-kriptovalyut/internal/entities"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCheckpointStorage is a mock of CheckpointStorage interface.
+type MockCheckpointStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockCheckpointStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockCheckpointStorageMockRecorder is the mock recorder for MockCheckpointStorage.
+type MockCheckpointStorageMockRecorder struct {
+	mock *MockCheckpointStorage
+}
+
+// NewMockCheckpointStorage creates a new mock instance.
+func NewMockCheckpointStorage(ctrl *gomock.Controller) *MockCheckpointStorage {
+	mock := &MockCheckpointStorage{ctrl: ctrl}
+	mock.recorder = &MockCheckpointStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCheckpointStorage) EXPECT() *MockCheckpointStorageMockRecorder {
+	return m.recorder
+}
+
+// GetCheckpoint mocks base method.
+func (m *MockCheckpointStorage) GetCheckpoint(ctx context.Context, title string, interval entities.Interval) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCheckpoint", ctx, title, interval)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCheckpoint indicates an expected call of GetCheckpoint.
+func (mr *MockCheckpointStorageMockRecorder) GetCheckpoint(ctx, title, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCheckpoint", reflect.TypeOf((*MockCheckpointStorage)(nil).GetCheckpoint), ctx, title, interval)
+}
+
+// SaveCheckpoint mocks base method.
+func (m *MockCheckpointStorage) SaveCheckpoint(ctx context.Context, title string, interval entities.Interval, ts time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCheckpoint", ctx, title, interval, ts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCheckpoint indicates an expected call of SaveCheckpoint.
+func (mr *MockCheckpointStorageMockRecorder) SaveCheckpoint(ctx, title, interval, ts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCheckpoint", reflect.TypeOf((*MockCheckpointStorage)(nil).SaveCheckpoint), ctx, title, interval, ts)
+}
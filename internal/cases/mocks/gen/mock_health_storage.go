@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./health_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./health_storage.go -destination=./mocks/gen/mock_health_storage.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	entities "kursy-kriptovalyut/internal/entities"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHealthStorage is a mock of HealthStorage interface.
+type MockHealthStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockHealthStorageMockRecorder is the mock recorder for MockHealthStorage.
+type MockHealthStorageMockRecorder struct {
+	mock *MockHealthStorage
+}
+
+// NewMockHealthStorage creates a new mock instance.
+func NewMockHealthStorage(ctrl *gomock.Controller) *MockHealthStorage {
+	mock := &MockHealthStorage{ctrl: ctrl}
+	mock.recorder = &MockHealthStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthStorage) EXPECT() *MockHealthStorageMockRecorder {
+	return m.recorder
+}
+
+// GetActualCoins mocks base method.
+func (m *MockHealthStorage) GetActualCoins(ctx context.Context, titles []string) ([]entities.Coin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActualCoins", ctx, titles)
+	ret0, _ := ret[0].([]entities.Coin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActualCoins indicates an expected call of GetActualCoins.
+func (mr *MockHealthStorageMockRecorder) GetActualCoins(ctx, titles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActualCoins", reflect.TypeOf((*MockHealthStorage)(nil).GetActualCoins), ctx, titles)
+}
+
+// GetCoinsList mocks base method.
+func (m *MockHealthStorage) GetCoinsList(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoinsList", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoinsList indicates an expected call of GetCoinsList.
+func (mr *MockHealthStorageMockRecorder) GetCoinsList(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoinsList", reflect.TypeOf((*MockHealthStorage)(nil).GetCoinsList), ctx)
+}
+
+// LastUpdate mocks base method.
+func (m *MockHealthStorage) LastUpdate(ctx context.Context) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastUpdate", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastUpdate indicates an expected call of LastUpdate.
+func (mr *MockHealthStorageMockRecorder) LastUpdate(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastUpdate", reflect.TypeOf((*MockHealthStorage)(nil).LastUpdate), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockHealthStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockHealthStorageMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockHealthStorage)(nil).Ping), ctx)
+}
+
+// SchemaVersion mocks base method.
+func (m *MockHealthStorage) SchemaVersion(ctx context.Context) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchemaVersion", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SchemaVersion indicates an expected call of SchemaVersion.
+func (mr *MockHealthStorageMockRecorder) SchemaVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaVersion", reflect.TypeOf((*MockHealthStorage)(nil).SchemaVersion), ctx)
+}
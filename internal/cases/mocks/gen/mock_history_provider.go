@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./history_provider.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./history_provider.go -destination=./mocks/gen/mock_history_provider.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	entities "kursy-kriptovalyut/internal/entities"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHistoryProvider is a mock of HistoryProvider interface.
+type MockHistoryProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockHistoryProviderMockRecorder is the mock recorder for MockHistoryProvider.
+type MockHistoryProviderMockRecorder struct {
+	mock *MockHistoryProvider
+}
+
+// NewMockHistoryProvider creates a new mock instance.
+func NewMockHistoryProvider(ctrl *gomock.Controller) *MockHistoryProvider {
+	mock := &MockHistoryProvider{ctrl: ctrl}
+	mock.recorder = &MockHistoryProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryProvider) EXPECT() *MockHistoryProviderMockRecorder {
+	return m.recorder
+}
+
+// GetHistory mocks base method.
+func (m *MockHistoryProvider) GetHistory(ctx context.Context, title string, interval entities.Interval, to time.Time, limit int) ([]entities.Coin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, title, interval, to, limit)
+	ret0, _ := ret[0].([]entities.Coin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockHistoryProviderMockRecorder) GetHistory(ctx, title, interval, to, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockHistoryProvider)(nil).GetHistory), ctx, title, interval, to, limit)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./key_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./key_storage.go -destination=./mocks/gen/mock_key_storage.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	entities "kursy-kriptovalyut/internal/entities"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockKeyStorage is a mock of KeyStorage interface.
+type MockKeyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockKeyStorageMockRecorder is the mock recorder for MockKeyStorage.
+type MockKeyStorageMockRecorder struct {
+	mock *MockKeyStorage
+}
+
+// NewMockKeyStorage creates a new mock instance.
+func NewMockKeyStorage(ctrl *gomock.Controller) *MockKeyStorage {
+	mock := &MockKeyStorage{ctrl: ctrl}
+	mock.recorder = &MockKeyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyStorage) EXPECT() *MockKeyStorageMockRecorder {
+	return m.recorder
+}
+
+// AddUsage mocks base method.
+func (m *MockKeyStorage) AddUsage(ctx context.Context, usage []entities.KeyUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUsage", ctx, usage)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUsage indicates an expected call of AddUsage.
+func (mr *MockKeyStorageMockRecorder) AddUsage(ctx, usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsage", reflect.TypeOf((*MockKeyStorage)(nil).AddUsage), ctx, usage)
+}
+
+// CreateKey mocks base method.
+func (m *MockKeyStorage) CreateKey(ctx context.Context, key entities.APIKey) (entities.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKey", ctx, key)
+	ret0, _ := ret[0].(entities.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKey indicates an expected call of CreateKey.
+func (mr *MockKeyStorageMockRecorder) CreateKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockKeyStorage)(nil).CreateKey), ctx, key)
+}
+
+// GetKeyByHash mocks base method.
+func (m *MockKeyStorage) GetKeyByHash(ctx context.Context, hash string) (entities.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(entities.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyByHash indicates an expected call of GetKeyByHash.
+func (mr *MockKeyStorageMockRecorder) GetKeyByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyByHash", reflect.TypeOf((*MockKeyStorage)(nil).GetKeyByHash), ctx, hash)
+}
+
+// GetUsage mocks base method.
+func (m *MockKeyStorage) GetUsage(ctx context.Context, id int64, from, to time.Time) ([]entities.KeyUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, id, from, to)
+	ret0, _ := ret[0].([]entities.KeyUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockKeyStorageMockRecorder) GetUsage(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockKeyStorage)(nil).GetUsage), ctx, id, from, to)
+}
+
+// ListKeys mocks base method.
+func (m *MockKeyStorage) ListKeys(ctx context.Context) ([]entities.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys", ctx)
+	ret0, _ := ret[0].([]entities.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockKeyStorageMockRecorder) ListKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockKeyStorage)(nil).ListKeys), ctx)
+}
+
+// RevokeKey mocks base method.
+func (m *MockKeyStorage) RevokeKey(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockKeyStorageMockRecorder) RevokeKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockKeyStorage)(nil).RevokeKey), ctx, id)
+}
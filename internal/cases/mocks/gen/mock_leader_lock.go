@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./leader_lock.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./leader_lock.go -destination=./mocks/gen/mock_leader_lock.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLeaderLock is a mock of LeaderLock interface.
+type MockLeaderLock struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaderLockMockRecorder
+	isgomock struct{}
+}
+
+// MockLeaderLockMockRecorder is the mock recorder for MockLeaderLock.
+type MockLeaderLockMockRecorder struct {
+	mock *MockLeaderLock
+}
+
+// NewMockLeaderLock creates a new mock instance.
+func NewMockLeaderLock(ctrl *gomock.Controller) *MockLeaderLock {
+	mock := &MockLeaderLock{ctrl: ctrl}
+	mock.recorder = &MockLeaderLockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaderLock) EXPECT() *MockLeaderLockMockRecorder {
+	return m.recorder
+}
+
+// TryLock mocks base method.
+func (m *MockLeaderLock) TryLock(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLock", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryLock indicates an expected call of TryLock.
+func (mr *MockLeaderLockMockRecorder) TryLock(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLock", reflect.TypeOf((*MockLeaderLock)(nil).TryLock), ctx)
+}
+
+// Unlock mocks base method.
+func (m *MockLeaderLock) Unlock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockLeaderLockMockRecorder) Unlock(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockLeaderLock)(nil).Unlock), ctx)
+}
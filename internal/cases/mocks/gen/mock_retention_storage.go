@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./retention_storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./retention_storage.go -destination=./mocks/gen/mock_retention_storage.go
+//
+
+// Package mock_cases is a generated GoMock package.
+package mock_cases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRetentionStorage is a mock of RetentionStorage interface.
+type MockRetentionStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockRetentionStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockRetentionStorageMockRecorder is the mock recorder for MockRetentionStorage.
+type MockRetentionStorageMockRecorder struct {
+	mock *MockRetentionStorage
+}
+
+// NewMockRetentionStorage creates a new mock instance.
+func NewMockRetentionStorage(ctrl *gomock.Controller) *MockRetentionStorage {
+	mock := &MockRetentionStorage{ctrl: ctrl}
+	mock.recorder = &MockRetentionStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRetentionStorage) EXPECT() *MockRetentionStorageMockRecorder {
+	return m.recorder
+}
+
+// Compact mocks base method.
+func (m *MockRetentionStorage) Compact(ctx context.Context, rawBefore, hourlyBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compact", ctx, rawBefore, hourlyBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compact indicates an expected call of Compact.
+func (mr *MockRetentionStorageMockRecorder) Compact(ctx, rawBefore, hourlyBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compact", reflect.TypeOf((*MockRetentionStorage)(nil).Compact), ctx, rawBefore, hourlyBefore)
+}
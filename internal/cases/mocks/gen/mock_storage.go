@@ -13,6 +13,7 @@ import (
 	context "context"
 	entities "kursy-kriptovalyut/internal/entities"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -71,6 +72,36 @@ func (mr *MockStorageMockRecorder) GetAggregateCoins(ctx, titles, aggFuncName an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAggregateCoins", reflect.TypeOf((*MockStorage)(nil).GetAggregateCoins), ctx, titles, aggFuncName)
 }
 
+// GetAggregateCoinsSince mocks base method.
+func (m *MockStorage) GetAggregateCoinsSince(ctx context.Context, titles []string, aggFuncName string, since time.Time) ([]entities.Coin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAggregateCoinsSince", ctx, titles, aggFuncName, since)
+	ret0, _ := ret[0].([]entities.Coin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAggregateCoinsSince indicates an expected call of GetAggregateCoinsSince.
+func (mr *MockStorageMockRecorder) GetAggregateCoinsSince(ctx, titles, aggFuncName, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAggregateCoinsSince", reflect.TypeOf((*MockStorage)(nil).GetAggregateCoinsSince), ctx, titles, aggFuncName, since)
+}
+
+// GetCandles mocks base method.
+func (m *MockStorage) GetCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCandles", ctx, title, interval, from, to)
+	ret0, _ := ret[0].([]entities.Candle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCandles indicates an expected call of GetCandles.
+func (mr *MockStorageMockRecorder) GetCandles(ctx, title, interval, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCandles", reflect.TypeOf((*MockStorage)(nil).GetCandles), ctx, title, interval, from, to)
+}
+
 // GetCoinsList mocks base method.
 func (m *MockStorage) GetCoinsList(ctx context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -86,6 +117,21 @@ func (mr *MockStorageMockRecorder) GetCoinsList(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoinsList", reflect.TypeOf((*MockStorage)(nil).GetCoinsList), ctx)
 }
 
+// GetHistory mocks base method.
+func (m *MockStorage) GetHistory(ctx context.Context, title string, from, to time.Time) ([]entities.Coin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, title, from, to)
+	ret0, _ := ret[0].([]entities.Coin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockStorageMockRecorder) GetHistory(ctx, title, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockStorage)(nil).GetHistory), ctx, title, from, to)
+}
+
 // Store mocks base method.
 func (m *MockStorage) Store(ctx context.Context, coins []entities.Coin) error {
 	m.ctrl.T.Helper()
@@ -99,3 +145,31 @@ func (mr *MockStorageMockRecorder) Store(ctx, coins any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockStorage)(nil).Store), ctx, coins)
 }
+
+// StreamCandles mocks base method.
+func (m *MockStorage) StreamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamCandles", ctx, title, interval, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamCandles indicates an expected call of StreamCandles.
+func (mr *MockStorageMockRecorder) StreamCandles(ctx, title, interval, from, to, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamCandles", reflect.TypeOf((*MockStorage)(nil).StreamCandles), ctx, title, interval, from, to, fn)
+}
+
+// StreamHistory mocks base method.
+func (m *MockStorage) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistory", ctx, title, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistory indicates an expected call of StreamHistory.
+func (mr *MockStorageMockRecorder) StreamHistory(ctx, title, from, to, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistory", reflect.TypeOf((*MockStorage)(nil).StreamHistory), ctx, title, from, to, fn)
+}
@@ -0,0 +1,12 @@
+package cases
+
+import (
+	"kursy-kriptovalyut/internal/entities"
+)
+
+//go:generate mockgen -source=./publisher.go -destination=./mocks/gen/mock_publisher.go
+type Publisher interface {
+	Publish(coins []entities.Coin)
+}
+
+// Publish - для рассылки подписчикам цен, только что записанных в хранилище
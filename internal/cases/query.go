@@ -0,0 +1,292 @@
+package cases
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/tracing"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	MaxQueryItems = 100
+	// окно агрегации ограничено сроком хранения сырых данных по умолчанию
+	MaxQueryWindow = 7 * 24 * time.Hour
+)
+
+type aggGroup struct {
+	aggFunc string
+	window  time.Duration
+}
+
+// QueryRates отвечает на пакетный запрос курсов. Последние цены читаются из хранилища одним запросом, агрегаты -
+// по группам (агрегат, окно). К провайдеру уходит не больше двух запросов: за монетами, которых нет в хранилище,
+// и за устаревшими ценами; если обновить устаревшую цену не удалось, возвращается цена из хранилища с пометкой Stale.
+// Ошибки отдельных элементов возвращаются в их результатах.
+func (s *Service) QueryRates(ctx context.Context, queries []entities.RateQuery) ([]entities.RateResult, error) {
+	ctx, span := tracer.Start(ctx, "Service.QueryRates")
+	results, err := s.queryRates(ctx, queries)
+	tracing.End(span, err)
+
+	return results, err
+}
+
+func (s *Service) queryRates(ctx context.Context, queries []entities.RateQuery) ([]entities.RateResult, error) {
+	log := logger.FromContext(ctx)
+	if len(queries) == 0 || len(queries) > MaxQueryItems {
+		err := errors.Wrapf(entities.ErrInvalidParam, "query must contain from 1 to %d items", MaxQueryItems)
+		log.Error("(service.QueryRates) wrong number of items", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	results := make([]entities.RateResult, len(queries))
+	for i, q := range queries {
+		results[i].Query, results[i].Err = normalizeRateQuery(q, s.quote)
+	}
+
+	existingTitles, err := s.storage.GetCoinsList(ctx)
+	if err != nil {
+		log.Error("(service.QueryRates) failed to get list of coin titles", zap.Any("err", err.Error()))
+		return nil, errors.Wrap(err, "failed to get list of coin titles")
+	}
+	tracked := make(map[string]bool, len(existingTitles))
+	for _, title := range existingTitles {
+		tracked[title] = true
+	}
+
+	// монеты не из хранилища и не из каталога к провайдеру не запрашиваются
+	for i := range results {
+		res := &results[i]
+		if res.Err == nil && !tracked[res.Query.Title] {
+			res.Err = s.rejectUnknown([]string{res.Query.Title})
+		}
+	}
+
+	// последние цены из хранилища
+	lastTitles := uniqueTitles(results, func(q entities.RateQuery) bool {
+		return q.Mode == entities.ModeLast && tracked[q.Title]
+	})
+	stored := make(map[string]entities.Coin, len(lastTitles))
+	var storedErr error
+	if len(lastTitles) > 0 {
+		coins, err := s.storage.GetActualCoins(ctx, lastTitles)
+		if err != nil {
+			log.Error("(service.QueryRates) failed to get coin data from storage", zap.Any("err", err.Error()))
+			storedErr = errors.Wrap(err, "failed to get coin data from storage")
+		}
+		for _, coin := range coins {
+			stored[coin.Title] = coin
+		}
+	}
+
+	// монеты, за которыми нужно сходить к провайдеру
+	now := time.Now()
+	isStale := func(q entities.RateQuery) bool {
+		if !tracked[q.Title] || q.Mode != entities.ModeLast || storedErr != nil {
+			return false
+		}
+
+		coin, ok := stored[q.Title]
+		return !ok || q.MaxAge > 0 && now.Sub(coin.CreatedAt) > q.MaxAge
+	}
+	isUnknown := func(q entities.RateQuery) bool {
+		return !tracked[q.Title]
+	}
+	refreshed := make([]bool, len(results))
+	for i, res := range results {
+		refreshed[i] = res.Err == nil && (isUnknown(res.Query) || isStale(res.Query))
+	}
+
+	// неизвестные и устаревшие монеты запрашиваются отдельно: ошибка провайдера по неизвестной
+	// монете не должна лишать ответа монеты, для которых в хранилище есть цена
+	unknownTitles := uniqueTitles(results, isUnknown)
+	staleTitles := uniqueTitles(results, isStale)
+	fresh := make(map[string]entities.Coin, len(unknownTitles)+len(staleTitles))
+	refreshErrs := make(map[string]error)
+	for _, titles := range [][]string{unknownTitles, staleTitles} {
+		if len(titles) == 0 {
+			continue
+		}
+
+		log.Info("(service.QueryRates) refreshing rates from provider", zap.Any("titles", titles))
+		coins, err := s.refreshRates(ctx, titles)
+		if err != nil {
+			log.Error("(service.QueryRates) error from (refreshRates)", zap.Any("err", err.Error()))
+			for _, title := range titles {
+				refreshErrs[title] = err
+			}
+		}
+		for _, coin := range coins {
+			fresh[coin.Title] = coin
+			tracked[coin.Title] = true
+		}
+	}
+
+	// агрегаты считаются по хранилищу, куда уже записаны свежие цены
+	groups := make(map[aggGroup][]string)
+	grouped := make(map[aggGroup]map[string]bool)
+	for _, res := range results {
+		q := res.Query
+		if res.Err != nil || q.Mode == entities.ModeLast || !tracked[q.Title] {
+			continue
+		}
+
+		group := aggGroup{aggFunc: q.Mode, window: q.Window}
+		if grouped[group] == nil {
+			grouped[group] = make(map[string]bool)
+		}
+		if !grouped[group][q.Title] {
+			grouped[group][q.Title] = true
+			groups[group] = append(groups[group], q.Title)
+		}
+	}
+	aggregated := make(map[aggGroup]map[string]entities.Coin, len(groups))
+	aggErrs := make(map[aggGroup]error)
+	for group, titles := range groups {
+		coins, err := s.aggregate(ctx, titles, group, now)
+		if err != nil {
+			log.Error("(service.QueryRates) failed to get aggregated coin data from storage", zap.Any("err", err.Error()))
+			aggErrs[group] = errors.Wrap(err, "failed to get aggregated coin data from storage")
+			continue
+		}
+
+		aggregated[group] = make(map[string]entities.Coin, len(coins))
+		for _, coin := range coins {
+			aggregated[group][coin.Title] = coin
+		}
+	}
+
+	for i := range results {
+		res := &results[i]
+		if res.Err != nil {
+			continue
+		}
+		q := res.Query
+
+		if err := refreshErrs[q.Title]; refreshed[i] && err != nil {
+			// устаревшая цена полезнее ошибки: клиент видит её возраст и пометку
+			if coin, ok := stored[q.Title]; ok {
+				res.Coin = coin
+				res.Stale = true
+				continue
+			}
+
+			res.Err = err
+			if s.readOnly && !tracked[q.Title] {
+				res.Err = errors.Wrapf(entities.ErrUnknownSymbol, "coin %v not in storage", q.Title)
+			}
+			continue
+		}
+
+		if q.Mode == entities.ModeLast {
+			coin, ok := fresh[q.Title]
+			if !ok {
+				coin, ok = stored[q.Title]
+			}
+			switch {
+			case ok:
+				res.Coin = coin
+			case storedErr != nil:
+				res.Err = storedErr
+			default:
+				res.Err = errors.Wrapf(entities.ErrUnknownSymbol, "coin %v is not available", q.Title)
+			}
+			continue
+		}
+
+		group := aggGroup{aggFunc: q.Mode, window: q.Window}
+		if err := aggErrs[group]; err != nil {
+			res.Err = err
+			continue
+		}
+
+		coin, ok := aggregated[group][q.Title]
+		if !tracked[q.Title] {
+			res.Err = errors.Wrapf(entities.ErrUnknownSymbol, "coin %v is not available", q.Title)
+			continue
+		}
+		if !ok {
+			res.Err = errors.Wrapf(entities.ErrNotFound, "no %v prices in the requested window", q.Title)
+			continue
+		}
+		res.Coin = coin
+	}
+
+	return results, nil
+}
+
+// refreshRates получает и сохраняет текущие цены монет; в режиме только для чтения провайдер недоступен
+func (s *Service) refreshRates(ctx context.Context, titles []string) ([]entities.Coin, error) {
+	if s.readOnly {
+		return nil, errors.Wrap(entities.ErrReadOnly, "no price within max age in storage and provider fallback is disabled")
+	}
+
+	return s.handleMissingTitles(ctx, titles, "PRICE")
+}
+
+func (s *Service) aggregate(ctx context.Context, titles []string, group aggGroup, now time.Time) ([]entities.Coin, error) {
+	if group.window == 0 {
+		return s.storage.GetAggregateCoins(ctx, titles, group.aggFunc)
+	}
+
+	return s.storage.GetAggregateCoinsSince(ctx, titles, group.aggFunc, now.Add(-group.window))
+}
+
+func normalizeRateQuery(q entities.RateQuery, quote string) (entities.RateQuery, error) {
+	if strings.TrimSpace(q.Title) == "" {
+		return q, errors.Wrap(entities.ErrInvalidSymbol, "missing 'title'")
+	}
+
+	title, err := normalizeTitle(q.Title)
+	if err != nil {
+		return q, err
+	}
+	q.Title = title
+
+	q.Quote = strings.ToUpper(strings.TrimSpace(q.Quote))
+	if q.Quote == "" {
+		q.Quote = quote
+	}
+	if q.Quote != quote {
+		return q, errors.Wrapf(entities.ErrInvalidParam, "unsupported quote %v, only %v is available", q.Quote, quote)
+	}
+
+	q.Mode = strings.ToUpper(strings.TrimSpace(q.Mode))
+	if q.Mode == "" {
+		q.Mode = entities.ModeLast
+	}
+	if q.Mode != entities.ModeLast && !validAggFuncs[q.Mode] {
+		return q, errors.Wrapf(entities.ErrUnknownAggregate, "unknown mode %v, expected LAST, MAX, MIN or AVG", q.Mode)
+	}
+
+	if q.Window < 0 || q.Window > MaxQueryWindow {
+		return q, errors.Wrapf(entities.ErrInvalidRange, "window must be between 0 and %v", MaxQueryWindow)
+	}
+
+	if q.MaxAge < 0 {
+		return q, errors.Wrap(entities.ErrInvalidParam, "max age must not be negative")
+	}
+
+	return q, nil
+}
+
+// uniqueTitles возвращает монеты корректных элементов, подходящих под условие, в порядке первого появления
+func uniqueTitles(results []entities.RateResult, match func(q entities.RateQuery) bool) []string {
+	seen := make(map[string]bool)
+	titles := make([]string, 0)
+	for _, res := range results {
+		if res.Err != nil || seen[res.Query.Title] || !match(res.Query) {
+			continue
+		}
+
+		seen[res.Query.Title] = true
+		titles = append(titles, res.Query.Title)
+	}
+
+	return titles
+}
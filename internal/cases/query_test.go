@@ -0,0 +1,239 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestQueryRates_GroupsCalls(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	unknown := []entities.Coin{{Title: "DOGE", Price: 1, CreatedAt: now}}
+	fresh := []entities.Coin{{Title: "ETH", Price: 11, CreatedAt: now}}
+
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC", "ETH"}, nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC", "ETH"}).Return([]entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: now},
+		{Title: "ETH", Price: 10, CreatedAt: now.Add(-time.Hour)},
+	}, nil)
+	// к провайдеру - отдельные запросы за неизвестной DOGE и устаревшей ETH
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"DOGE"}, "PRICE").Return(unknown, nil)
+	storage.EXPECT().Store(gomock.Any(), unknown).Return(nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return(fresh, nil)
+	storage.EXPECT().Store(gomock.Any(), fresh).Return(nil)
+	storage.EXPECT().GetAggregateCoins(gomock.Any(), []string{"BTC", "ETH"}, "MAX").Return([]entities.Coin{
+		{Title: "BTC", Price: 110},
+		{Title: "ETH", Price: 12},
+	}, nil)
+	storage.EXPECT().GetAggregateCoinsSince(gomock.Any(), []string{"BTC"}, "AVG", gomock.Any()).Return([]entities.Coin{{Title: "BTC", Price: 105}}, nil)
+
+	results, err := srv.QueryRates(ctx, []entities.RateQuery{
+		{Title: "btc"},
+		{Title: "ETH", Mode: "last", MaxAge: time.Minute},
+		{Title: "BTC", Mode: "max"},
+		{Title: "ETH", Mode: "MAX"},
+		{Title: "BTC", Mode: "AVG", Window: time.Hour},
+		{Title: "DOGE"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+
+	prices := []float64{100, 11, 110, 12, 105, 1}
+	for i, res := range results {
+		require.NoError(t, res.Err)
+		require.False(t, res.Stale)
+		require.Equal(t, prices[i], res.Coin.Price)
+		require.Equal(t, entities.DefaultQuote, res.Query.Quote)
+	}
+	require.Equal(t, "BTC", results[0].Query.Title)
+	require.Equal(t, entities.ModeLast, results[0].Query.Mode)
+}
+
+func TestQueryRates_InvalidItems(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+
+	results, err := srv.QueryRates(ctx, []entities.RateQuery{
+		{Title: " "},
+		{Title: "BTC", Quote: "EUR"},
+		{Title: "BTC", Mode: "MEDIAN"},
+		{Title: "BTC", Mode: "MAX", Window: 30 * 24 * time.Hour},
+		{Title: "BTC", MaxAge: -time.Second},
+	})
+	require.NoError(t, err)
+
+	resErrs := []error{
+		entities.ErrInvalidSymbol,
+		entities.ErrInvalidParam,
+		entities.ErrUnknownAggregate,
+		entities.ErrInvalidRange,
+		entities.ErrInvalidParam,
+	}
+	for i, res := range results {
+		require.ErrorIs(t, res.Err, resErrs[i])
+	}
+}
+
+func TestQueryRates_WrongItemsCount(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), mock_cases.NewMockStorage(ctrl))
+	require.NoError(t, err)
+
+	_, err = srv.QueryRates(context.Background(), nil)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+
+	_, err = srv.QueryRates(context.Background(), make([]entities.RateQuery, cases.MaxQueryItems+1))
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func TestQueryRates_ProviderError(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC"}).Return([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now()}}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return(nil, errors.Wrap(entities.ErrProviderUnavailable, "timeout"))
+
+	results, err := srv.QueryRates(ctx, []entities.RateQuery{{Title: "BTC"}, {Title: "ETH"}, {Title: "ETH", Mode: "MIN"}})
+	require.NoError(t, err)
+
+	require.NoError(t, results[0].Err)
+	require.Equal(t, float64(100), results[0].Coin.Price)
+	require.ErrorIs(t, results[1].Err, entities.ErrProviderUnavailable)
+	require.ErrorIs(t, results[2].Err, entities.ErrProviderUnavailable)
+}
+
+func TestQueryRates_StaleFallback(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	old := time.Now().Add(-time.Hour)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC", "ETH"}, nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC", "ETH"}).Return([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: old}}, nil)
+	// неизвестная монета не мешает обновлению устаревших: запросы к провайдеру раздельные
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"NOPE"}, "PRICE").Return(nil, errors.Wrap(entities.ErrUnknownSymbol, "no such coin"))
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"BTC", "ETH"}, "PRICE").Return(nil, errors.Wrap(entities.ErrProviderUnavailable, "timeout"))
+
+	results, err := srv.QueryRates(ctx, []entities.RateQuery{
+		{Title: "BTC", MaxAge: time.Minute},
+		{Title: "ETH"},
+		{Title: "NOPE"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Stale)
+	require.Equal(t, float64(100), results[0].Coin.Price)
+	require.Equal(t, old, results[0].Coin.CreatedAt)
+	// в хранилище нет цены, отдавать нечего
+	require.ErrorIs(t, results[1].Err, entities.ErrProviderUnavailable)
+	require.ErrorIs(t, results[2].Err, entities.ErrUnknownSymbol)
+}
+
+func TestQueryRates_UnknownToCatalog(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+	assetStorage := mock_cases.NewMockAssetStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+	catalog, err := cases.NewReadOnlyAssetCatalog(assetStorage)
+	require.NoError(t, err)
+	srv.SetCatalog(catalog)
+
+	ctx := context.Background()
+	assetStorage.EXPECT().ListAssetSymbols(ctx).Return([]string{"BTC", "ETH"}, nil)
+	require.NoError(t, catalog.Refresh(ctx))
+
+	fresh := []entities.Coin{{Title: "ETH", Price: 10, CreatedAt: time.Now()}}
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	// к провайдеру уходит только монета из каталога
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return(fresh, nil)
+	storage.EXPECT().Store(gomock.Any(), fresh).Return(nil)
+
+	results, err := srv.QueryRates(ctx, []entities.RateQuery{{Title: "ETH"}, {Title: "NOPE"}})
+	require.NoError(t, err)
+
+	require.NoError(t, results[0].Err)
+	require.Equal(t, float64(10), results[0].Coin.Price)
+	require.ErrorIs(t, results[1].Err, entities.ErrUnknownSymbol)
+}
+
+func TestReadOnlyService_QueryRates(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewReadOnlyService(storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC"}).Return([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now().Add(-time.Hour)}}, nil)
+
+	results, err := srv.QueryRates(ctx, []entities.RateQuery{{Title: "BTC", MaxAge: time.Minute}, {Title: "BTC"}, {Title: "ETH"}})
+	require.NoError(t, err)
+
+	// без провайдера устаревшая цена отдаётся с пометкой
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Stale)
+	require.Equal(t, float64(100), results[0].Coin.Price)
+	require.NoError(t, results[1].Err)
+	require.False(t, results[1].Stale)
+	require.Equal(t, float64(100), results[1].Coin.Price)
+	require.ErrorIs(t, results[2].Err, entities.ErrUnknownSymbol)
+}
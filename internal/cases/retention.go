@@ -0,0 +1,54 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// Retention хранит сырые тики rawRetention, часовые свёртки - hourlyRetention,
+// после чего данные сворачиваются в следующее разрешение (дневные свёртки хранятся бессрочно).
+type Retention struct {
+	storage         RetentionStorage
+	rawRetention    time.Duration
+	hourlyRetention time.Duration
+}
+
+func NewRetention(storage RetentionStorage, rawRetention, hourlyRetention time.Duration) (*Retention, error) {
+	if storage == nil || storage == RetentionStorage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "retention storage not set")
+	}
+
+	if rawRetention <= 0 || hourlyRetention <= rawRetention {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "retention periods must be positive and hourly retention must exceed raw retention")
+	}
+
+	return &Retention{
+		storage:         storage,
+		rawRetention:    rawRetention,
+		hourlyRetention: hourlyRetention,
+	}, nil
+}
+
+func (r *Retention) Compact(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	// границы выравниваются по интервалу свёртки, чтобы в неё не попадали неполные часы и дни
+	now := time.Now().UTC()
+	rawBefore := now.Add(-r.rawRetention).Truncate(time.Hour)
+	hourlyBefore := now.Add(-r.hourlyRetention).Truncate(24 * time.Hour)
+
+	log.Info("(retention.Compact) compacting coins", zap.Any("rawBefore", rawBefore), zap.Any("hourlyBefore", hourlyBefore))
+	deleted, err := r.storage.Compact(ctx, rawBefore, hourlyBefore)
+	if err != nil {
+		log.Error("(retention.Compact) failed to compact coins", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to compact coins")
+	}
+
+	log.Info("(retention.Compact) compaction completed", zap.Any("deleted", deleted))
+	return nil
+}
@@ -0,0 +1,14 @@
+package cases
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate mockgen -source=./retention_storage.go -destination=./mocks/gen/mock_retention_storage.go
+type RetentionStorage interface {
+	Compact(ctx context.Context, rawBefore, hourlyBefore time.Time) (int64, error)
+}
+
+// Compact - сворачивает сырые записи старше rawBefore в часовые агрегаты, а часовые старше hourlyBefore - в дневные;
+// возвращает количество удалённых после свёртки строк (без учёта удалённых целиком секций)
@@ -0,0 +1,87 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestNewRetention(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tests := []struct {
+		name    string
+		storage cases.RetentionStorage
+		raw     time.Duration
+		hourly  time.Duration
+		wantErr bool
+	}{
+		{name: "valid input", storage: mock_cases.NewMockRetentionStorage(ctrl), raw: time.Hour, hourly: 24 * time.Hour},
+		{name: "storage not set", raw: time.Hour, hourly: 24 * time.Hour, wantErr: true},
+		{name: "wrong raw retention", storage: mock_cases.NewMockRetentionStorage(ctrl), hourly: time.Hour, wantErr: true},
+		{name: "hourly shorter than raw", storage: mock_cases.NewMockRetentionStorage(ctrl), raw: 24 * time.Hour, hourly: time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			retention, err := cases.NewRetention(tt.storage, tt.raw, tt.hourly)
+			if tt.wantErr {
+				require.Nil(t, retention)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, retention)
+		})
+	}
+}
+
+func TestRetention_Compact(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockRetentionStorage(ctrl)
+	retention, err := cases.NewRetention(storage, 7*24*time.Hour, 90*24*time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	storage.EXPECT().Compact(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, rawBefore, hourlyBefore time.Time) (int64, error) {
+			// границы выровнены по часу и по суткам
+			require.True(t, rawBefore.Equal(rawBefore.Truncate(time.Hour)))
+			require.True(t, hourlyBefore.Equal(hourlyBefore.Truncate(24*time.Hour)))
+			require.WithinDuration(t, time.Now().Add(-7*24*time.Hour), rawBefore, time.Hour)
+			require.WithinDuration(t, time.Now().Add(-90*24*time.Hour), hourlyBefore, 24*time.Hour)
+			return 10, nil
+		})
+
+	require.NoError(t, retention.Compact(ctx))
+}
+
+func TestRetention_CompactError(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockRetentionStorage(ctrl)
+	retention, err := cases.NewRetention(storage, time.Hour, 24*time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	storage.EXPECT().Compact(ctx, gomock.Any(), gomock.Any()).Return(int64(0), errors.New("Compact error"))
+
+	err = retention.Compact(ctx)
+	require.ErrorContains(t, err, "failed to compact coins")
+}
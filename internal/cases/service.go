@@ -3,18 +3,37 @@ package cases
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
 
 	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
+	"kursy-kriptovalyut/internal/tracing"
 	"kursy-kriptovalyut/pkg/logger"
 )
 
 var log = logger.NewLogger()
 
+// экспортируемые методы сервиса открывают span и передают его контекст реализации,
+// поэтому span'ы хранилища и провайдера вложены в span метода
+var tracer = otel.Tracer("kursy-kriptovalyut/internal/cases")
+
+var validAggFuncs = map[string]bool{"MAX": true, "MIN": true, "AVG": true}
+
 type Service struct {
-	provider CryptoProvider
-	storage  Storage
+	provider  CryptoProvider
+	storage   Storage
+	publisher Publisher
+	readOnly  bool
+	// бюджет вызовов провайдера на клиента API; nil - без ограничений
+	providerLimiter *ClientLimiter
+	// каталог известных монет; nil - монеты не проверяются до запроса к провайдеру
+	catalog *AssetCatalog
+	// валюта, в которой провайдер котирует монеты и в которой хранятся цены
+	quote string
 }
 
 func NewService(provider CryptoProvider, storage Storage) (*Service, error) {
@@ -29,25 +48,90 @@ func NewService(provider CryptoProvider, storage Storage) (*Service, error) {
 	return &Service{
 		provider: provider,
 		storage:  storage,
+		quote:    entities.DefaultQuote,
 	}, nil
 }
 
+// NewReadOnlyService создаёт сервис, который отдаёт данные только из хранилища
+// и никогда не обращается к провайдеру (для реплик, обслуживающих только чтение).
+func NewReadOnlyService(storage Storage) (*Service, error) {
+	if storage == nil || storage == Storage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "storage not set")
+	}
+
+	return &Service{
+		storage:  storage,
+		readOnly: true,
+		quote:    entities.DefaultQuote,
+	}, nil
+}
+
+// SetPublisher включает рассылку подписчикам цен, которые сервис записывает в хранилище
+func (s *Service) SetPublisher(publisher Publisher) {
+	s.publisher = publisher
+}
+
+// SetProviderLimiter ограничивает запросы клиентов, которые приводят к вызову провайдера:
+// каждая монета, которой нет в хранилище, стоит запроса к CryptoCompare
+func (s *Service) SetProviderLimiter(limiter *ClientLimiter) {
+	s.providerLimiter = limiter
+}
+
+// SetCatalog включает проверку по каталогу: монеты, которых нет среди активных монет провайдера,
+// отклоняются без запроса к нему
+func (s *Service) SetCatalog(catalog *AssetCatalog) {
+	s.catalog = catalog
+}
+
+// SetQuote задаёт валюту котировок; она должна совпадать с валютой, которую запрашивает провайдер
+func (s *Service) SetQuote(quote string) {
+	s.quote = quote
+}
+
+// Quote возвращает валюту, в которой сервис отдаёт цены
+func (s *Service) Quote() string {
+	return s.quote
+}
+
 func (s *Service) GetLastRates(ctx context.Context, requestedCoinTitles []string) ([]entities.Coin, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetLastRates")
+	coins, err := s.getLastRates(ctx, requestedCoinTitles)
+	tracing.End(span, err)
+
+	return coins, err
+}
+
+func (s *Service) getLastRates(ctx context.Context, requestedCoinTitles []string) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	requestedCoinTitles, err := normalizeTitles(requestedCoinTitles)
+	if err != nil {
+		log.Warn("(service.GetLastRates) invalid coin titles", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
 	// получаем список монет, которые уже есть в хранилище
 	existingTitles, err := s.storage.GetCoinsList(ctx)
 	if err != nil {
-		log.Error("(service.GetLastRates) failed to get list of coin titles")
+		log.Error("(service.GetLastRates) failed to get list of coin titles", zap.Any("err", err.Error()))
 		return nil, errors.Wrap(err, "failed to get list of coin titles")
 	}
 
+	_, split := tracer.Start(ctx, "splitRequestedTitles")
 	existingReqTitles, nonExistingReqTitles := splitRequestedTitles(requestedCoinTitles, existingTitles)
+	split.End()
+	metrics.ObserveCacheLookups(len(existingReqTitles), len(nonExistingReqTitles))
+
+	if err := s.rejectUnknown(nonExistingReqTitles); err != nil {
+		log.Warn("(service.GetLastRates) unknown coin titles", zap.Any("err", err.Error()))
+		return nil, err
+	}
 
 	// 1-случай: все монеты есть в хранилище
 	if len(nonExistingReqTitles) == 0 {
 		log.Info("(service.GetLastRates) all requested titles in storage")
 		coins, err := s.storage.GetActualCoins(ctx, requestedCoinTitles)
 		if err != nil {
-			log.Error("(service.GetLastRates) failed to get coin data from storage")
+			log.Error("(service.GetLastRates) failed to get coin data from storage", zap.Any("err", err.Error()))
 			return nil, errors.Wrap(err, "failed to get coin data from storage")
 		}
 
@@ -59,7 +143,7 @@ func (s *Service) GetLastRates(ctx context.Context, requestedCoinTitles []string
 		log.Info("(service.GetLastRates) all requested titles not in storage")
 		newCoins, err := s.handleMissingTitles(ctx, nonExistingReqTitles, "PRICE")
 		if err != nil {
-			log.Error("(service.GetLastRates) error from (handleMissingTitles)")
+			log.Error("(service.GetLastRates) error from (handleMissingTitles)", zap.Any("err", err.Error()))
 			return nil, err
 		}
 
@@ -70,13 +154,13 @@ func (s *Service) GetLastRates(ctx context.Context, requestedCoinTitles []string
 	log.Info("(service.GetLastRates) requested titles partially in storage")
 	newCoins, err := s.handleMissingTitles(ctx, nonExistingReqTitles, "PRICE")
 	if err != nil {
-		log.Error("(service.GetLastRates) error from (handleMissingTitles)")
+		log.Error("(service.GetLastRates) error from (handleMissingTitles)", zap.Any("err", err.Error()))
 		return nil, err
 	}
 
 	coins, err := s.storage.GetActualCoins(ctx, existingReqTitles)
 	if err != nil {
-		log.Error("(service.GetLastRates) failed to get coin data from storage")
+		log.Error("(service.GetLastRates) failed to get coin data from storage", zap.Any("err", err.Error()))
 		return nil, errors.Wrap(err, "failed to get coin data from storage")
 	}
 
@@ -84,27 +168,49 @@ func (s *Service) GetLastRates(ctx context.Context, requestedCoinTitles []string
 }
 
 func (s *Service) GetAggRates(ctx context.Context, requestedCoinTitles []string, aggFuncName string) ([]entities.Coin, error) {
-	validAggFuncs := map[string]bool{"MAX": true, "MIN": true, "AVG": true}
+	ctx, span := tracer.Start(ctx, "Service.GetAggRates")
+	coins, err := s.getAggRates(ctx, requestedCoinTitles, aggFuncName)
+	tracing.End(span, err)
+
+	return coins, err
+}
+
+func (s *Service) getAggRates(ctx context.Context, requestedCoinTitles []string, aggFuncName string) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	requestedCoinTitles, err := normalizeTitles(requestedCoinTitles)
+	if err != nil {
+		log.Warn("(service.GetAggRates) invalid coin titles", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
 	if !validAggFuncs[strings.ToUpper(aggFuncName)] {
-		log.Error("(service.GetAggRates) wrong aggregate function")
-		return nil, errors.Wrap(entities.ErrInvalidParam, "wrong aggregate function name")
+		err := errors.Wrap(entities.ErrUnknownAggregate, "wrong aggregate function name")
+		log.Error("(service.GetAggRates) wrong aggregate function", zap.Any("err", err.Error()))
+		return nil, err
 	}
 
 	// получаем список монет, которые уже есть в хранилище
 	existingTitles, err := s.storage.GetCoinsList(ctx)
 	if err != nil {
-		log.Error("(service.GetAggRates) failed to get list of coin titles")
+		log.Error("(service.GetAggRates) failed to get list of coin titles", zap.Any("err", err.Error()))
 		return nil, errors.Wrap(err, "failed to get list of coin titles")
 	}
 
+	_, split := tracer.Start(ctx, "splitRequestedTitles")
 	existingReqTitles, nonExistingReqTitles := splitRequestedTitles(requestedCoinTitles, existingTitles)
+	split.End()
+
+	if err := s.rejectUnknown(nonExistingReqTitles); err != nil {
+		log.Warn("(service.GetAggRates) unknown coin titles", zap.Any("err", err.Error()))
+		return nil, err
+	}
 
 	// 1-случай: все запрашиваемые монеты есть в хранилище
 	if len(nonExistingReqTitles) == 0 {
 		log.Info("(service.GetAggRates) all requested titles in storage")
 		aggCoins, err := s.storage.GetAggregateCoins(ctx, requestedCoinTitles, aggFuncName)
 		if err != nil {
-			log.Error("(service.GetAggRates) failed to get aggregated coin data from storage")
+			log.Error("(service.GetAggRates) failed to get aggregated coin data from storage", zap.Any("err", err.Error()))
 			return nil, errors.Wrap(err, "failed to get aggregated coin data from storage")
 		}
 
@@ -117,7 +223,7 @@ func (s *Service) GetAggRates(ctx context.Context, requestedCoinTitles []string,
 		// получаем актуальные данные по отсутствующим монетам от провайдера и сохраняем в хранилище
 		newAggCoins, err := s.handleMissingTitles(ctx, nonExistingReqTitles, aggFuncName)
 		if err != nil {
-			log.Error("(service.GetAggRates) error from (handleMissingTitles)")
+			log.Error("(service.GetAggRates) error from (handleMissingTitles)", zap.Any("err", err.Error()))
 			return nil, err
 		}
 
@@ -129,13 +235,13 @@ func (s *Service) GetAggRates(ctx context.Context, requestedCoinTitles []string,
 	log.Info("(service.GetAggRates) requested titles partially in storage")
 	newAggCoins, err := s.handleMissingTitles(ctx, nonExistingReqTitles, aggFuncName)
 	if err != nil {
-		log.Error("(service.GetAggRates) error from (handleMissingTitles)")
+		log.Error("(service.GetAggRates) error from (handleMissingTitles)", zap.Any("err", err.Error()))
 		return nil, err
 	}
 
 	aggCoins, err := s.storage.GetAggregateCoins(ctx, existingReqTitles, aggFuncName)
 	if err != nil {
-		log.Error("(service.GetAggRates) failed to get aggregated coin data from storage")
+		log.Error("(service.GetAggRates) failed to get aggregated coin data from storage", zap.Any("err", err.Error()))
 		return nil, errors.Wrap(err, "failed to get aggregated coin data from storage")
 	}
 
@@ -143,11 +249,150 @@ func (s *Service) GetAggRates(ctx context.Context, requestedCoinTitles []string,
 	return append(aggCoins, newAggCoins...), nil
 }
 
+func (s *Service) GetHistory(ctx context.Context, title string, from, to time.Time) ([]entities.Coin, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetHistory")
+	coins, err := s.getHistory(ctx, title, from, to)
+	tracing.End(span, err)
+
+	return coins, err
+}
+
+func (s *Service) getHistory(ctx context.Context, title string, from, to time.Time) ([]entities.Coin, error) {
+	log := logger.FromContext(ctx)
+	title, err := normalizeTitle(title)
+	if err != nil {
+		log.Warn("(service.GetHistory) invalid coin title", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	if !from.Before(to) {
+		err := errors.Wrap(entities.ErrInvalidRange, "'from' must be before 'to'")
+		log.Error("(service.GetHistory) wrong time range", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	coins, err := s.storage.GetHistory(ctx, title, from, to)
+	if err != nil {
+		log.Error("(service.GetHistory) failed to get coin history from storage", zap.Any("err", err.Error()))
+		return nil, errors.Wrap(err, "failed to get coin history from storage")
+	}
+
+	return coins, nil
+}
+
+func (s *Service) GetCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetCandles")
+	candles, err := s.getCandles(ctx, title, interval, from, to)
+	tracing.End(span, err)
+
+	return candles, err
+}
+
+func (s *Service) getCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error) {
+	log := logger.FromContext(ctx)
+	title, err := normalizeTitle(title)
+	if err != nil {
+		log.Warn("(service.GetCandles) invalid coin title", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	if !from.Before(to) {
+		err := errors.Wrap(entities.ErrInvalidRange, "'from' must be before 'to'")
+		log.Error("(service.GetCandles) wrong time range", zap.Any("err", err.Error()))
+		return nil, err
+	}
+
+	candles, err := s.storage.GetCandles(ctx, title, interval, from, to)
+	if err != nil {
+		log.Error("(service.GetCandles) failed to get coin candles from storage", zap.Any("err", err.Error()))
+		return nil, errors.Wrap(err, "failed to get coin candles from storage")
+	}
+
+	return candles, nil
+}
+
+// StreamHistory - потоковый вариант GetHistory для выгрузки больших периодов
+func (s *Service) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error {
+	ctx, span := tracer.Start(ctx, "Service.StreamHistory")
+	err := s.streamHistory(ctx, title, from, to, fn)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *Service) streamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error {
+	log := logger.FromContext(ctx)
+	title, err := normalizeTitle(title)
+	if err != nil {
+		log.Warn("(service.StreamHistory) invalid coin title", zap.Any("err", err.Error()))
+		return err
+	}
+
+	if !from.Before(to) {
+		err := errors.Wrap(entities.ErrInvalidRange, "'from' must be before 'to'")
+		log.Error("(service.StreamHistory) wrong time range", zap.Any("err", err.Error()))
+		return err
+	}
+
+	if err := s.storage.StreamHistory(ctx, title, from, to, fn); err != nil {
+		log.Error("(service.StreamHistory) failed to stream coin history from storage", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to stream coin history from storage")
+	}
+
+	return nil
+}
+
+// StreamCandles - потоковый вариант GetCandles
+func (s *Service) StreamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error {
+	ctx, span := tracer.Start(ctx, "Service.StreamCandles")
+	err := s.streamCandles(ctx, title, interval, from, to, fn)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *Service) streamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error {
+	log := logger.FromContext(ctx)
+	title, err := normalizeTitle(title)
+	if err != nil {
+		log.Warn("(service.StreamCandles) invalid coin title", zap.Any("err", err.Error()))
+		return err
+	}
+
+	if !from.Before(to) {
+		err := errors.Wrap(entities.ErrInvalidRange, "'from' must be before 'to'")
+		log.Error("(service.StreamCandles) wrong time range", zap.Any("err", err.Error()))
+		return err
+	}
+
+	if err := s.storage.StreamCandles(ctx, title, interval, from, to, fn); err != nil {
+		log.Error("(service.StreamCandles) failed to stream coin candles from storage", zap.Any("err", err.Error()))
+		return errors.Wrap(err, "failed to stream coin candles from storage")
+	}
+
+	return nil
+}
+
 func (s *Service) ActualizeRates(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Service.ActualizeRates")
+	err := s.actualizeRates(ctx)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *Service) actualizeRates(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	if s.readOnly {
+		err := errors.Wrap(entities.ErrReadOnly, "read-only service cannot actualize rates")
+		log.Error("(service.ActualizeRates) service is read-only", zap.Any("err", err.Error()))
+		return err
+	}
+
 	log.Info("(service.ActualizeRates) getting list of coin titles")
 	existingTitles, err := s.storage.GetCoinsList(ctx)
 	if err != nil {
-		log.Error("(service.ActualizeRates) failed to get list of coin titles")
+		log.Error("(service.ActualizeRates) failed to get list of coin titles", zap.Any("err", err.Error()))
 		return errors.Wrap(err, "failed to get list of coin titles")
 	}
 
@@ -157,30 +402,116 @@ func (s *Service) ActualizeRates(ctx context.Context) error {
 	}
 
 	log.Info("(service.ActualizeRates) actualizing coin rates")
-	_, err = s.handleMissingTitles(ctx, existingTitles, "PRICE")
+	coins, err := s.handleMissingTitles(ctx, existingTitles, "PRICE")
 	if err != nil {
-		log.Error("(service.ActualizeRates) failed to actualize coin rates")
+		log.Error("(service.ActualizeRates) failed to actualize coin rates", zap.Any("err", err.Error()))
 		return errors.Wrap(err, "failed to actualize coin rates")
 	}
+	metrics.ObserveActualize(len(coins))
 
 	return nil
 }
 
 func (s *Service) handleMissingTitles(ctx context.Context, missingTitles []string, extraArg string) ([]entities.Coin, error) {
+	ctx, span := tracer.Start(ctx, "Service.handleMissingTitles")
+	coins, err := s.fetchMissingTitles(ctx, missingTitles, extraArg)
+	tracing.End(span, err)
+
+	return coins, err
+}
+
+func (s *Service) fetchMissingTitles(ctx context.Context, missingTitles []string, extraArg string) ([]entities.Coin, error) {
+	// в режиме только для чтения к провайдеру не ходим
+	if s.readOnly {
+		return nil, errors.Wrapf(entities.ErrUnknownSymbol, "coin(s) %v not in storage", missingTitles)
+	}
+
+	if err := s.allowProviderCall(ctx, missingTitles); err != nil {
+		return nil, err
+	}
+
 	// получаем актуальные данные по отсутствующим монетам от провайдера
 	newCoins, err := s.provider.GetActualRates(ctx, missingTitles, extraArg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get coin data from provider")
 	}
 
+	// контекст отменяется при остановке приложения: после этого в хранилище ничего не пишем
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "canceled before writing coin data to storage")
+	}
+
 	// сохраняем новые монеты в хранилище
 	if err := s.storage.Store(ctx, newCoins); err != nil {
 		return nil, errors.Wrap(err, "failed to write new coin data to storage")
 	}
 
+	// агрегаты (MAX, MIN, AVG) - не текущая цена: подписчикам их не рассылаем и в метриках поступления не учитываем
+	if extraArg != "PRICE" {
+		return newCoins, nil
+	}
+	metrics.ObserveIngestion(newCoins)
+
+	if s.publisher != nil {
+		s.publisher.Publish(newCoins)
+	}
+
 	return newCoins, nil
 }
 
+// allowProviderCall расходует бюджет клиента, от имени которого выполняется запрос; плановая актуализация не ограничивается
+func (s *Service) allowProviderCall(ctx context.Context, missingTitles []string) error {
+	client := entities.ClientFromContext(ctx)
+	if s.providerLimiter == nil || client == "" {
+		return nil
+	}
+
+	retryAfter, ok := s.providerLimiter.Allow(client, providerRoute)
+	if !ok {
+		log := logger.FromContext(ctx)
+		log.Warn("(service.handleMissingTitles) provider budget exceeded", zap.Any("client", client), zap.Any("titles", missingTitles))
+		return entities.NewRetryError(errors.Wrapf(entities.ErrRateLimited, "too many requests for coins not in storage %v", missingTitles), retryAfter)
+	}
+
+	return nil
+}
+
+// rejectUnknown отклоняет монеты, которых нет в хранилище и в каталоге: провайдер всё равно не отдаст их цену
+func (s *Service) rejectUnknown(missingTitles []string) error {
+	if s.catalog == nil {
+		return nil
+	}
+
+	if unknown := s.catalog.Unknown(missingTitles); len(unknown) > 0 {
+		return errors.Wrapf(entities.ErrUnknownSymbol, "coin(s) %v not in asset catalog", unknown)
+	}
+
+	return nil
+}
+
+// normalizeTitles приводит запрошенные монеты к тикерам entities.Symbol: без пробелов, повторов и псевдонимов
+func normalizeTitles(titles []string) ([]string, error) {
+	normalized, err := entities.NormalizeTitles(titles)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(normalized) == 0 {
+		return nil, errors.Wrap(entities.ErrInvalidSymbol, "no coin titles given")
+	}
+
+	return normalized, nil
+}
+
+func normalizeTitle(title string) (string, error) {
+	symbol, err := entities.ParseSymbol(title)
+	if err != nil {
+		return "", err
+	}
+
+	return string(symbol), nil
+}
+
 // функция для разделения монет на категории: (существующий запрашиваемый) и (несуществующий запрашиваемый)
 func splitRequestedTitles(requested, existing []string) ([]string, []string) {
 	existingReqTitles := make([]string, 0)
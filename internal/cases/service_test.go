@@ -3,6 +3,7 @@ package cases_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -78,8 +79,8 @@ func TestGetLastRates_Case1_Line_48(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	btcCoin := entities.Coin{Title: "BTC", Price: 100}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	storage.EXPECT().GetActualCoins(ctx, []string{"BTC"}).Return([]entities.Coin{btcCoin}, nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC"}).Return([]entities.Coin{btcCoin}, nil)
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.NoError(t, err)
@@ -103,9 +104,9 @@ func TestGetLastRates_Case2_Line_59(t *testing.T) {
 	requestedTitles := []string{"ETH"}
 	nonExistingCoin := entities.Coin{Title: "ETH", Price: 10}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{nonExistingCoin}).Return(nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{nonExistingCoin}).Return(nil)
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.NoError(t, err)
@@ -134,10 +135,10 @@ func TestGetLastRates_Case3_Line_73(t *testing.T) {
 	ethCoin := entities.Coin{Title: "ETH", Price: 100}
 	requestedTitles := []string{"BTC", "ETH", "USDT"}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC", "ETH"}, nil)
-	provider.EXPECT().GetActualRates(ctx, []string{"USDT"}, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{nonExistingCoin}).Return(nil)
-	storage.EXPECT().GetActualCoins(ctx, []string{"BTC", "ETH"}).Return([]entities.Coin{ethCoin, btcCoin}, nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC", "ETH"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"USDT"}, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{nonExistingCoin}).Return(nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC", "ETH"}).Return([]entities.Coin{ethCoin, btcCoin}, nil)
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.NoError(t, err)
@@ -160,7 +161,7 @@ func TestGetLastRates_Case4_Line_36(t *testing.T) {
 	ctx := context.Background()
 	requestedTitles := []string{"BTC"}
 
-	storage.EXPECT().GetCoinsList(ctx).Return(nil, errors.New("GetCoinsList error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return(nil, errors.New("GetCoinsList error"))
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.Nil(t, coins)
@@ -183,8 +184,8 @@ func TestGetLastRates_Case5_Line_45(t *testing.T) {
 	ctx := context.Background()
 	requestedTitles := []string{"BTC"}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	storage.EXPECT().GetActualCoins(ctx, []string{"BTC"}).Return(nil, errors.New("GetActualCoins error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC"}).Return(nil, errors.New("GetActualCoins error"))
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.Nil(t, coins)
@@ -207,8 +208,8 @@ func TestGetLastRates_Case6_Line_131(t *testing.T) {
 	ctx := context.Background()
 	requestedTitles := []string{"BTC"}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, "PRICE").Return(nil, errors.New("GetActualRates error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, "PRICE").Return(nil, errors.New("GetActualRates error"))
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.Nil(t, coins)
@@ -232,9 +233,9 @@ func TestGetLastRates_Case7_Line_136(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	nonExistingCoin := entities.Coin{Title: "BTC", Price: 100}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{nonExistingCoin}).Return(errors.New("Store error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{nonExistingCoin}).Return(errors.New("Store error"))
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.Nil(t, coins)
@@ -257,8 +258,8 @@ func TestGetLastRates_Case8_Line_65(t *testing.T) {
 	ctx := context.Background()
 	requestedTitles := []string{"BTC", "ETH"}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	provider.EXPECT().GetActualRates(ctx, []string{"ETH"}, "PRICE").Return(nil, errors.New("GetActualRates error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return(nil, errors.New("GetActualRates error"))
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.Nil(t, coins)
@@ -282,10 +283,10 @@ func TestGetLastRates_Case9_Line_70(t *testing.T) {
 	requestedTitles := []string{"BTC", "ETH"}
 	nonExistingCoin := entities.Coin{Title: "ETH", Price: 10}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	provider.EXPECT().GetActualRates(ctx, []string{"ETH"}, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{nonExistingCoin}).Return(nil)
-	storage.EXPECT().GetActualCoins(ctx, []string{"BTC"}).Return(nil, errors.New("GetActualCoins error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return([]entities.Coin{nonExistingCoin}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{nonExistingCoin}).Return(nil)
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC"}).Return(nil, errors.New("GetActualCoins error"))
 
 	coins, err := srv.GetLastRates(ctx, requestedTitles)
 	require.Nil(t, coins)
@@ -332,8 +333,8 @@ func TestGetAggRates_Case2_Line_97(t *testing.T) {
 	aggFuncName := "max"
 	btcCoin := entities.Coin{Title: "BTC", Price: 100}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	storage.EXPECT().GetAggregateCoins(ctx, []string{"BTC"}, aggFuncName).Return([]entities.Coin{btcCoin}, nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetAggregateCoins(gomock.Any(), []string{"BTC"}, aggFuncName).Return([]entities.Coin{btcCoin}, nil)
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.NoError(t, err)
@@ -357,9 +358,9 @@ func TestGetAggRates_Case3_Line_108(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	aggFuncName := "MAX"
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, "MAX").Return([]entities.Coin{{Title: "BTC", Price: 100}}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{{Title: "BTC", Price: 100}}).Return(nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, "MAX").Return([]entities.Coin{{Title: "BTC", Price: 100}}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{{Title: "BTC", Price: 100}}).Return(nil)
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.NoError(t, err)
@@ -385,10 +386,10 @@ func TestGetAggRates_Case4_Line_124(t *testing.T) {
 	nonExistingCoin := entities.Coin{Title: "ETH", Price: 10}
 	existingCoin := entities.Coin{Title: "BTC", Price: 100}
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	provider.EXPECT().GetActualRates(ctx, []string{"ETH"}, "max").Return([]entities.Coin{nonExistingCoin}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{nonExistingCoin}).Return(nil)
-	storage.EXPECT().GetAggregateCoins(ctx, []string{"BTC"}, aggFuncName).Return([]entities.Coin{existingCoin}, nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "max").Return([]entities.Coin{nonExistingCoin}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{nonExistingCoin}).Return(nil)
+	storage.EXPECT().GetAggregateCoins(gomock.Any(), []string{"BTC"}, aggFuncName).Return([]entities.Coin{existingCoin}, nil)
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.NoError(t, err)
@@ -412,7 +413,7 @@ func TestGetAggRates_Case5_Line_85(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	aggFuncName := "max"
 
-	storage.EXPECT().GetCoinsList(ctx).Return(nil, errors.New("ZZZ"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return(nil, errors.New("ZZZ"))
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.Nil(t, coins)
@@ -436,8 +437,8 @@ func TestGetAggRates_Case6_Line_94(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	aggFuncName := "max"
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	storage.EXPECT().GetAggregateCoins(ctx, requestedTitles, aggFuncName).Return(nil, errors.New("GetAggregateCoins error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetAggregateCoins(gomock.Any(), requestedTitles, aggFuncName).Return(nil, errors.New("GetAggregateCoins error"))
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.Nil(t, coins)
@@ -461,8 +462,8 @@ func TestGetAggRates_Case7_Line_105(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	aggFuncName := "max"
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, aggFuncName).Return(nil, errors.New("GetActualRates"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, aggFuncName).Return(nil, errors.New("GetActualRates"))
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.Nil(t, coins)
@@ -486,8 +487,8 @@ func TestGetAggRates_Case8_Line_115(t *testing.T) {
 	requestedTitles := []string{"BTC", "ETH"}
 	aggFuncName := "max"
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	provider.EXPECT().GetActualRates(ctx, []string{"ETH"}, aggFuncName).Return(nil, errors.New("GetActualRates error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, aggFuncName).Return(nil, errors.New("GetActualRates error"))
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.Nil(t, coins)
@@ -511,10 +512,10 @@ func TestGetAggRates_Case9_Line_120(t *testing.T) {
 	requestedTitles := []string{"BTC", "ETH"}
 	aggFuncName := "max"
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
-	provider.EXPECT().GetActualRates(ctx, []string{"ETH"}, aggFuncName).Return([]entities.Coin{{Title: "ETH", Price: 10}}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{{Title: "ETH", Price: 10}}).Return(nil)
-	storage.EXPECT().GetAggregateCoins(ctx, []string{"BTC"}, aggFuncName).Return(nil, errors.New("GetAggregateCoins error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, aggFuncName).Return([]entities.Coin{{Title: "ETH", Price: 10}}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{{Title: "ETH", Price: 10}}).Return(nil)
+	storage.EXPECT().GetAggregateCoins(gomock.Any(), []string{"BTC"}, aggFuncName).Return(nil, errors.New("GetAggregateCoins error"))
 
 	coins, err := srv.GetAggRates(ctx, requestedTitles, aggFuncName)
 	require.Nil(t, coins)
@@ -537,9 +538,9 @@ func TestActualizeRates_Case1(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	aggFuncName := "PRICE"
 
-	storage.EXPECT().GetCoinsList(ctx).Return(requestedTitles, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, aggFuncName).Return([]entities.Coin{{Title: "BTC", Price: 100}}, nil)
-	storage.EXPECT().Store(ctx, []entities.Coin{{Title: "BTC", Price: 100}}).Return(nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return(requestedTitles, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, aggFuncName).Return([]entities.Coin{{Title: "BTC", Price: 100}}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{{Title: "BTC", Price: 100}}).Return(nil)
 
 	err = srv.ActualizeRates(ctx)
 	require.NoError(t, err)
@@ -559,7 +560,7 @@ func TestActualizeRates_Case2(t *testing.T) {
 
 	ctx := context.Background()
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, errors.New("GetCoinsList error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, errors.New("GetCoinsList error"))
 
 	err = srv.ActualizeRates(ctx)
 	require.ErrorContains(t, err, "failed to get list of coin titles")
@@ -579,7 +580,7 @@ func TestActualizeRates_Case3(t *testing.T) {
 
 	ctx := context.Background()
 
-	storage.EXPECT().GetCoinsList(ctx).Return([]string{}, nil)
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
 
 	err = srv.ActualizeRates(ctx)
 	require.Nil(t, err)
@@ -601,9 +602,340 @@ func TestActualizeRates_Case4(t *testing.T) {
 	requestedTitles := []string{"BTC"}
 	aggFuncName := "PRICE"
 
-	storage.EXPECT().GetCoinsList(ctx).Return(requestedTitles, nil)
-	provider.EXPECT().GetActualRates(ctx, requestedTitles, aggFuncName).Return(nil, errors.New("GetActualRates error"))
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return(requestedTitles, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), requestedTitles, aggFuncName).Return(nil, errors.New("GetActualRates error"))
 
 	err = srv.ActualizeRates(ctx)
 	require.ErrorContains(t, err, "failed to actualize coin rates")
 }
+
+func TestReadOnlyService_GetLastRates(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewReadOnlyService(storage)
+	require.NoError(t, err)
+	require.NotNil(t, srv)
+
+	ctx := context.Background()
+
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+
+	coins, err := srv.GetLastRates(ctx, []string{"BTC", "ETH"})
+	require.Nil(t, coins)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func TestReadOnlyService_ActualizeRates(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv, err := cases.NewReadOnlyService(mock_cases.NewMockStorage(ctrl))
+	require.NoError(t, err)
+
+	err = srv.ActualizeRates(context.Background())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func TestGetCandles(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	candles := []entities.Candle{{Title: "BTC", Bucket: from.Truncate(time.Hour), Open: 1, High: 2, Low: 1, Close: 2, Avg: 1.5, Count: 2}}
+
+	storage.EXPECT().GetCandles(gomock.Any(), "BTC", entities.IntervalHour, from, to).Return(candles, nil)
+
+	res, err := srv.GetCandles(ctx, "BTC", entities.IntervalHour, from, to)
+	require.NoError(t, err)
+	require.Equal(t, candles, res)
+}
+
+func TestGetHistory_WrongRange(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), mock_cases.NewMockStorage(ctrl))
+	require.NoError(t, err)
+
+	now := time.Now()
+	coins, err := srv.GetHistory(context.Background(), "BTC", now, now.Add(-time.Hour))
+	require.Nil(t, coins)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func TestStreamHistory(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockStorage(ctrl)
+	srv, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	stored := []entities.Coin{{Title: "BTC", Price: 1, CreatedAt: from}, {Title: "BTC", Price: 2, CreatedAt: to}}
+
+	storage.EXPECT().StreamHistory(gomock.Any(), "BTC", from, to, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, _, _ time.Time, fn func(entities.Coin) error) error {
+			for _, coin := range stored {
+				if err := fn(coin); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	got := make([]entities.Coin, 0)
+	err = srv.StreamHistory(ctx, "BTC", from, to, func(coin entities.Coin) error {
+		got = append(got, coin)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, stored, got)
+}
+
+func TestStreamHistory_WrongRange(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), mock_cases.NewMockStorage(ctrl))
+	require.NoError(t, err)
+
+	now := time.Now()
+	err = srv.StreamHistory(context.Background(), "BTC", now, now, func(entities.Coin) error { return nil })
+	require.ErrorIs(t, err, entities.ErrInvalidRange)
+}
+
+func TestStreamCandles_StorageError(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockStorage(ctrl)
+	srv, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	storage.EXPECT().StreamCandles(gomock.Any(), "BTC", entities.IntervalHour, from, to, gomock.Any()).Return(entities.ErrStorageUnavailable)
+
+	err = srv.StreamCandles(ctx, "BTC", entities.IntervalHour, from, to, func(entities.Candle) error { return nil })
+	require.ErrorIs(t, err, entities.ErrStorageUnavailable)
+}
+
+func TestActualizeRates_Publishes(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+	publisher := mock_cases.NewMockPublisher(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+	srv.SetPublisher(publisher)
+
+	ctx := context.Background()
+	coins := []entities.Coin{{Title: "BTC", Price: 100}}
+
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"BTC"}, "PRICE").Return(coins, nil)
+	gomock.InOrder(
+		storage.EXPECT().Store(gomock.Any(), coins).Return(nil),
+		publisher.EXPECT().Publish(coins),
+	)
+
+	require.NoError(t, srv.ActualizeRates(ctx))
+}
+
+func TestGetAggRates_DoesNotPublishAggregates(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+	// Publish не ожидается: мок упадёт при вызове
+	publisher := mock_cases.NewMockPublisher(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+	srv.SetPublisher(publisher)
+
+	ctx := context.Background()
+	coins := []entities.Coin{{Title: "BTC", Price: 100}}
+
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"BTC"}, "MAX").Return(coins, nil)
+	storage.EXPECT().Store(gomock.Any(), coins).Return(nil)
+
+	got, err := srv.GetAggRates(ctx, []string{"BTC"}, "MAX")
+	require.NoError(t, err)
+	require.Equal(t, coins, got)
+}
+
+func TestActualizeRates_CanceledBeforeStore(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	// контекст отменён, пока ждали провайдера: Store вызываться не должен
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"BTC"}, "PRICE").DoAndReturn(
+		func(ctx context.Context, titles []string, extraArg string) ([]entities.Coin, error) {
+			cancel()
+			return []entities.Coin{{Title: "BTC", Price: 100}}, nil
+		})
+
+	err = srv.ActualizeRates(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGetLastRates_ProviderBudget(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+	limiter, err := cases.NewClientLimiter(entities.RateBudget{RPS: 0.001, Burst: 1}, nil)
+	require.NoError(t, err)
+	srv.SetProviderLimiter(limiter)
+
+	ctx := entities.WithClient(context.Background(), "10.0.0.1")
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil).Times(3)
+	// провайдер вызывается один раз: второй запрос неизвестной монеты с того же IP уже сверх бюджета
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return([]entities.Coin{{Title: "ETH", Price: 10}}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{{Title: "ETH", Price: 10}}).Return(nil)
+
+	coins, err := srv.GetLastRates(ctx, []string{"ETH"})
+	require.NoError(t, err)
+	require.Equal(t, []entities.Coin{{Title: "ETH", Price: 10}}, coins)
+
+	_, err = srv.GetLastRates(ctx, []string{"DOGE"})
+	require.ErrorIs(t, err, entities.ErrRateLimited)
+	var retry *entities.RetryError
+	require.ErrorAs(t, err, &retry)
+	require.Greater(t, retry.RetryAfter, time.Duration(0))
+
+	// бюджет у каждого клиента свой
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"DOGE"}, "PRICE").Return([]entities.Coin{{Title: "DOGE", Price: 1}}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{{Title: "DOGE", Price: 1}}).Return(nil)
+	_, err = srv.GetLastRates(entities.WithClient(context.Background(), "10.0.0.2"), []string{"DOGE"})
+	require.NoError(t, err)
+}
+
+func TestGetLastRates_NormalizesTitles(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	coins := []entities.Coin{{Title: "BTC", Price: 100}, {Title: "ETH", Price: 10}}
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC", "ETH"}, nil)
+	// пробелы, регистр, псевдонимы и повторы не порождают лишних монет
+	storage.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC", "ETH"}).Return(coins, nil)
+
+	res, err := srv.GetLastRates(ctx, []string{" btc", "ETH ", "XBT", "", "eth"})
+	require.NoError(t, err)
+	require.Equal(t, coins, res)
+}
+
+func TestGetLastRates_InvalidTitles(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+
+	// ни хранилище, ни провайдер не вызываются
+	_, err = srv.GetLastRates(context.Background(), []string{"BTC", "DROP TABLE", "ETH/USD"})
+	require.ErrorIs(t, err, entities.ErrInvalidSymbol)
+	require.Contains(t, err.Error(), `["DROP TABLE" "ETH/USD"]`)
+
+	_, err = srv.GetAggRates(context.Background(), []string{" ", ""}, "MAX")
+	require.ErrorIs(t, err, entities.ErrInvalidSymbol)
+
+	_, err = srv.GetHistory(context.Background(), "BTC;", time.Now().Add(-time.Hour), time.Now())
+	require.ErrorIs(t, err, entities.ErrInvalidSymbol)
+}
+
+func TestGetLastRates_UnknownToCatalog(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	storage := mock_cases.NewMockStorage(ctrl)
+	assetStorage := mock_cases.NewMockAssetStorage(ctrl)
+
+	srv, err := cases.NewService(provider, storage)
+	require.NoError(t, err)
+	catalog, err := cases.NewReadOnlyAssetCatalog(assetStorage)
+	require.NoError(t, err)
+	srv.SetCatalog(catalog)
+
+	ctx := context.Background()
+	assetStorage.EXPECT().ListAssetSymbols(ctx).Return([]string{"BTC", "ETH"}, nil)
+	require.NoError(t, catalog.Refresh(ctx))
+
+	// провайдер не вызывается: NOPE нет ни в хранилище, ни в каталоге
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil).Times(2)
+	_, err = srv.GetLastRates(ctx, []string{"BTC", "NOPE"})
+	require.ErrorIs(t, err, entities.ErrUnknownSymbol)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+
+	_, err = srv.GetAggRates(ctx, []string{"NOPE"}, "MAX")
+	require.ErrorIs(t, err, entities.ErrUnknownSymbol)
+
+	// монета из каталога запрашивается у провайдера как раньше
+	storage.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC"}, nil)
+	provider.EXPECT().GetActualRates(gomock.Any(), []string{"ETH"}, "PRICE").Return([]entities.Coin{{Title: "ETH", Price: 10}}, nil)
+	storage.EXPECT().Store(gomock.Any(), []entities.Coin{{Title: "ETH", Price: 10}}).Return(nil)
+	coins, err := srv.GetLastRates(ctx, []string{"ETH"})
+	require.NoError(t, err)
+	require.Equal(t, []entities.Coin{{Title: "ETH", Price: 10}}, coins)
+}
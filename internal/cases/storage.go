@@ -2,6 +2,7 @@ package cases
 
 import (
 	"context"
+	"time"
 
 	"kursy-kriptovalyut/internal/entities"
 )
@@ -12,9 +13,19 @@ type Storage interface {
 	GetCoinsList(ctx context.Context) ([]string, error)
 	GetActualCoins(ctx context.Context, titles []string) ([]entities.Coin, error)
 	GetAggregateCoins(ctx context.Context, titles []string, aggFuncName string) ([]entities.Coin, error)
+	GetAggregateCoinsSince(ctx context.Context, titles []string, aggFuncName string, since time.Time) ([]entities.Coin, error)
+	GetHistory(ctx context.Context, title string, from, to time.Time) ([]entities.Coin, error)
+	GetCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error)
+	StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error
+	StreamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error
 }
 
 // Store - для записи новых данных в БД полученных из внешнего API
 // GetCoinsList - для получения списка коинов
 // GetActualCoins - для получения последней цены крипты записанной в БД
 // GetAggregateCoins - для получения агрегированного ответа (макс. мин. сред. цены)
+// GetAggregateCoinsSince - то же, но за период с since, а не за текущие сутки
+// GetHistory - для получения всех записей по монете за период [from, to] в порядке времени (из свёрток, если сырых данных уже нет)
+// GetCandles - для получения свечей с шагом interval за период [from, to) из данных наиболее подробного доступного разрешения
+// StreamHistory, StreamCandles - то же, что GetHistory и GetCandles, но записи передаются в fn по одной по мере чтения;
+// ошибка fn прерывает чтение и возвращается как есть
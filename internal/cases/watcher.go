@@ -0,0 +1,79 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// StorageWatcher периодически читает последние цены из хранилища и передаёт их подписчикам.
+// Нужен репликам API, в которые курсы записывает другой процесс (режим worker).
+type StorageWatcher struct {
+	storage   Storage
+	publisher Publisher
+	interval  time.Duration
+}
+
+func NewStorageWatcher(storage Storage, publisher Publisher, interval time.Duration) (*StorageWatcher, error) {
+	if storage == nil || storage == Storage(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "storage not set")
+	}
+
+	if publisher == nil || publisher == Publisher(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "publisher not set")
+	}
+
+	if interval <= 0 {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "watch interval must be positive")
+	}
+
+	return &StorageWatcher{
+		storage:   storage,
+		publisher: publisher,
+		interval:  interval,
+	}, nil
+}
+
+// Run блокируется до отмены ctx
+func (w *StorageWatcher) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(ctx); err != nil {
+			log.Error("(watcher.Run) failed to poll storage", zap.Any("err", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll передаёт подписчикам последние цены всех отслеживаемых монет; повторы отсекает шина
+func (w *StorageWatcher) Poll(ctx context.Context) error {
+	titles, err := w.storage.GetCoinsList(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get list of coin titles")
+	}
+
+	if len(titles) == 0 {
+		return nil
+	}
+
+	coins, err := w.storage.GetActualCoins(ctx, titles)
+	if err != nil {
+		return errors.Wrap(err, "failed to get coin data from storage")
+	}
+
+	w.publisher.Publish(coins)
+	return nil
+}
@@ -0,0 +1,55 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestStorageWatcher_Poll(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockStorage(ctrl)
+	publisher := mock_cases.NewMockPublisher(ctrl)
+
+	watcher, err := cases.NewStorageWatcher(storage, publisher, time.Second)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	coins := []entities.Coin{{Title: "BTC", Price: 100}}
+
+	storage.EXPECT().GetCoinsList(ctx).Return([]string{"BTC"}, nil)
+	storage.EXPECT().GetActualCoins(ctx, []string{"BTC"}).Return(coins, nil)
+	publisher.EXPECT().Publish(coins)
+
+	require.NoError(t, watcher.Poll(ctx))
+}
+
+func TestStorageWatcher_PollStorageError(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_cases.NewMockStorage(ctrl)
+	publisher := mock_cases.NewMockPublisher(ctrl)
+
+	watcher, err := cases.NewStorageWatcher(storage, publisher, time.Second)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	storage.EXPECT().GetCoinsList(ctx).Return(nil, errors.Wrap(entities.ErrStorageUnavailable, "connection refused"))
+
+	err = watcher.Poll(ctx)
+	require.ErrorIs(t, err, entities.ErrStorageUnavailable)
+	require.ErrorContains(t, err, "failed to get list of coin titles")
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Scope - право, выданное API-ключу: read - чтение курсов, stream - подписки (SSE, WebSocket, gRPC),
+// admin - служебные маршруты /admin
+type Scope string
+
+const (
+	ScopeRead   Scope = "read"
+	ScopeStream Scope = "stream"
+	ScopeAdmin  Scope = "admin"
+)
+
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(strings.ToLower(strings.TrimSpace(s)))
+	switch scope {
+	case ScopeRead, ScopeStream, ScopeAdmin:
+		return scope, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidParam, "unknown scope %q, expected one of read, stream, admin", s)
+	}
+}
+
+// APIKey - ключ клиента API. Сам ключ не хранится: по Hash он находится, по Prefix его узнаёт владелец
+type APIKey struct {
+	ID     int64
+	Name   string
+	Prefix string
+	Hash   string
+	Scopes []Scope
+	// RateLimit - допустимое число запросов в минуту
+	RateLimit int
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (k APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// KeyUsage - число запросов по ключу за сутки Day (UTC)
+type KeyUsage struct {
+	KeyID    int64
+	Day      time.Time
+	Requests int64
+}
+
+// RateLimit - состояние лимита запросов ключа: Remaining запросов осталось до Reset
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
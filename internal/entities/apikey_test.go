@@ -0,0 +1,49 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestParseScope(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   string
+		scope   entities.Scope
+		wantErr bool
+	}{
+		{
+			name:  "read",
+			input: "read",
+			scope: entities.ScopeRead,
+		},
+		{
+			name:  "upper case with spaces",
+			input: " ADMIN ",
+			scope: entities.ScopeAdmin,
+		},
+		{
+			name:    "unknown scope",
+			input:   "write",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			scope, err := entities.ParseScope(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.scope, scope)
+		})
+	}
+}
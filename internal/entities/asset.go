@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// AssetStatus - состояние монеты в каталоге
+type AssetStatus string
+
+const (
+	// AssetActive - монета торгуется, и провайдер отдаёт её цену
+	AssetActive AssetStatus = "active"
+	// AssetInactive - монета есть в списке провайдера, но не торгуется
+	AssetInactive AssetStatus = "inactive"
+	// AssetDelisted - монета пропала из списка провайдера
+	AssetDelisted AssetStatus = "delisted"
+)
+
+// Asset - монета из каталога известных активов, который синхронизируется со списками монет провайдеров
+type Asset struct {
+	Symbol Symbol
+	Name   string
+	// ProviderIDs - идентификаторы монеты у провайдеров, по имени провайдера
+	ProviderIDs map[string]string
+	Decimals    int
+	Status      AssetStatus
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// Candle - агрегат цен монеты за интервал, начинающийся в момент Bucket
+type Candle struct {
+	Title  string
+	Bucket time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Avg    float64
+	Count  int
+}
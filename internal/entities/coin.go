@@ -1,14 +1,27 @@
 package entities
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 )
 
 type Coin struct {
 	Title string
 	Price float64
+	// CreatedAt - момент, к которому относится цена; пустое значение означает "сейчас"
+	CreatedAt time.Time
+	// Backfilled - запись загружена из исторических данных, а не получена в реальном времени
+	Backfilled bool
+	// Source - откуда получена цена: хранилище или имя провайдера
+	Source string
 }
 
+// DefaultQuote - валюта, в которой провайдер котирует монеты
+const DefaultQuote = "USD"
+
+const SourceStorage = "storage"
+
 func NewCoin(title string, price float64) (*Coin, error) {
 	if title == "" {
 		return nil, errors.Wrap(ErrInvalidParam, "title must not be empty")
@@ -8,4 +8,80 @@ var (
 	ErrInvalidParam = errors.New("invalid parameter")
 	ErrInternal     = errors.New("internal error")
 	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrShutdown     = errors.New("shutting down")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
 )
+
+// ErrorCode - машиночитаемый код ошибки, который отдаётся клиентам API
+type ErrorCode string
+
+const (
+	CodeInvalidParam        ErrorCode = "invalid_param"
+	CodeInvalidSymbol       ErrorCode = "invalid_symbol"
+	CodeInvalidRange        ErrorCode = "invalid_range"
+	CodeUnknownAggregate    ErrorCode = "unknown_aggregate"
+	CodeUnknownInterval     ErrorCode = "unknown_interval"
+	CodeReadOnly            ErrorCode = "read_only"
+	CodeNotFound            ErrorCode = "not_found"
+	CodeUnknownSymbol       ErrorCode = "unknown_symbol"
+	CodeRateLimited         ErrorCode = "rate_limited"
+	CodeUnauthorized        ErrorCode = "unauthorized"
+	CodeForbidden           ErrorCode = "forbidden"
+	CodeProviderUnavailable ErrorCode = "provider_unavailable"
+	CodeStorageUnavailable  ErrorCode = "storage_unavailable"
+	CodeStaleData           ErrorCode = "stale_data"
+	CodeInternal            ErrorCode = "internal"
+)
+
+// Error - типизированная ошибка с кодом; разворачивается в одну из базовых ошибок выше,
+// поэтому проверки вида errors.Is(err, ErrInvalidParam) продолжают работать
+type Error struct {
+	Code ErrorCode
+	msg  string
+	kind error
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+var (
+	ErrInvalidSymbol       = &Error{Code: CodeInvalidSymbol, msg: "invalid symbol", kind: ErrInvalidParam}
+	ErrInvalidRange        = &Error{Code: CodeInvalidRange, msg: "invalid time range", kind: ErrInvalidParam}
+	ErrUnknownAggregate    = &Error{Code: CodeUnknownAggregate, msg: "unknown aggregate function", kind: ErrInvalidParam}
+	ErrUnknownInterval     = &Error{Code: CodeUnknownInterval, msg: "unknown interval", kind: ErrInvalidParam}
+	ErrReadOnly            = &Error{Code: CodeReadOnly, msg: "read-only mode", kind: ErrInvalidParam}
+	ErrUnknownSymbol       = &Error{Code: CodeUnknownSymbol, msg: "unknown symbol", kind: ErrNotFound}
+	ErrProviderUnavailable = &Error{Code: CodeProviderUnavailable, msg: "provider unavailable", kind: ErrInternal}
+	ErrStorageUnavailable  = &Error{Code: CodeStorageUnavailable, msg: "storage unavailable", kind: ErrInternal}
+	ErrStaleData           = &Error{Code: CodeStaleData, msg: "stale data", kind: ErrInternal}
+)
+
+// Code возвращает код ошибки; для ошибок без типа код определяется по базовой ошибке
+func Code(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidParam):
+		return CodeInvalidParam
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrRateLimited):
+		return CodeRateLimited
+	case errors.Is(err, ErrUnauthorized):
+		return CodeUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return CodeForbidden
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,60 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		err   error
+		code  entities.ErrorCode
+		cause error
+	}{
+		{
+			name:  "typed error",
+			err:   errors.Wrap(errors.Wrap(entities.ErrUnknownAggregate, "wrong aggregate function name"), "failed"),
+			code:  entities.CodeUnknownAggregate,
+			cause: entities.ErrInvalidParam,
+		},
+		{
+			name:  "typed server error",
+			err:   errors.Wrapf(entities.ErrStorageUnavailable, "failed to execute query: %v", "conn refused"),
+			code:  entities.CodeStorageUnavailable,
+			cause: entities.ErrInternal,
+		},
+		{
+			name:  "base error",
+			err:   errors.Wrap(entities.ErrRateLimited, "provider rate limit exceeded"),
+			code:  entities.CodeRateLimited,
+			cause: entities.ErrRateLimited,
+		},
+		{
+			name:  "auth error",
+			err:   errors.Wrap(entities.ErrForbidden, "api key has no admin scope"),
+			code:  entities.CodeForbidden,
+			cause: entities.ErrForbidden,
+		},
+		{
+			name: "untyped error",
+			err:  errors.New("boom"),
+			code: entities.CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.code, entities.Code(tt.err))
+			if tt.cause != nil {
+				require.ErrorIs(t, tt.err, tt.cause)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package entities
+
+// PriceEvent - событие о новой сохранённой цене монеты; ID - время цены в микросекундах Unix (см. cases.Hub)
+type PriceEvent struct {
+	ID   uint64
+	Coin Coin
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// Gap - промежуток, в котором отсутствуют ожидаемые точки ряда:
+// From и To - моменты первой и последней пропущенной точки
+type Gap struct {
+	From    time.Time
+	To      time.Time
+	Missing int
+}
@@ -0,0 +1,43 @@
+package entities
+
+import "time"
+
+// CircuitState - состояние предохранителя перед провайдером курсов
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+type ProviderStatus struct {
+	State               CircuitState
+	ConsecutiveFailures int
+	LastSuccess         time.Time
+	LastFailure         time.Time
+}
+
+// CoinLag - отставание данных по монете; LastUpdate пустой, если за сегодня данных нет
+type CoinLag struct {
+	Title      string
+	LastUpdate time.Time
+	Lag        time.Duration
+	// Lagging - отставание больше допустимого
+	Lagging bool
+}
+
+// Check - результат одной проверки готовности; Err пустая, если проверка пройдена
+type Check struct {
+	Name string
+	Err  error
+}
+
+type Status struct {
+	// Provider пустой, если сервис работает без провайдера (read-only)
+	Provider      *ProviderStatus
+	LastActualize time.Time
+	Coins         []CoinLag
+	// Degraded - данные хотя бы по одной монете отстают; на готовность реплики не влияет
+	Degraded bool
+}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Interval - шаг исторических данных
+type Interval string
+
+const (
+	IntervalMinute Interval = "1m"
+	IntervalHour   Interval = "1h"
+	IntervalDay    Interval = "1d"
+)
+
+var intervalDurations = map[Interval]time.Duration{
+	IntervalMinute: time.Minute,
+	IntervalHour:   time.Hour,
+	IntervalDay:    24 * time.Hour,
+}
+
+func ParseInterval(s string) (Interval, error) {
+	interval := Interval(s)
+	if _, ok := intervalDurations[interval]; !ok {
+		return "", errors.Wrapf(ErrUnknownInterval, "unknown interval %q, expected one of 1m, 1h, 1d", s)
+	}
+
+	return interval, nil
+}
+
+func (i Interval) Duration() time.Duration {
+	return intervalDurations[i]
+}
@@ -0,0 +1,57 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestParseInterval(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		input    string
+		duration time.Duration
+		wantErr  bool
+		resErr   error
+	}{
+		{
+			name:     "minute",
+			input:    "1m",
+			duration: time.Minute,
+		},
+		{
+			name:     "hour",
+			input:    "1h",
+			duration: time.Hour,
+		},
+		{
+			name:     "day",
+			input:    "1d",
+			duration: 24 * time.Hour,
+		},
+		{
+			name:    "unknown interval",
+			input:   "5m",
+			wantErr: true,
+			resErr:  entities.ErrUnknownInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			interval, err := entities.ParseInterval(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, tt.resErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.duration, interval.Duration())
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// ModeLast - режим запроса последней цены; остальные режимы - агрегатные функции (MAX, MIN, AVG)
+const ModeLast = "LAST"
+
+// RateQuery - элемент пакетного запроса курсов
+type RateQuery struct {
+	Title string
+	Quote string
+	Mode  string
+	// Window - период агрегации до текущего момента; 0 - с начала текущих суток
+	Window time.Duration
+	// MaxAge - допустимый возраст последней цены из хранилища; 0 - любая цена за текущие сутки
+	MaxAge time.Duration
+}
+
+// RateResult - ответ на элемент пакетного запроса: цена или ошибка
+type RateResult struct {
+	Query RateQuery
+	Coin  Coin
+	// Stale - цену не удалось обновить у провайдера, возвращена последняя цена из хранилища
+	Stale bool
+	Err   error
+}
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// RateBudget - token bucket: RPS запросов в секунду в среднем и до Burst подряд
+type RateBudget struct {
+	RPS   float64
+	Burst int
+}
+
+func (b RateBudget) Valid() bool {
+	return b.RPS > 0 && b.Burst > 0
+}
+
+// RetryError - ошибка, после которой запрос можно повторить не раньше, чем через RetryAfter
+type RetryError struct {
+	RetryAfter time.Duration
+	err        error
+}
+
+func NewRetryError(err error, retryAfter time.Duration) error {
+	return &RetryError{RetryAfter: retryAfter, err: err}
+}
+
+func (e *RetryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.err
+}
+
+type clientKey struct{}
+
+// WithClient помечает контекст клиентом API, от имени которого выполняется запрос;
+// у плановых задач клиента нет
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+func ClientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Symbol - тикер монеты в нормализованном виде: заглавные латинские буквы и цифры, например BTC или 1INCH
+type Symbol string
+
+// symbolMaxLen совпадает с шириной колонок title (VARCHAR(10)) в coins и роллапах
+const symbolMaxLen = 10
+
+// symbolAliases - тикеры, под которыми одну монету знают разные биржи; храним и запрашиваем у провайдера основной
+var symbolAliases = map[string]Symbol{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// SymbolsError перечисляет неверные тикеры из запроса, чтобы клиент мог исправить их все сразу
+type SymbolsError struct {
+	Invalid []string
+	err     error
+}
+
+func (e *SymbolsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *SymbolsError) Unwrap() error {
+	return e.err
+}
+
+// ParseSymbol приводит тикер к верхнему регистру без пробелов, заменяет псевдоним основным тикером и проверяет его
+func ParseSymbol(raw string) (Symbol, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if !validSymbol(s) {
+		return "", errors.Wrapf(ErrInvalidSymbol, "invalid symbol %q: expected 1-%d latin letters and digits", raw, symbolMaxLen)
+	}
+
+	if alias, ok := symbolAliases[s]; ok {
+		return alias, nil
+	}
+
+	return Symbol(s), nil
+}
+
+// ParseSymbols разбирает список тикеров: пустые элементы пропускаются, повторы (в том числе через псевдоним)
+// убираются с сохранением порядка. Ошибка перечисляет все неверные тикеры, а не только первый
+func ParseSymbols(raw []string) ([]Symbol, error) {
+	symbols := make([]Symbol, 0, len(raw))
+	seen := make(map[Symbol]struct{}, len(raw))
+	var invalid []string
+	for _, r := range raw {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+
+		s, err := ParseSymbol(r)
+		if err != nil {
+			invalid = append(invalid, strings.TrimSpace(r))
+			continue
+		}
+
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		symbols = append(symbols, s)
+	}
+
+	if len(invalid) > 0 {
+		return nil, &SymbolsError{
+			Invalid: invalid,
+			err:     errors.Wrapf(ErrInvalidSymbol, "invalid symbol(s) %q: expected 1-%d latin letters and digits", invalid, symbolMaxLen),
+		}
+	}
+
+	return symbols, nil
+}
+
+// NormalizeTitles - ParseSymbols для слоёв, которые работают с тикерами как со строками
+func NormalizeTitles(raw []string) ([]string, error) {
+	symbols, err := ParseSymbols(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		titles = append(titles, string(s))
+	}
+
+	return titles, nil
+}
+
+func validSymbol(s string) bool {
+	if len(s) == 0 || len(s) > symbolMaxLen {
+		return false
+	}
+
+	for _, c := range s {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+func TestParseSymbol(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   string
+		symbol  entities.Symbol
+		wantErr bool
+	}{
+		{name: "upper case", input: "BTC", symbol: "BTC"},
+		{name: "lower case with spaces", input: " eth ", symbol: "ETH"},
+		{name: "digits", input: "1inch", symbol: "1INCH"},
+		{name: "alias", input: "xbt", symbol: "BTC"},
+		{name: "empty", input: " ", wantErr: true},
+		{name: "wrong charset", input: "BTC-USD", wantErr: true},
+		{name: "max length", input: "ABCDEFGHIJ", symbol: "ABCDEFGHIJ"},
+		{name: "too long", input: "ABCDEFGHIJK", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			symbol, err := entities.ParseSymbol(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, entities.ErrInvalidSymbol)
+				require.ErrorIs(t, err, entities.ErrInvalidParam)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.symbol, symbol)
+		})
+	}
+}
+
+func TestParseSymbols(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   []string
+		symbols []entities.Symbol
+		invalid []string
+	}{
+		{name: "trims and skips empty items", input: []string{"BTC", " ETH", "", " "}, symbols: []entities.Symbol{"BTC", "ETH"}},
+		{name: "dedupes with aliases", input: []string{"btc", "ETH", "XBT", "BTC"}, symbols: []entities.Symbol{"BTC", "ETH"}},
+		{name: "nothing given", input: []string{""}, symbols: []entities.Symbol{}},
+		{name: "lists all invalid symbols", input: []string{"BTC", "B$C", "ETH", "<script>"}, invalid: []string{"B$C", "<script>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			symbols, err := entities.ParseSymbols(tt.input)
+			if tt.invalid != nil {
+				require.ErrorIs(t, err, entities.ErrInvalidSymbol)
+				var symbolsErr *entities.SymbolsError
+				require.ErrorAs(t, err, &symbolsErr)
+				require.Equal(t, tt.invalid, symbolsErr.Invalid)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.symbols, symbols)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+const namespace = "cryptorate"
+
+// resultOK - значение метки result для успешных операций; для ошибок в метку пишется код ошибки
+const resultOK = "ok"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	providerCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_total",
+		Help:      "Rates provider calls by provider, method and result.",
+	}, []string{"provider", "method", "result"})
+
+	providerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_request_duration_seconds",
+		Help:      "Rates provider call latency by provider and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "method"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rates_cache_lookups_total",
+		Help:      "Coins requested from GetLastRates found in storage (hit) or fetched from the provider (miss).",
+	}, []string{"result"})
+
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_query_duration_seconds",
+		Help:      "Storage query latency by query.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	storedRows = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "actualize_stored_rows",
+		Help:      "Rows stored per actualize run.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	lastIngestion = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ingestion_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful rate ingestion per coin.",
+	}, []string{"title"})
+
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_job_runs_total",
+		Help:      "Scheduled job runs by job and result.",
+	}, []string{"job", "result"})
+)
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest учитывает обработанный HTTP-запрос; route - шаблон маршрута, а не путь,
+// чтобы число рядов не зависело от параметров запроса
+func ObserveHTTPRequest(route, method string, status int, start time.Time) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpDuration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProviderCall учитывает обращение к провайдеру курсов
+func ObserveProviderCall(provider, method string, start time.Time, err error) {
+	providerCalls.WithLabelValues(provider, method, result(err)).Inc()
+	providerDuration.WithLabelValues(provider, method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCacheLookups учитывает монеты, найденные в хранилище и запрошенные у провайдера
+func ObserveCacheLookups(hits, misses int) {
+	cacheLookups.WithLabelValues("hit").Add(float64(hits))
+	cacheLookups.WithLabelValues("miss").Add(float64(misses))
+}
+
+// ObserveStorageQuery учитывает длительность запроса к хранилищу; удобно вызывать через defer
+func ObserveStorageQuery(query string, start time.Time) {
+	storageDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
+
+// ObserveActualize учитывает число записей, сохранённых за один проход актуализации
+func ObserveActualize(rows int) {
+	storedRows.Observe(float64(rows))
+}
+
+// ObserveIngestion отмечает успешное сохранение свежих цен монет
+func ObserveIngestion(coins []entities.Coin) {
+	now := float64(time.Now().Unix())
+	for _, coin := range coins {
+		lastIngestion.WithLabelValues(coin.Title).Set(now)
+	}
+}
+
+// ObserveJob учитывает результат запуска плановой задачи
+func ObserveJob(job string, err error) {
+	jobRuns.WithLabelValues(job, result(err)).Inc()
+}
+
+func result(err error) string {
+	if err == nil {
+		return resultOK
+	}
+
+	return string(entities.Code(err))
+}
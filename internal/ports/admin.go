@@ -0,0 +1,151 @@
+package ports
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// @Summary Find gaps
+// @Description Find missing intervals in the stored series of a tracked coin; the range is limited by the max-query-range setting
+// @Tags admin
+// @Produce json,application/problem+json
+// @Param title query string true "Coin title" example(BTC)
+// @Param interval query string false "Expected sampling interval (1m, 1h, 1d)" default(1m)
+// @Param from query string false "Range start, RFC3339 (default: 24h before 'to')"
+// @Param to query string false "Range end, RFC3339 (default: now)"
+// @Success 200 {object} dto.GapsRespDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/gaps [get]
+func (s *Server) GetGaps(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.GetGaps)")
+	q, err := parseRangeQuery(r)
+	if err != nil {
+		log.Warn("(server.GetGaps) invalid query parameters", zap.Any("err", err.Error()))
+		respondWithProblem(rw, r, err)
+		return
+	}
+	r = withTitles(r, q.title)
+
+	if err := s.checkQueryRange(q, false); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	gaps, err := s.admin.FindGaps(r.Context(), q.title, q.interval, q.from, q.to)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	response := dto.GapsRespDTO{
+		Title:    q.title,
+		Interval: string(q.interval),
+		Gaps:     make([]dto.GapDTO, 0, len(gaps)),
+	}
+	for _, gap := range gaps {
+		response.Gaps = append(response.Gaps, dto.GapDTO{From: gap.From, To: gap.To, Missing: gap.Missing})
+	}
+
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// @Summary Repair gaps
+// @Description Fill missing intervals of a tracked coin from the provider history; repaired rows are marked as backfilled
+// @Tags admin
+// @Produce json,application/problem+json
+// @Param title query string true "Coin title" example(BTC)
+// @Param interval query string false "Expected sampling interval (1m, 1h, 1d)" default(1m)
+// @Param from query string false "Range start, RFC3339 (default: 24h before 'to')"
+// @Param to query string false "Range end, RFC3339 (default: now)"
+// @Success 200 {object} dto.RepairRespDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/gaps/repair [post]
+func (s *Server) RepairGaps(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.RepairGaps)")
+	q, err := parseRangeQuery(r)
+	if err != nil {
+		log.Warn("(server.RepairGaps) invalid query parameters", zap.Any("err", err.Error()))
+		respondWithProblem(rw, r, err)
+		return
+	}
+	r = withTitles(r, q.title)
+
+	repaired, err := s.admin.RepairGaps(r.Context(), q.title, q.interval, q.from, q.to)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	respondWithJSON(rw, http.StatusOK, dto.RepairRespDTO{
+		Title:    q.title,
+		Interval: string(q.interval),
+		Repaired: repaired,
+	})
+}
+
+// @Summary Get log level
+// @Description Get the current logging level
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.LogLevelDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/log-level [get]
+func (s *Server) GetLogLevel(rw http.ResponseWriter, r *http.Request) {
+	respondWithJSON(rw, http.StatusOK, dto.LogLevelDTO{Level: logger.Level()})
+}
+
+// @Summary Set log level
+// @Description Change the logging level at runtime without restart (debug, info, warn, error)
+// @Tags admin
+// @Accept json
+// @Produce json,application/problem+json
+// @Param request body dto.LogLevelDTO true "New logging level"
+// @Success 200 {object} dto.LogLevelDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/log-level [put]
+func (s *Server) SetLogLevel(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	var req dto.LogLevelDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidParam, "request body must be a JSON object with 'level'"))
+		return
+	}
+
+	if err := logger.SetLevel(req.Level); err != nil {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidParam, err.Error()))
+		return
+	}
+
+	log.Warn("(server.SetLogLevel) log level changed", zap.Any("level", logger.Level()))
+	respondWithJSON(rw, http.StatusOK, dto.LogLevelDTO{Level: logger.Level()})
+}
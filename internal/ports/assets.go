@@ -0,0 +1,83 @@
+package ports
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const defaultAssetsLimit = 50
+
+// SetAssets включает GET /api/v1/assets; без каталога маршрут отвечает 404
+func (s *Server) SetAssets(assets AssetService) {
+	s.assets = assets
+}
+
+// @Summary Search assets
+// @Description Search the catalog of known coins by symbol prefix or part of the name. The catalog is synced periodically from the provider coin list; coins that are not active in it are rejected before any provider call
+// @Tags v1
+// @Produce json,application/problem+json
+// @Param search query string false "Symbol prefix or part of the coin name (default: all coins)" example(bit)
+// @Param limit query int false "Maximum number of assets (default: 50, max: 500)"
+// @Success 200 {object} dto.EnvelopeDTO{data=[]dto.AssetDTO}
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/assets [get]
+func (s *Server) GetAssetsV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.GetAssetsV1)")
+	if s.assets == nil {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrNotFound, "asset catalog is disabled"))
+		return
+	}
+
+	limit := defaultAssetsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidParam, "invalid 'limit' query parameter, expected an integer"))
+			return
+		}
+		limit = n
+	}
+
+	assets, err := s.assets.Search(r.Context(), r.URL.Query().Get("search"), limit)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	response := make([]dto.AssetDTO, 0, len(assets))
+	for _, a := range assets {
+		response = append(response, toAssetDTO(a))
+	}
+
+	respondWithEnvelope(rw, r, response)
+}
+
+func toAssetDTO(a entities.Asset) dto.AssetDTO {
+	ids := a.ProviderIDs
+	if ids == nil {
+		ids = map[string]string{}
+	}
+
+	return dto.AssetDTO{
+		Symbol:      string(a.Symbol),
+		Name:        a.Name,
+		ProviderIDs: ids,
+		Decimals:    a.Decimals,
+		Status:      string(a.Status),
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
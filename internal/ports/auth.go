@@ -0,0 +1,131 @@
+package ports
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	// браузерный EventSource не умеет передавать заголовки, поэтому для подписок ключ принимается и в запросе
+	apiKeyQueryParam = "api_key"
+
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// SetAuth включает проверку API-ключей; без неё API открыто
+func (s *Server) SetAuth(auth AuthService) {
+	s.auth = auth
+}
+
+// requireScope пропускает только запросы с действующим ключом, выданным со scope, и отдаёт клиенту
+// состояние его лимита в заголовках X-RateLimit-*; respond - формат ошибки для группы маршрутов
+func (s *Server) requireScope(scope entities.Scope, respond func(rw http.ResponseWriter, r *http.Request, err error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if s.auth == nil {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			token := requestAPIKey(r, scope == entities.ScopeStream)
+			key, limit, err := s.auth.Authorize(r.Context(), token, scope)
+			if limit.Limit > 0 {
+				setRateLimitHeaders(rw.Header(), limit)
+			}
+			if err != nil {
+				if errors.Is(err, entities.ErrUnauthorized) {
+					rw.Header().Set("WWW-Authenticate", `ApiKey header="`+apiKeyHeader+`"`)
+				}
+				respond(rw, r, err)
+				return
+			}
+
+			ctx := logger.With(r.Context(), zap.Int64("api_key_id", key.ID))
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestAPIKey ищет ключ в X-API-Key, затем в Authorization: Bearer
+func requestAPIKey(r *http.Request, allowQuery bool) string {
+	if token := r.Header.Get(apiKeyHeader); token != "" {
+		return token
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	if allowQuery {
+		return r.URL.Query().Get(apiKeyQueryParam)
+	}
+
+	return ""
+}
+
+func setRateLimitHeaders(h http.Header, limit entities.RateLimit) {
+	h.Set(rateLimitLimitHeader, strconv.Itoa(limit.Limit))
+	h.Set(rateLimitRemainingHeader, strconv.Itoa(limit.Remaining))
+	h.Set(rateLimitResetHeader, strconv.FormatInt(limit.Reset.Unix(), 10))
+}
+
+// UnaryAuth и StreamAuth - проверка API-ключей для gRPC: ключ передаётся в метаданных x-api-key
+// или authorization: Bearer; вызовам нужен scope read, подпискам - stream
+func UnaryAuth(auth AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorizeGRPC(ctx, auth, entities.ScopeRead, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) })
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuth(auth AuthService) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorizeGRPC(ss.Context(), auth, entities.ScopeStream, ss.SetHeader)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authorizeGRPC(ctx context.Context, auth AuthService, scope entities.Scope, setHeader func(metadata.MD) error) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	if values := md.Get(strings.ToLower(apiKeyHeader)); len(values) > 0 {
+		token = values[0]
+	} else if values := md.Get("authorization"); len(values) > 0 {
+		token, _ = strings.CutPrefix(values[0], "Bearer ")
+	}
+
+	key, limit, err := auth.Authorize(ctx, token, scope)
+	if limit.Limit > 0 {
+		_ = setHeader(metadata.Pairs(
+			strings.ToLower(rateLimitLimitHeader), strconv.Itoa(limit.Limit),
+			strings.ToLower(rateLimitRemainingHeader), strconv.Itoa(limit.Remaining),
+			strings.ToLower(rateLimitResetHeader), strconv.FormatInt(limit.Reset.Unix(), 10),
+		))
+	}
+	if err != nil {
+		return ctx, grpcError(ctx, err)
+	}
+
+	return logger.With(ctx, zap.Int64("api_key_id", key.ID)), nil
+}
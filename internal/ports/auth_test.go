@@ -0,0 +1,167 @@
+package ports_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+// keyring - выпуск ключей для тестового сервера с включённой проверкой ключей; ключи хранятся в памяти мока
+type keyring struct {
+	auth *cases.Auth
+
+	mu   sync.Mutex
+	keys map[string]entities.APIKey
+}
+
+func newAuthServer(t *testing.T) (*testServer, *keyring) {
+	t.Helper()
+	storage := mock_cases.NewMockKeyStorage(gomock.NewController(t))
+	auth, err := cases.NewAuth(storage, time.Minute, 100)
+	require.NoError(t, err)
+
+	ring := &keyring{auth: auth, keys: make(map[string]entities.APIKey)}
+	storage.EXPECT().CreateKey(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, key entities.APIKey) (entities.APIKey, error) {
+			ring.mu.Lock()
+			defer ring.mu.Unlock()
+			key.ID = int64(len(ring.keys) + 1)
+			key.CreatedAt = time.Now()
+			ring.keys[key.Hash] = key
+			return key, nil
+		})
+	storage.EXPECT().GetKeyByHash(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, hash string) (entities.APIKey, error) {
+			ring.mu.Lock()
+			defer ring.mu.Unlock()
+			key, ok := ring.keys[hash]
+			if !ok {
+				return entities.APIKey{}, entities.ErrNotFound
+			}
+			return key, nil
+		})
+
+	srv := newTestServer(t)
+	srv.SetAuth(auth)
+
+	return srv, ring
+}
+
+// issue выпускает ключ; revoked - ключ отозван ещё до первого запроса
+func (k *keyring) issue(t *testing.T, rateLimit int, revoked bool, scopes ...entities.Scope) string {
+	t.Helper()
+	key, token, err := k.auth.IssueKey(context.Background(), "test", scopes, rateLimit)
+	require.NoError(t, err)
+
+	if revoked {
+		k.mu.Lock()
+		now := time.Now()
+		key.RevokedAt = &now
+		k.keys[key.Hash] = key
+		k.mu.Unlock()
+	}
+
+	return token
+}
+
+func TestRequireScope(t *testing.T) {
+	t.Parallel()
+	srv, ring := newAuthServer(t)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now()}}))
+
+	read := ring.issue(t, 0, false, entities.ScopeRead)
+	stream := ring.issue(t, 0, false, entities.ScopeStream)
+	admin := ring.issue(t, 0, false, entities.ScopeAdmin)
+	revoked := ring.issue(t, 0, true, entities.ScopeRead)
+
+	tests := []struct {
+		name   string
+		url    string
+		header string
+		token  string
+		want   int
+	}{
+		{name: "no key", url: "/api/v1/rates/last?titles=BTC", want: http.StatusUnauthorized},
+		{name: "unknown key", url: "/api/v1/rates/last?titles=BTC", header: "X-API-Key", token: "cr_unknown", want: http.StatusUnauthorized},
+		{name: "revoked key", url: "/api/v1/rates/last?titles=BTC", header: "X-API-Key", token: revoked, want: http.StatusUnauthorized},
+		{name: "read key in header", url: "/api/v1/rates/last?titles=BTC", header: "X-API-Key", token: read, want: http.StatusOK},
+		{name: "read key as bearer token", url: "/api/v1/rates/last?titles=BTC", header: "Authorization", token: "Bearer " + read, want: http.StatusOK},
+		{name: "key in query is only for streams", url: "/api/v1/rates/last?titles=BTC&api_key=" + read, want: http.StatusUnauthorized},
+		// запрос без titles прошёл проверку ключа и отклонён уже обработчиком
+		{name: "stream key in query", url: "/api/v1/stream?api_key=" + stream, want: http.StatusBadRequest},
+		{name: "read key cannot stream", url: "/api/v1/stream?titles=BTC", header: "X-API-Key", token: read, want: http.StatusForbidden},
+		{name: "stream key cannot read", url: "/api/v1/rates/last?titles=BTC", header: "X-API-Key", token: stream, want: http.StatusForbidden},
+		{name: "read key cannot administer", url: "/admin/log-level", header: "X-API-Key", token: read, want: http.StatusForbidden},
+		{name: "admin key", url: "/admin/log-level", header: "X-API-Key", token: admin, want: http.StatusOK},
+		{name: "probes are open", url: "/healthz", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.token)
+			}
+
+			resp := srv.do(req)
+			require.Equal(t, tt.want, resp.Code)
+			if tt.want == http.StatusUnauthorized {
+				require.Equal(t, `ApiKey header="X-API-Key"`, resp.Header().Get("WWW-Authenticate"))
+				require.Equal(t, "unauthorized", decodeJSON[dto.ProblemDTO](t, resp).Code)
+			}
+		})
+	}
+}
+
+func TestRequireScope_LegacyErrors(t *testing.T) {
+	t.Parallel()
+	srv, _ := newAuthServer(t)
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/rates/last?titles=BTC", nil))
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+	require.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	body := decodeJSON[dto.ErrRespDTO](t, resp)
+	require.Equal(t, http.StatusUnauthorized, body.StatusCode)
+	require.Equal(t, "unauthorized", body.Code)
+}
+
+func TestRequireScope_RateLimit(t *testing.T) {
+	t.Parallel()
+	srv, ring := newAuthServer(t)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now()}}))
+	token := ring.issue(t, 2, false, entities.ScopeRead)
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rates/last?titles=BTC", nil)
+		req.Header.Set("X-API-Key", token)
+		return srv.do(req)
+	}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		resp := request()
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "2", resp.Header().Get("X-RateLimit-Limit"))
+		require.Equal(t, strconv.Itoa(remaining), resp.Header().Get("X-RateLimit-Remaining"))
+		require.NotEmpty(t, resp.Header().Get("X-RateLimit-Reset"))
+	}
+
+	resp := request()
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	require.Equal(t, "0", resp.Header().Get("X-RateLimit-Remaining"))
+	require.NotEmpty(t, resp.Header().Get("Retry-After"))
+	require.Equal(t, "rate_limited", decodeJSON[dto.ProblemDTO](t, resp).Code)
+}
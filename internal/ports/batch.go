@@ -0,0 +1,127 @@
+package ports
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const maxQueryBodyBytes = 1 << 20
+
+// @Summary Batch rates query
+// @Description Get rates for a list of items in one request. Every item has a title, an optional quote (USD),
+// @Description a mode (last - default, max, min, avg), an aggregation window and a max age of the last price as Go durations.
+// @Description Without a window aggregates are calculated since the start of the day; a last price older than max_age is refreshed from the provider.
+// @Description If the provider cannot refresh it, the stored price is returned with stale: true.
+// @Description Results follow the order of items; an item that failed carries a problem description instead of a rate.
+// @Tags v1
+// @Accept json
+// @Produce json,application/problem+json
+// @Param query body dto.RateQueryReqDTO true "Items to query"
+// @Success 200 {object} dto.EnvelopeDTO{data=[]dto.RateQueryResultDTO}
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/rates/query [post]
+func (s *Server) QueryRatesV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.QueryRatesV1)")
+	var req dto.RateQueryReqDTO
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxQueryBodyBytes)).Decode(&req); err != nil {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidParam, "request body must be a JSON object with 'items'"))
+		return
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > cases.MaxQueryItems {
+		respondWithProblem(rw, r, errors.Wrapf(entities.ErrInvalidParam, "query must contain from 1 to %d items", cases.MaxQueryItems))
+		return
+	}
+
+	response := make([]dto.RateQueryResultDTO, len(req.Items))
+	queries := make([]entities.RateQuery, 0, len(req.Items))
+	// индексы элементов, переданных в сервис; остальные не прошли разбор
+	positions := make([]int, 0, len(req.Items))
+	titles := make([]string, 0, len(req.Items))
+	for i, item := range req.Items {
+		response[i] = dto.RateQueryResultDTO{Title: item.Title, Quote: item.Quote, Mode: item.Mode}
+
+		q, err := toRateQuery(item)
+		if err != nil {
+			response[i].Error = itemProblem(r, err)
+			continue
+		}
+
+		queries = append(queries, q)
+		positions = append(positions, i)
+		titles = append(titles, q.Title)
+	}
+
+	if len(queries) > 0 {
+		r = withTitles(r, titles...)
+		results, err := s.service.QueryRates(r.Context(), queries)
+		if err != nil {
+			respondWithProblem(rw, r, err)
+			return
+		}
+
+		for j, res := range results {
+			item := &response[positions[j]]
+			item.Title = res.Query.Title
+			item.Quote = res.Query.Quote
+			item.Mode = strings.ToLower(res.Query.Mode)
+			if res.Err != nil {
+				item.Error = itemProblem(r, res.Err)
+				continue
+			}
+
+			rate := toRateDTOs([]entities.Coin{res.Coin}, s.service.Quote())[0]
+			item.Rate = &rate
+			item.Stale = res.Stale
+		}
+	}
+
+	respondWithEnvelope(rw, r, response)
+}
+
+func itemProblem(r *http.Request, err error) *dto.ProblemDTO {
+	_, problem := newProblem(err, r)
+	return &problem
+}
+
+func toRateQuery(item dto.RateQueryItemDTO) (entities.RateQuery, error) {
+	q := entities.RateQuery{
+		Title: item.Title,
+		Quote: item.Quote,
+		Mode:  item.Mode,
+	}
+
+	if item.Window != "" {
+		window, err := time.ParseDuration(item.Window)
+		if err != nil {
+			return q, errors.Wrapf(entities.ErrInvalidRange, "invalid window %q, expected a duration like 6h", item.Window)
+		}
+		q.Window = window
+	}
+
+	if item.MaxAge != "" {
+		maxAge, err := time.ParseDuration(item.MaxAge)
+		if err != nil {
+			return q, errors.Wrapf(entities.ErrInvalidParam, "invalid max_age %q, expected a duration like 2m", item.MaxAge)
+		}
+		q.MaxAge = maxAge
+	}
+
+	return q, nil
+}
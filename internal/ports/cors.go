@@ -0,0 +1,80 @@
+package ports
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	corsAllowMethods = "GET, POST, PUT, DELETE"
+	corsAllowHeaders = apiKeyHeader + ", Authorization, Content-Type, " + lastEventIDParam
+)
+
+// corsExposeHeaders - заголовки ответа, которые браузер отдаёт скрипту с другого источника
+var corsExposeHeaders = strings.Join([]string{
+	middleware.RequestIDHeader, "Retry-After", rateLimitLimitHeader, rateLimitRemainingHeader, rateLimitResetHeader,
+}, ", ")
+
+// corsPolicy - источники, которым браузер разрешает обращаться к API; "*" разрешает любой
+type corsPolicy struct {
+	origins   map[string]bool
+	anyOrigin bool
+	maxAge    time.Duration
+}
+
+// SetCORS задаёт источники для CORS и время, на которое браузер запоминает ответ на предварительный запрос.
+// Пустой список отключает CORS. Безопасно вызывать во время работы сервера - при перезагрузке конфига
+func (s *Server) SetCORS(origins []string, maxAge time.Duration) {
+	if len(origins) == 0 {
+		s.cors.Store(nil)
+		return
+	}
+
+	policy := &corsPolicy{origins: make(map[string]bool, len(origins)), maxAge: maxAge}
+	for _, origin := range origins {
+		if origin == "*" {
+			policy.anyOrigin = true
+		}
+		policy.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	s.cors.Store(policy)
+}
+
+// handleCORS добавляет заголовки CORS для разрешённых источников и сам отвечает на предварительные запросы,
+// чтобы они не расходовали лимит и не требовали ключа
+func (s *Server) handleCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		policy := s.cors.Load()
+		if origin == "" || policy == nil {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		h := rw.Header()
+		h.Add("Vary", "Origin")
+		if !policy.anyOrigin && !policy.origins[origin] {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			if policy.maxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.maxAge.Seconds())))
+			}
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
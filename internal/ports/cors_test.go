@@ -0,0 +1,74 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		origins    []string
+		origin     string
+		preflight  bool
+		wantStatus int
+		wantOrigin string
+	}{
+		{name: "disabled", origin: "https://app.example.com", wantStatus: http.StatusOK},
+		{name: "allowed origin", origins: []string{"https://app.example.com"}, origin: "https://app.example.com", wantStatus: http.StatusOK, wantOrigin: "https://app.example.com"},
+		{name: "other origin", origins: []string{"https://app.example.com"}, origin: "https://evil.example.com", wantStatus: http.StatusOK},
+		{name: "any origin", origins: []string{"*"}, origin: "https://evil.example.com", wantStatus: http.StatusOK, wantOrigin: "https://evil.example.com"},
+		{name: "same origin request", origins: []string{"*"}, wantStatus: http.StatusOK},
+		{name: "preflight", origins: []string{"https://app.example.com/"}, origin: "https://app.example.com", preflight: true, wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			srv.SetCORS(tt.origins, 10*time.Minute)
+
+			method := http.MethodGet
+			if tt.preflight {
+				method = http.MethodOptions
+			}
+			req := httptest.NewRequest(method, "/healthz", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+
+			resp := srv.do(req)
+			require.Equal(t, tt.wantStatus, resp.Code)
+			require.Equal(t, tt.wantOrigin, resp.Header().Get("Access-Control-Allow-Origin"))
+			if tt.preflight {
+				require.Contains(t, resp.Header().Get("Access-Control-Allow-Headers"), "X-API-Key")
+				require.Equal(t, "600", resp.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
+
+func TestCORS_Reload(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	request := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		return req
+	}
+
+	srv.SetCORS([]string{"https://app.example.com"}, 0)
+	require.Equal(t, "https://app.example.com", srv.do(request()).Header().Get("Access-Control-Allow-Origin"))
+
+	// пустой список при перезагрузке конфига отключает CORS
+	srv.SetCORS(nil, 0)
+	require.Empty(t, srv.do(request()).Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,145 @@
+package ports
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const problemContentType = "application/problem+json"
+
+type problem struct {
+	status int
+	title  string
+}
+
+// problems - единая таблица соответствия кодов ошибок HTTP-статусам
+var problems = map[entities.ErrorCode]problem{
+	entities.CodeInvalidParam:        {http.StatusBadRequest, "Invalid parameter"},
+	entities.CodeInvalidSymbol:       {http.StatusBadRequest, "Invalid symbol"},
+	entities.CodeInvalidRange:        {http.StatusBadRequest, "Invalid time range"},
+	entities.CodeUnknownAggregate:    {http.StatusBadRequest, "Unknown aggregate function"},
+	entities.CodeUnknownInterval:     {http.StatusBadRequest, "Unknown interval"},
+	entities.CodeReadOnly:            {http.StatusConflict, "Not available in read-only mode"},
+	entities.CodeNotFound:            {http.StatusNotFound, "Not found"},
+	entities.CodeUnknownSymbol:       {http.StatusNotFound, "Unknown symbol"},
+	entities.CodeRateLimited:         {http.StatusTooManyRequests, "Rate limit exceeded"},
+	entities.CodeUnauthorized:        {http.StatusUnauthorized, "API key required"},
+	entities.CodeForbidden:           {http.StatusForbidden, "API key not allowed"},
+	entities.CodeProviderUnavailable: {http.StatusBadGateway, "Rates provider unavailable"},
+	entities.CodeStorageUnavailable:  {http.StatusServiceUnavailable, "Storage unavailable"},
+	entities.CodeStaleData:           {http.StatusServiceUnavailable, "Data is stale"},
+	entities.CodeInternal:            {http.StatusInternalServerError, "Internal error"},
+}
+
+func lookupProblem(err error) (entities.ErrorCode, problem) {
+	code := entities.Code(err)
+	p, ok := problems[code]
+	if !ok {
+		return entities.CodeInternal, problems[entities.CodeInternal]
+	}
+
+	return code, p
+}
+
+// publicDetail возвращает пояснение из места возникновения клиентской ошибки без контекста
+// внутренних слоёв; тексты серверных ошибок наружу не отдаются
+func publicDetail(err error, p problem) string {
+	if p.status >= http.StatusInternalServerError {
+		return ""
+	}
+
+	for cur := err; cur != nil; cur = errors.Unwrap(cur) {
+		next := errors.Unwrap(cur)
+		if next == nil {
+			break
+		}
+
+		if _, typed := next.(*entities.Error); typed || errors.Unwrap(next) == nil {
+			return strings.TrimSuffix(cur.Error(), ": "+next.Error())
+		}
+	}
+
+	return ""
+}
+
+func logProblem(ctx context.Context, err error, p problem) {
+	log := logger.FromContext(ctx)
+	if p.status >= http.StatusInternalServerError {
+		log.Error("(server) request failed", zap.Any("err", err.Error()))
+		return
+	}
+
+	log.Warn("(server) bad request", zap.Any("err", err.Error()))
+}
+
+func newProblem(err error, r *http.Request) (int, dto.ProblemDTO) {
+	code, p := lookupProblem(err)
+	logProblem(r.Context(), err, p)
+
+	problem := dto.ProblemDTO{
+		Type:      "about:blank",
+		Title:     p.title,
+		Status:    p.status,
+		Detail:    publicDetail(err, p),
+		Instance:  r.URL.Path,
+		Code:      string(code),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+
+	var symbolsErr *entities.SymbolsError
+	if errors.As(err, &symbolsErr) {
+		problem.InvalidSymbols = symbolsErr.Invalid
+	}
+
+	return p.status, problem
+}
+
+// respondWithProblem отвечает ошибкой в формате RFC 7807
+func respondWithProblem(rw http.ResponseWriter, r *http.Request, err error) {
+	status, problem := newProblem(err, r)
+	setRetryAfter(rw.Header(), err)
+
+	rw.Header().Set("Content-Type", problemContentType)
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(problem); err != nil {
+		log.Error("(respondWithProblem) failed to encode response", zap.Any("err", err.Error()))
+	}
+}
+
+// respondWithError отвечает ошибкой в прежнем формате для маршрутов без версии
+func respondWithError(rw http.ResponseWriter, r *http.Request, err error) {
+	code, p := lookupProblem(err)
+	logProblem(r.Context(), err, p)
+
+	msg := publicDetail(err, p)
+	if msg == "" {
+		msg = p.title
+	}
+	setRetryAfter(rw.Header(), err)
+
+	respondWithJSON(rw, p.status, dto.ErrRespDTO{
+		StatusCode: p.status,
+		Code:       string(code),
+		Msg:        msg,
+	})
+}
+
+// setRetryAfter сообщает клиенту, через сколько секунд можно повторить запрос, если ошибка это знает
+func setRetryAfter(h http.Header, err error) {
+	var retry *entities.RetryError
+	if errors.As(err, &retry) {
+		h.Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retry.RetryAfter.Seconds())))))
+	}
+}
@@ -0,0 +1,110 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+func TestProblemResponses(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		url         string
+		providerErr error
+		wantStatus  int
+		wantCode    entities.ErrorCode
+		wantDetail  string
+		wantInvalid []string
+	}{
+		{
+			name:       "missing titles",
+			url:        "/api/v1/rates/last",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   entities.CodeInvalidSymbol,
+			wantDetail: "missing 'titles' query parameter",
+		},
+		{
+			name:        "invalid symbols are listed",
+			url:         "/api/v1/rates/last?titles=BTC,B$C,<script>",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    entities.CodeInvalidSymbol,
+			wantDetail:  `invalid symbol(s) ["B$C" "<script>"]: expected 1-10 latin letters and digits`,
+			wantInvalid: []string{"B$C", "<script>"},
+		},
+		{
+			name:       "missing aggregate function",
+			url:        "/api/v1/rates/agg?titles=BTC",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   entities.CodeUnknownAggregate,
+			wantDetail: "missing 'aggFunc' query parameter",
+		},
+		{
+			name:        "unknown symbol",
+			url:         "/api/v1/rates/last?titles=NOPE",
+			providerErr: errors.Wrap(entities.ErrUnknownSymbol, "coin [NOPE] does not exist"),
+			wantStatus:  http.StatusNotFound,
+			wantCode:    entities.CodeUnknownSymbol,
+			wantDetail:  "coin [NOPE] does not exist",
+		},
+		{
+			name:        "provider down hides the cause",
+			url:         "/api/v1/rates/last?titles=BTC",
+			providerErr: errors.Wrap(entities.ErrProviderUnavailable, "failed to execute request, err: dial tcp 10.0.0.1:443"),
+			wantStatus:  http.StatusBadGateway,
+			wantCode:    entities.CodeProviderUnavailable,
+		},
+		{
+			name:        "untyped error is internal",
+			url:         "/api/v1/rates/last?titles=BTC",
+			providerErr: errors.New("boom"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    entities.CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			if tt.providerErr != nil {
+				srv.provider.EXPECT().GetActualRates(gomock.Any(), gomock.Any(), "PRICE").Return(nil, tt.providerErr)
+			}
+
+			resp := srv.do(httptest.NewRequest(http.MethodGet, tt.url, nil))
+			require.Equal(t, tt.wantStatus, resp.Code)
+			require.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+
+			problem := decodeJSON[dto.ProblemDTO](t, resp)
+			require.Equal(t, tt.wantStatus, problem.Status)
+			require.Equal(t, string(tt.wantCode), problem.Code)
+			require.Equal(t, tt.wantDetail, problem.Detail)
+			require.Equal(t, tt.wantInvalid, problem.InvalidSymbols)
+			require.NotEmpty(t, problem.Title)
+			require.Equal(t, resp.Header().Get(middleware.RequestIDHeader), problem.RequestID)
+			require.Equal(t, httptest.NewRequest(http.MethodGet, tt.url, nil).URL.Path, problem.Instance)
+		})
+	}
+}
+
+func TestLegacyErrorResponse(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/rates/agg?titles=BTC", nil))
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	body := decodeJSON[dto.ErrRespDTO](t, resp)
+	require.Equal(t, http.StatusBadRequest, body.StatusCode)
+	require.Equal(t, string(entities.CodeInvalidParam), body.Code)
+	require.Equal(t, "missing 'titles' or 'aggFunc' query parameters", body.Msg)
+}
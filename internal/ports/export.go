@@ -0,0 +1,213 @@
+package ports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// exportFormat - формат ответа эндпоинтов временных рядов
+type exportFormat string
+
+const (
+	formatJSON   exportFormat = "json"
+	formatCSV    exportFormat = "csv"
+	formatNDJSON exportFormat = "ndjson"
+)
+
+var exportContentTypes = map[exportFormat]string{
+	formatCSV:    "text/csv; charset=utf-8",
+	formatNDJSON: "application/x-ndjson",
+}
+
+// exportFlushRows - через сколько строк выгрузки данные проталкиваются клиенту
+const exportFlushRows = 1000
+
+var (
+	historyColumns = []string{"time", "price", "backfilled"}
+	candleColumns  = []string{"time", "open", "high", "low", "close", "avg", "count"}
+)
+
+// parseFormat определяет формат ответа: параметр format важнее заголовка Accept,
+// из Accept берётся первый известный тип; по умолчанию - json
+func parseFormat(r *http.Request) (exportFormat, error) {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		switch format := exportFormat(strings.ToLower(raw)); format {
+		case formatJSON, formatCSV, formatNDJSON:
+			return format, nil
+		}
+		return "", errors.Wrapf(entities.ErrInvalidParam, "unknown format %q, expected one of json, csv, ndjson", raw)
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "text/csv":
+			return formatCSV, nil
+		case "application/x-ndjson":
+			return formatNDJSON, nil
+		case "application/json":
+			return formatJSON, nil
+		}
+	}
+
+	return formatJSON, nil
+}
+
+// exportWriter пишет строки выгрузки сразу в ответ, не накапливая их в памяти.
+// Заголовки отправляются вместе с первой строкой, поэтому ошибку, случившуюся до неё,
+// ещё можно вернуть клиенту с правильным статусом
+type exportWriter struct {
+	rw       http.ResponseWriter
+	format   exportFormat
+	filename string
+	columns  []string
+	csv      *csv.Writer
+	json     *json.Encoder
+	started  bool
+	rows     int
+	log      *zap.Logger
+}
+
+func newExportWriter(rw http.ResponseWriter, r *http.Request, format exportFormat, name string, columns []string) *exportWriter {
+	return &exportWriter{
+		rw:       rw,
+		log:      logger.FromContext(r.Context()),
+		format:   format,
+		filename: name + "." + string(format),
+		columns:  columns,
+	}
+}
+
+func (w *exportWriter) start() error {
+	w.started = true
+
+	header := w.rw.Header()
+	header.Set("Content-Type", exportContentTypes[w.format])
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.filename}))
+	w.rw.WriteHeader(http.StatusOK)
+
+	if w.format == formatCSV {
+		w.csv = csv.NewWriter(w.rw)
+		return w.csv.Write(w.columns)
+	}
+
+	w.json = json.NewEncoder(w.rw)
+	return nil
+}
+
+// write пишет строку: record - для csv, obj - для ndjson
+func (w *exportWriter) write(record []string, obj interface{}) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if w.format == formatCSV {
+		err = w.csv.Write(record)
+	} else {
+		err = w.json.Encode(obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%exportFlushRows == 0 {
+		return w.flush()
+	}
+
+	return nil
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if f, ok := w.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return nil
+}
+
+// finish завершает выгрузку. Ошибка возвращается, только если ответ ещё не начат.
+// После первой строки статус уже не изменить, поэтому соединение обрывается через http.ErrAbortHandler:
+// клиент получает незавершённый chunked-ответ и не примет обрезанный файл за полный
+func (w *exportWriter) finish(err error) error {
+	if err != nil {
+		if !w.started {
+			return err
+		}
+
+		w.log.Error("(export) export aborted", zap.Any("file", w.filename), zap.Any("rows", w.rows), zap.Any("err", err.Error()))
+		panic(http.ErrAbortHandler)
+	}
+
+	if !w.started {
+		if err := w.start(); err != nil {
+			w.log.Error("(export) failed to write header", zap.Any("err", err.Error()))
+			return nil
+		}
+	}
+
+	if err := w.flush(); err != nil {
+		w.log.Error("(export) failed to flush export", zap.Any("err", err.Error()))
+	}
+
+	return nil
+}
+
+// exportHistory выгружает историю построчно в формате q.format
+func (s *Server) exportHistory(rw http.ResponseWriter, r *http.Request, q rangeQuery) error {
+	w := newExportWriter(rw, r, q.format, q.title+"_history", historyColumns)
+	err := s.service.StreamHistory(r.Context(), q.title, q.from, q.to, func(coin entities.Coin) error {
+		point := toHistoryPointDTO(coin)
+		return w.write([]string{
+			point.Time.Format(time.RFC3339Nano),
+			formatFloat(point.Price),
+			strconv.FormatBool(point.Backfilled),
+		}, point)
+	})
+
+	return w.finish(err)
+}
+
+// exportCandles выгружает свечи построчно в формате q.format
+func (s *Server) exportCandles(rw http.ResponseWriter, r *http.Request, q rangeQuery) error {
+	w := newExportWriter(rw, r, q.format, q.title+"_candles_"+string(q.interval), candleColumns)
+	err := s.service.StreamCandles(r.Context(), q.title, q.interval, q.from, q.to, func(c entities.Candle) error {
+		candle := toCandleDTO(c)
+		return w.write([]string{
+			candle.Time.Format(time.RFC3339Nano),
+			formatFloat(candle.Open),
+			formatFloat(candle.High),
+			formatFloat(candle.Low),
+			formatFloat(candle.Close),
+			formatFloat(candle.Avg),
+			strconv.Itoa(candle.Count),
+		}, candle)
+	})
+
+	return w.finish(err)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
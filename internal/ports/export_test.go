@@ -0,0 +1,165 @@
+package ports_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/ports"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+func historyURL(to time.Time, format string) string {
+	return "/api/v1/rates/history?title=BTC&from=" + to.Add(-time.Hour).Format(time.RFC3339) +
+		"&to=" + to.Format(time.RFC3339) + "&format=" + format
+}
+
+func TestExportHistory(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	to := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: to.Add(-2 * time.Minute)},
+		{Title: "BTC", Price: 100.5, CreatedAt: to.Add(-time.Minute)},
+	}))
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+		resp := srv.do(httptest.NewRequest(http.MethodGet, historyURL(to, "csv"), nil))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename=BTC_history.csv`, resp.Header().Get("Content-Disposition"))
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"time", "price", "backfilled"},
+			{to.Add(-2 * time.Minute).Format(time.RFC3339Nano), "100", "false"},
+			{to.Add(-time.Minute).Format(time.RFC3339Nano), "100.5", "false"},
+		}, records)
+	})
+
+	t.Run("ndjson from accept header", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodGet, historyURL(to, ""), nil)
+		req.Header.Set("Accept", "application/x-ndjson, application/json;q=0.9")
+		resp := srv.do(req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+
+		var prices []float64
+		lines := bufio.NewScanner(resp.Body)
+		for lines.Scan() {
+			var point dto.HistoryPointDTO
+			require.NoError(t, json.Unmarshal(lines.Bytes(), &point))
+			prices = append(prices, point.Price)
+		}
+		require.Equal(t, []float64{100, 100.5}, prices)
+	})
+
+	t.Run("empty range still has a csv header", func(t *testing.T) {
+		t.Parallel()
+		resp := srv.do(httptest.NewRequest(http.MethodGet, historyURL(to.Add(-24*time.Hour), "csv"), nil))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "time,price,backfilled\n", resp.Body.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		t.Parallel()
+		resp := srv.do(httptest.NewRequest(http.MethodGet, historyURL(to, "xml"), nil))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+	})
+}
+
+func TestExportCandles(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	to := time.Now().UTC().Truncate(time.Hour)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: to.Add(-50 * time.Minute)},
+		{Title: "BTC", Price: 102, CreatedAt: to.Add(-40 * time.Minute)},
+	}))
+
+	url := "/api/v1/rates/candles?title=BTC&interval=1h&from=" + to.Add(-time.Hour).Format(time.RFC3339) +
+		"&to=" + to.Format(time.RFC3339) + "&format=csv"
+	resp := srv.do(httptest.NewRequest(http.MethodGet, url, nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, `attachment; filename=BTC_candles_1h.csv`, resp.Header().Get("Content-Disposition"))
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, []string{"time", "open", "high", "low", "close", "avg", "count"}, records[0])
+	require.Equal(t, []string{"100", "102", "100", "102", "101", "2"}, records[1][1:])
+}
+
+// newStorageServer - HTTP API поверх сервиса с заданным хранилищем, чтобы воспроизводить сбои посреди выгрузки
+func newStorageServer(t *testing.T, storage cases.Storage) *ports.Server {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	service, err := cases.NewService(mock_cases.NewMockCryptoProvider(ctrl), storage)
+	require.NoError(t, err)
+
+	gaps, err := cases.NewGapDetector(storage, mock_cases.NewMockHistoryProvider(ctrl))
+	require.NoError(t, err)
+
+	hub, err := cases.NewHub(16, 16)
+	require.NoError(t, err)
+
+	health, err := cases.NewHealthChecker(mock_cases.NewMockHealthStorage(ctrl), 9, 0)
+	require.NoError(t, err)
+
+	server, err := ports.NewServer(service, gaps, hub, health)
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestExportHistory_StorageFailure(t *testing.T) {
+	t.Parallel()
+	to := time.Now().UTC().Truncate(time.Second)
+	failure := errors.Wrap(entities.ErrStorageUnavailable, "connection reset")
+
+	t.Run("before the first row the error is returned as a problem", func(t *testing.T) {
+		t.Parallel()
+		storage := mock_cases.NewMockStorage(gomock.NewController(t))
+		storage.EXPECT().StreamHistory(gomock.Any(), "BTC", gomock.Any(), gomock.Any(), gomock.Any()).Return(failure)
+
+		resp := httptest.NewRecorder()
+		newStorageServer(t, storage).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, historyURL(to, "csv"), nil))
+		require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		require.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+	})
+
+	t.Run("after the first row the response is aborted", func(t *testing.T) {
+		t.Parallel()
+		storage := mock_cases.NewMockStorage(gomock.NewController(t))
+		storage.EXPECT().StreamHistory(gomock.Any(), "BTC", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, _, _ time.Time, fn func(entities.Coin) error) error {
+				require.NoError(t, fn(entities.Coin{Title: "BTC", Price: 100, CreatedAt: to.Add(-time.Minute)}))
+				return failure
+			})
+
+		// клиент не должен принять обрезанный файл за полный: соединение обрывается
+		resp := httptest.NewRecorder()
+		server := newStorageServer(t, storage)
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, historyURL(to, "ndjson"), nil))
+		})
+		require.Equal(t, http.StatusOK, resp.Code)
+	})
+}
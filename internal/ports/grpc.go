@@ -0,0 +1,222 @@
+package ports
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+	cryptoratev1 "kursy-kriptovalyut/pkg/pb/cryptorate/v1"
+)
+
+const grpcErrorDomain = "cryptorate"
+
+// grpcCodes - соответствие кодов ошибок статусам gRPC, по смыслу совпадающее с таблицей problems
+var grpcCodes = map[entities.ErrorCode]codes.Code{
+	entities.CodeInvalidParam:        codes.InvalidArgument,
+	entities.CodeInvalidSymbol:       codes.InvalidArgument,
+	entities.CodeInvalidRange:        codes.InvalidArgument,
+	entities.CodeUnknownAggregate:    codes.InvalidArgument,
+	entities.CodeUnknownInterval:     codes.InvalidArgument,
+	entities.CodeReadOnly:            codes.FailedPrecondition,
+	entities.CodeNotFound:            codes.NotFound,
+	entities.CodeUnknownSymbol:       codes.NotFound,
+	entities.CodeRateLimited:         codes.ResourceExhausted,
+	entities.CodeUnauthorized:        codes.Unauthenticated,
+	entities.CodeForbidden:           codes.PermissionDenied,
+	entities.CodeProviderUnavailable: codes.Unavailable,
+	entities.CodeStorageUnavailable:  codes.Unavailable,
+	entities.CodeStaleData:           codes.Unavailable,
+	entities.CodeInternal:            codes.Internal,
+}
+
+// GRPCServer - gRPC API поверх того же сервиса, что и HTTP API
+type GRPCServer struct {
+	cryptoratev1.UnimplementedRatesServiceServer
+
+	service Service
+	hub     StreamHub
+}
+
+func NewGRPCServer(service Service, hub StreamHub) (*GRPCServer, error) {
+	if service == nil || service == Service(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "service not set")
+	}
+
+	if hub == nil || hub == StreamHub(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "stream hub not set")
+	}
+
+	return &GRPCServer{
+		service: service,
+		hub:     hub,
+	}, nil
+}
+
+// Register регистрирует сервис в gRPC-сервере
+func (s *GRPCServer) Register(srv *grpc.Server) {
+	cryptoratev1.RegisterRatesServiceServer(srv, s)
+}
+
+func (s *GRPCServer) GetLastRates(ctx context.Context, req *cryptoratev1.GetLastRatesRequest) (*cryptoratev1.RatesResponse, error) {
+	titles, err := entities.NormalizeTitles(req.GetTitles())
+	ctx = logger.With(ctx, zap.Strings("titles", titles))
+	logger.FromContext(ctx).Info("(grpc.GetLastRates)")
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if len(titles) == 0 {
+		return nil, grpcError(ctx, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles'"))
+	}
+
+	coins, err := s.service.GetLastRates(ctx, titles)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return &cryptoratev1.RatesResponse{Rates: toProtoRates(coins, s.service.Quote())}, nil
+}
+
+func (s *GRPCServer) GetAggRates(ctx context.Context, req *cryptoratev1.GetAggRatesRequest) (*cryptoratev1.RatesResponse, error) {
+	titles, err := entities.NormalizeTitles(req.GetTitles())
+	ctx = logger.With(ctx, zap.Strings("titles", titles))
+	logger.FromContext(ctx).Info("(grpc.GetAggRates)")
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if len(titles) == 0 {
+		return nil, grpcError(ctx, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles'"))
+	}
+
+	if req.GetAggFunc() == "" {
+		return nil, grpcError(ctx, errors.Wrap(entities.ErrUnknownAggregate, "missing 'agg_func'"))
+	}
+
+	coins, err := s.service.GetAggRates(ctx, titles, strings.ToUpper(req.GetAggFunc()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return &cryptoratev1.RatesResponse{Rates: toProtoRates(coins, s.service.Quote())}, nil
+}
+
+func (s *GRPCServer) GetHistory(ctx context.Context, req *cryptoratev1.GetHistoryRequest) (*cryptoratev1.GetHistoryResponse, error) {
+	titles, err := entities.NormalizeTitles([]string{req.GetTitle()})
+	ctx = logger.With(ctx, zap.Strings("titles", titles))
+	logger.FromContext(ctx).Info("(grpc.GetHistory)")
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if len(titles) == 0 {
+		return nil, grpcError(ctx, errors.Wrap(entities.ErrInvalidSymbol, "missing 'title'"))
+	}
+
+	to := time.Now()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	from := to.Add(-defaultRangeWindow)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+
+	coins, err := s.service.GetHistory(ctx, titles[0], from, to)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	response := &cryptoratev1.GetHistoryResponse{
+		Title:  titles[0],
+		Points: make([]*cryptoratev1.HistoryPoint, 0, len(coins)),
+	}
+	for _, coin := range coins {
+		response.Points = append(response.Points, &cryptoratev1.HistoryPoint{
+			Price:      coin.Price,
+			Time:       timestamppb.New(coin.CreatedAt),
+			Backfilled: coin.Backfilled,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *GRPCServer) WatchRates(req *cryptoratev1.WatchRatesRequest, stream grpc.ServerStreamingServer[cryptoratev1.RateEvent]) error {
+	ctx := stream.Context()
+	titles, err := entities.NormalizeTitles(req.GetTitles())
+	ctx = logger.With(ctx, zap.Strings("titles", titles))
+	logger.FromContext(ctx).Info("(grpc.WatchRates)")
+	if err != nil {
+		return grpcError(ctx, err)
+	}
+	if len(titles) == 0 {
+		return grpcError(ctx, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles'"))
+	}
+
+	events, unsubscribe := s.hub.Subscribe(titles, req.GetLastEventId())
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscription closed, reconnect with last_event_id")
+			}
+
+			if err := stream.Send(&cryptoratev1.RateEvent{
+				EventId: event.ID,
+				Rate:    toProtoRate(event.Coin, s.service.Quote()),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProtoRates(coins []entities.Coin, quote string) []*cryptoratev1.Rate {
+	rates := make([]*cryptoratev1.Rate, 0, len(coins))
+	for _, coin := range coins {
+		rates = append(rates, toProtoRate(coin, quote))
+	}
+
+	return rates
+}
+
+func toProtoRate(coin entities.Coin, quote string) *cryptoratev1.Rate {
+	return &cryptoratev1.Rate{
+		Title:     coin.Title,
+		Quote:     quote,
+		Price:     coin.Price,
+		Timestamp: timestamppb.New(coin.CreatedAt),
+		Source:    coin.Source,
+	}
+}
+
+// grpcError переводит ошибку в статус gRPC, не раскрывая тексты серверных ошибок
+func grpcError(ctx context.Context, err error) error {
+	code, p := lookupProblem(err)
+	logProblem(ctx, err, p)
+
+	msg := publicDetail(err, p)
+	if msg == "" {
+		msg = p.title
+	}
+
+	st := status.New(grpcCodes[code], msg)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(code), Domain: grpcErrorDomain}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
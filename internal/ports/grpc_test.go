@@ -0,0 +1,206 @@
+package ports_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/ports"
+	cryptoratev1 "kursy-kriptovalyut/pkg/pb/cryptorate/v1"
+)
+
+// newGRPCClient поднимает gRPC API поверх сервиса с хранилищем в памяти на соединении в памяти
+func newGRPCClient(t *testing.T) (cryptoratev1.RatesServiceClient, *storage.Memory, *cases.Hub) {
+	t.Helper()
+	memory := storage.NewMemory()
+	service, err := cases.NewService(mock_cases.NewMockCryptoProvider(gomock.NewController(t)), memory)
+	require.NoError(t, err)
+
+	hub, err := cases.NewHub(16, 16)
+	require.NoError(t, err)
+
+	server, err := ports.NewGRPCServer(service, hub)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	server.Register(srv)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return cryptoratev1.NewRatesServiceClient(conn), memory, hub
+}
+
+// requireGRPCError проверяет статус ответа и код ошибки в ErrorInfo
+func requireGRPCError(t *testing.T, err error, wantCode codes.Code, wantReason string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, wantCode, st.Code())
+
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, wantReason, info.GetReason())
+	require.Equal(t, "cryptorate", info.GetDomain())
+}
+
+func TestGRPC_GetLastRates(t *testing.T) {
+	t.Parallel()
+	client, memory, _ := newGRPCClient(t)
+	updated := time.Now().UTC().Add(-time.Minute)
+	require.NoError(t, memory.Store(context.Background(), []entities.Coin{{Title: "BTC", Price: 100, CreatedAt: updated}}))
+
+	resp, err := client.GetLastRates(context.Background(), &cryptoratev1.GetLastRatesRequest{Titles: []string{"btc"}})
+	require.NoError(t, err)
+	require.Len(t, resp.GetRates(), 1)
+
+	rate := resp.GetRates()[0]
+	require.Equal(t, "BTC", rate.GetTitle())
+	require.Equal(t, entities.DefaultQuote, rate.GetQuote())
+	require.Equal(t, 100.0, rate.GetPrice())
+	require.True(t, rate.GetTimestamp().AsTime().Equal(updated))
+}
+
+func TestGRPC_GetHistory(t *testing.T) {
+	t.Parallel()
+	client, memory, _ := newGRPCClient(t)
+	to := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, memory.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: to.Add(-2 * time.Minute)},
+		{Title: "BTC", Price: 101, CreatedAt: to.Add(-time.Minute)},
+	}))
+
+	resp, err := client.GetHistory(context.Background(), &cryptoratev1.GetHistoryRequest{
+		Title: "BTC",
+		From:  timestamppb.New(to.Add(-time.Hour)),
+		To:    timestamppb.New(to),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "BTC", resp.GetTitle())
+	require.Len(t, resp.GetPoints(), 2)
+	require.Equal(t, 100.0, resp.GetPoints()[0].GetPrice())
+	require.Equal(t, 101.0, resp.GetPoints()[1].GetPrice())
+}
+
+func TestGRPC_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		call       func(client cryptoratev1.RatesServiceClient) error
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name: "missing titles",
+			call: func(client cryptoratev1.RatesServiceClient) error {
+				_, err := client.GetLastRates(context.Background(), &cryptoratev1.GetLastRatesRequest{})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "invalid_symbol",
+		},
+		{
+			name: "invalid title",
+			call: func(client cryptoratev1.RatesServiceClient) error {
+				_, err := client.GetLastRates(context.Background(), &cryptoratev1.GetLastRatesRequest{Titles: []string{"B$C"}})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "invalid_symbol",
+		},
+		{
+			name: "missing aggregate function",
+			call: func(client cryptoratev1.RatesServiceClient) error {
+				_, err := client.GetAggRates(context.Background(), &cryptoratev1.GetAggRatesRequest{Titles: []string{"BTC"}})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "unknown_aggregate",
+		},
+		{
+			name: "inverted history range",
+			call: func(client cryptoratev1.RatesServiceClient) error {
+				now := time.Now()
+				_, err := client.GetHistory(context.Background(), &cryptoratev1.GetHistoryRequest{
+					Title: "BTC",
+					From:  timestamppb.New(now),
+					To:    timestamppb.New(now.Add(-time.Hour)),
+				})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "invalid_range",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client, _, _ := newGRPCClient(t)
+			requireGRPCError(t, tt.call(client), tt.wantCode, tt.wantReason)
+		})
+	}
+}
+
+func TestGRPC_WatchRates(t *testing.T) {
+	t.Parallel()
+	client, _, hub := newGRPCClient(t)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: now.Add(-time.Minute)}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// с last_event_id поток начинается с пропущенных событий из буфера
+	stream, err := client.WatchRates(ctx, &cryptoratev1.WatchRatesRequest{
+		Titles:      []string{"btc"},
+		LastEventId: uint64(now.Add(-time.Hour).UnixMicro()),
+	})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(now.Add(-time.Minute).UnixMicro()), event.GetEventId())
+	require.Equal(t, 100.0, event.GetRate().GetPrice())
+
+	hub.Publish([]entities.Coin{{Title: "ETH", Price: 10, CreatedAt: now}, {Title: "BTC", Price: 101, CreatedAt: now}})
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(now.UnixMicro()), event.GetEventId())
+	require.Equal(t, "BTC", event.GetRate().GetTitle())
+	require.Equal(t, 101.0, event.GetRate().GetPrice())
+}
+
+func TestGRPC_WatchRatesInvalidTitles(t *testing.T) {
+	t.Parallel()
+	client, _, _ := newGRPCClient(t)
+
+	stream, err := client.WatchRates(context.Background(), &cryptoratev1.WatchRatesRequest{Titles: []string{"B$C"}})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	requireGRPCError(t, err, codes.InvalidArgument, "invalid_symbol")
+}
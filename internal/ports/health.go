@@ -0,0 +1,149 @@
+package ports
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+const (
+	checkOK       = "ok"
+	checkFail     = "fail"
+	checkDegraded = "degraded"
+)
+
+// @Summary Liveness probe
+// @Description Always succeeds while the process is able to serve HTTP
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthDTO
+// @Router /healthz [get]
+func (s *Server) Healthz(rw http.ResponseWriter, r *http.Request) {
+	respondWithJSON(rw, http.StatusOK, dto.HealthDTO{Status: checkOK})
+}
+
+// @Summary Readiness probe
+// @Description Checks the Postgres connection, that the DB schema is not older than the binary and, when ready-max-lag is set,
+// @Description that the newest ingested price is not older than ready-max-lag
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.ReadinessDTO
+// @Failure 503 {object} dto.ReadinessDTO
+// @Router /readyz [get]
+func (s *Server) Readyz(rw http.ResponseWriter, r *http.Request) {
+	response := dto.ReadinessDTO{
+		Status: checkOK,
+		Checks: make([]dto.CheckDTO, 0),
+	}
+	for _, check := range s.health.Ready(r.Context()) {
+		item := dto.CheckDTO{Name: check.Name, Status: checkOK}
+		if check.Err != nil {
+			item.Status = checkFail
+			item.Code = string(entities.Code(check.Err))
+			response.Status = checkFail
+		}
+
+		response.Checks = append(response.Checks, item)
+	}
+
+	status := http.StatusOK
+	if response.Status != checkOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	respondWithJSON(rw, status, response)
+}
+
+// @Summary Replica status
+// @Description Get provider circuit state, time of the newest ingested price, data lag per tracked coin and build info.
+// @Description Status is degraded when any coin lags more than status-max-lag or has no data today
+// @Tags health
+// @Produce json,application/problem+json
+// @Success 200 {object} dto.StatusDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /status [get]
+func (s *Server) Status(rw http.ResponseWriter, r *http.Request) {
+	status, err := s.health.Status(r.Context())
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	respondWithJSON(rw, http.StatusOK, toStatusDTO(status))
+}
+
+func toStatusDTO(status entities.Status) dto.StatusDTO {
+	response := dto.StatusDTO{
+		Status:        checkOK,
+		LastActualize: optionalTime(status.LastActualize),
+		Coins:         make([]dto.CoinLagDTO, 0, len(status.Coins)),
+		Build:         buildInfo(),
+	}
+
+	if status.Provider != nil {
+		response.Provider = &dto.ProviderStatusDTO{
+			Circuit:             string(status.Provider.State),
+			ConsecutiveFailures: status.Provider.ConsecutiveFailures,
+			LastSuccess:         optionalTime(status.Provider.LastSuccess),
+			LastFailure:         optionalTime(status.Provider.LastFailure),
+		}
+	}
+
+	if status.Degraded {
+		response.Status = checkDegraded
+	}
+
+	for _, coin := range status.Coins {
+		item := dto.CoinLagDTO{Title: coin.Title, Lagging: coin.Lagging}
+		if !coin.LastUpdate.IsZero() {
+			lag := coin.Lag.Seconds()
+			item.LastUpdate = &coin.LastUpdate
+			item.LagSec = &lag
+		}
+
+		response.Coins = append(response.Coins, item)
+	}
+
+	return response
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// buildInfo собирается один раз: сведения о сборке за время работы процесса не меняются
+var buildInfo = sync.OnceValue(func() dto.BuildInfoDTO {
+	info := dto.BuildInfoDTO{Version: "unknown"}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Version = bi.Main.Version
+	info.GoVersion = bi.GoVersion
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+})
@@ -0,0 +1,189 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+func TestHealthz(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "ok", decodeJSON[dto.HealthDTO](t, resp).Status)
+}
+
+func TestReadyz(t *testing.T) {
+	t.Parallel()
+	down := errors.Wrap(entities.ErrStorageUnavailable, "connection refused")
+
+	tests := []struct {
+		name        string
+		maxLag      time.Duration
+		ping        error
+		version     int
+		dirty       bool
+		lastUpdate  time.Time
+		wantStatus  int
+		wantChecks  map[string]string
+		wantFailure string
+	}{
+		{
+			name:       "ready",
+			version:    9,
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "ok", "ingestion": "ok"},
+		},
+		{
+			name:        "postgres down",
+			ping:        down,
+			version:     9,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantChecks:  map[string]string{"postgres": "fail", "migrations": "ok", "ingestion": "ok"},
+			wantFailure: "storage_unavailable",
+		},
+		{
+			name:        "schema is older than the binary",
+			version:     8,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantChecks:  map[string]string{"postgres": "ok", "migrations": "fail", "ingestion": "ok"},
+			wantFailure: "storage_unavailable",
+		},
+		{
+			name:        "dirty migration",
+			version:     9,
+			dirty:       true,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantChecks:  map[string]string{"postgres": "ok", "migrations": "fail", "ingestion": "ok"},
+			wantFailure: "storage_unavailable",
+		},
+		{
+			name:       "newer schema during rollout",
+			version:    10,
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "ok", "ingestion": "ok"},
+		},
+		{
+			name:       "fresh prices",
+			maxLag:     5 * time.Minute,
+			version:    9,
+			lastUpdate: time.Now().Add(-time.Minute),
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"postgres": "ok", "migrations": "ok", "ingestion": "ok"},
+		},
+		{
+			name:        "prices lag behind",
+			maxLag:      5 * time.Minute,
+			version:     9,
+			lastUpdate:  time.Now().Add(-time.Hour),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantChecks:  map[string]string{"postgres": "ok", "migrations": "ok", "ingestion": "fail"},
+			wantFailure: "stale_data",
+		},
+		{
+			name:        "no prices yet",
+			maxLag:      5 * time.Minute,
+			version:     9,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantChecks:  map[string]string{"postgres": "ok", "migrations": "ok", "ingestion": "fail"},
+			wantFailure: "stale_data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			require.NoError(t, srv.checker.SetReadyMaxLag(tt.maxLag))
+			srv.health.EXPECT().Ping(gomock.Any()).Return(tt.ping)
+			srv.health.EXPECT().SchemaVersion(gomock.Any()).Return(tt.version, tt.dirty, nil)
+			if tt.maxLag > 0 {
+				srv.health.EXPECT().LastUpdate(gomock.Any()).Return(tt.lastUpdate, nil)
+			}
+
+			resp := srv.do(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			require.Equal(t, tt.wantStatus, resp.Code)
+
+			body := decodeJSON[dto.ReadinessDTO](t, resp)
+			checks := make(map[string]string, len(body.Checks))
+			for _, check := range body.Checks {
+				checks[check.Name] = check.Status
+				if check.Status == "fail" {
+					require.Equal(t, tt.wantFailure, check.Code)
+				}
+			}
+			require.Equal(t, tt.wantChecks, checks)
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		maxLag     time.Duration
+		wantStatus string
+		wantLagged []string
+	}{
+		{name: "lag is not checked", wantStatus: "ok"},
+		{name: "coin without data today", maxLag: time.Hour, wantStatus: "degraded", wantLagged: []string{"SOL"}},
+		{name: "lagging coin", maxLag: 10 * time.Minute, wantStatus: "degraded", wantLagged: []string{"ETH", "SOL"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			require.NoError(t, srv.checker.SetStatusMaxLag(tt.maxLag))
+			srv.health.EXPECT().GetCoinsList(gomock.Any()).Return([]string{"BTC", "ETH", "SOL"}, nil)
+			srv.health.EXPECT().GetActualCoins(gomock.Any(), []string{"BTC", "ETH", "SOL"}).Return([]entities.Coin{
+				{Title: "BTC", CreatedAt: now.Add(-time.Minute)},
+				{Title: "ETH", CreatedAt: now.Add(-30 * time.Minute)},
+			}, nil)
+			srv.health.EXPECT().LastUpdate(gomock.Any()).Return(now.Add(-time.Minute), nil)
+
+			resp := srv.do(httptest.NewRequest(http.MethodGet, "/status", nil))
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			body := decodeJSON[dto.StatusDTO](t, resp)
+			require.Equal(t, tt.wantStatus, body.Status)
+			require.NotNil(t, body.LastActualize)
+			require.True(t, body.LastActualize.Equal(now.Add(-time.Minute)))
+			require.Nil(t, body.Provider)
+			require.NotEmpty(t, body.Build.Version)
+
+			var lagged []string
+			for _, coin := range body.Coins {
+				if coin.Lagging {
+					lagged = append(lagged, coin.Title)
+				}
+				// у монеты без данных за сегодня нет отставания
+				require.Equal(t, coin.Title != "SOL", coin.LagSec != nil)
+			}
+			require.Equal(t, tt.wantLagged, lagged)
+		})
+	}
+}
+
+func TestStatus_StorageUnavailable(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	srv.health.EXPECT().GetCoinsList(gomock.Any()).Return(nil, errors.Wrap(entities.ErrStorageUnavailable, "connection refused"))
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/status", nil))
+	require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	require.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+	require.Equal(t, "storage_unavailable", decodeJSON[dto.ProblemDTO](t, resp).Code)
+}
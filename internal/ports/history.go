@@ -0,0 +1,51 @@
+package ports
+
+import (
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+func toHistoryDTO(title string, coins []entities.Coin) dto.HistoryRespDTO {
+	response := dto.HistoryRespDTO{
+		Title:  title,
+		Points: make([]dto.HistoryPointDTO, 0, len(coins)),
+	}
+	for _, coin := range coins {
+		response.Points = append(response.Points, toHistoryPointDTO(coin))
+	}
+
+	return response
+}
+
+func toCandlesDTO(title string, interval entities.Interval, candles []entities.Candle) dto.CandlesRespDTO {
+	response := dto.CandlesRespDTO{
+		Title:    title,
+		Interval: string(interval),
+		Candles:  make([]dto.CandleDTO, 0, len(candles)),
+	}
+	for _, c := range candles {
+		response.Candles = append(response.Candles, toCandleDTO(c))
+	}
+
+	return response
+}
+
+func toHistoryPointDTO(coin entities.Coin) dto.HistoryPointDTO {
+	return dto.HistoryPointDTO{
+		Price:      coin.Price,
+		Time:       coin.CreatedAt,
+		Backfilled: coin.Backfilled,
+	}
+}
+
+func toCandleDTO(c entities.Candle) dto.CandleDTO {
+	return dto.CandleDTO{
+		Time:  c.Bucket,
+		Open:  c.Open,
+		High:  c.High,
+		Low:   c.Low,
+		Close: c.Close,
+		Avg:   c.Avg,
+		Count: c.Count,
+	}
+}
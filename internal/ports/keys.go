@@ -0,0 +1,233 @@
+package ports
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const defaultUsageWindow = 30 * 24 * time.Hour
+
+// @Summary Issue API key
+// @Description Issue a new API key; the key itself is returned only once in the response
+// @Tags admin
+// @Accept json
+// @Produce json,application/problem+json
+// @Param request body dto.IssueKeyReqDTO true "Key name, scopes and rate limit per minute (default: auth-rate-limit)"
+// @Success 201 {object} dto.APIKeyDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/keys [post]
+func (s *Server) IssueKey(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.IssueKey)")
+	if err := s.authEnabled(); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	var req dto.IssueKeyReqDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidParam, "request body must be a JSON object with 'name' and 'scopes'"))
+		return
+	}
+
+	scopes := make([]entities.Scope, 0, len(req.Scopes))
+	for _, raw := range req.Scopes {
+		scope, err := entities.ParseScope(raw)
+		if err != nil {
+			respondWithProblem(rw, r, err)
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+
+	key, token, err := s.auth.IssueKey(r.Context(), req.Name, scopes, req.RateLimit)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	response := toAPIKeyDTO(key)
+	response.Key = token
+	respondWithJSON(rw, http.StatusCreated, response)
+}
+
+// @Summary List API keys
+// @Description List all API keys including revoked ones; keys themselves are not returned
+// @Tags admin
+// @Produce json,application/problem+json
+// @Success 200 {array} dto.APIKeyDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/keys [get]
+func (s *Server) ListKeys(rw http.ResponseWriter, r *http.Request) {
+	if err := s.authEnabled(); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	keys, err := s.auth.ListKeys(r.Context())
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	response := make([]dto.APIKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKeyDTO(key))
+	}
+
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key; other replicas stop accepting it within the key cache TTL
+// @Tags admin
+// @Produce application/problem+json
+// @Param id path int true "Key ID"
+// @Success 204
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/keys/{id} [delete]
+func (s *Server) RevokeKey(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	if err := s.authEnabled(); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	id, err := parseKeyID(r)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	if err := s.auth.RevokeKey(r.Context(), id); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	log.Warn("(server.RevokeKey) key revoked", zap.Any("keyID", id))
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get API key usage
+// @Description Get the number of requests made with an API key per day (UTC)
+// @Tags admin
+// @Produce json,application/problem+json
+// @Param id path int true "Key ID"
+// @Param from query string false "First day, YYYY-MM-DD (default: 30 days before 'to')"
+// @Param to query string false "Last day, YYYY-MM-DD (default: today)"
+// @Success 200 {object} dto.KeyUsageRespDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /admin/keys/{id}/usage [get]
+func (s *Server) GetKeyUsage(rw http.ResponseWriter, r *http.Request) {
+	if err := s.authEnabled(); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	id, err := parseKeyID(r)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		if to, err = time.Parse(time.DateOnly, raw); err != nil {
+			respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidRange, "invalid 'to' query parameter, expected YYYY-MM-DD"))
+			return
+		}
+	}
+
+	from := to.Add(-defaultUsageWindow)
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		if from, err = time.Parse(time.DateOnly, raw); err != nil {
+			respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidRange, "invalid 'from' query parameter, expected YYYY-MM-DD"))
+			return
+		}
+	}
+
+	if from.After(to) {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidRange, "'from' must not be after 'to'"))
+		return
+	}
+
+	usage, err := s.auth.GetUsage(r.Context(), id, from, to)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	response := dto.KeyUsageRespDTO{KeyID: id, Usage: make([]dto.KeyUsageDTO, 0, len(usage))}
+	for _, u := range usage {
+		response.Usage = append(response.Usage, dto.KeyUsageDTO{Day: u.Day.Format(time.DateOnly), Requests: u.Requests})
+	}
+
+	respondWithJSON(rw, http.StatusOK, response)
+}
+
+func (s *Server) authEnabled() error {
+	if s.auth == nil {
+		return errors.Wrap(entities.ErrNotFound, "api keys are disabled (auth-enabled: false)")
+	}
+
+	return nil
+}
+
+func parseKeyID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.Wrap(entities.ErrInvalidParam, "key id must be a positive integer")
+	}
+
+	return id, nil
+}
+
+func toAPIKeyDTO(key entities.APIKey) dto.APIKeyDTO {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	return dto.APIKeyDTO{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    scopes,
+		RateLimit: key.RateLimit,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
@@ -0,0 +1,104 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// grpcRequestPrefix отличает идентификаторы, выданные gRPC-вызовам, от идентификаторов HTTP-запросов
+const grpcRequestPrefix = "grpc"
+
+// requestLogger кладёт в контекст запроса логгер с идентификатором запроса, маршрутом и идентификатором трассы
+func (s *Server) requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		route, ok := s.matchRoute(r)
+		if !ok {
+			route = r.URL.Path
+		}
+
+		fields := []zap.Field{
+			zap.String("request_id", middleware.GetReqID(r.Context())),
+			zap.String("method", r.Method),
+			zap.String("route", route),
+		}
+		fields = append(fields, traceFields(r.Context())...)
+
+		next.ServeHTTP(rw, r.WithContext(logger.With(r.Context(), fields...)))
+	})
+}
+
+// matchRoute возвращает шаблон маршрута запроса: до маршрутизации он ещё не известен, поэтому ищем его заранее
+func (s *Server) matchRoute(r *http.Request) (string, bool) {
+	rctx := chi.NewRouteContext()
+	if !s.server.Match(rctx, r.Method, r.URL.Path) {
+		return "", false
+	}
+
+	return rctx.RoutePattern(), true
+}
+
+// traceFields возвращает идентификатор трассы, если запрос в неё попал
+func traceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{zap.String("trace_id", sc.TraceID().String())}
+}
+
+// withTitles добавляет запрошенные монеты к логгеру запроса
+func withTitles(r *http.Request, titles ...string) *http.Request {
+	return r.WithContext(logger.With(r.Context(), zap.Strings("titles", titles)))
+}
+
+// UnaryRequestLogger - аналог requestLogger для унарных gRPC-вызовов
+func UnaryRequestLogger(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(grpcLoggerContext(ctx, info.FullMethod), req)
+}
+
+// StreamRequestLogger - аналог requestLogger для потоковых gRPC-вызовов
+func StreamRequestLogger(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &loggedStream{ServerStream: ss, ctx: grpcLoggerContext(ss.Context(), info.FullMethod)})
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcLoggerContext берёт идентификатор запроса из метаданных x-request-id или выдаёт новый
+func grpcLoggerContext(ctx context.Context, method string) context.Context {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(strings.ToLower(middleware.RequestIDHeader)); len(ids) > 0 {
+			requestID = ids[0]
+		}
+	}
+	if requestID == "" {
+		requestID = fmt.Sprintf("%s-%06d", grpcRequestPrefix, middleware.NextRequestID())
+	}
+
+	fields := []zap.Field{
+		zap.String("request_id", requestID),
+		zap.String("method", method),
+	}
+	fields = append(fields, traceFields(ctx)...)
+
+	return logger.With(ctx, fields...)
+}
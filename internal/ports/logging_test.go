@@ -0,0 +1,90 @@
+package ports_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// withObservedLogger подкладывает в запрос логгер, записи которого можно проверить:
+// логгер запроса строится от логгера из контекста
+func withObservedLogger(r *http.Request) (*http.Request, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return r.WithContext(logger.WithContext(r.Context(), zap.New(core))), logs
+}
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		url       string
+		requestID string
+		// wantBody - идентификатор есть и в теле ответа
+		wantBody bool
+	}{
+		{name: "generated", url: "/healthz"},
+		{name: "from client", url: "/healthz", requestID: "client-req-1"},
+		{name: "in envelope", url: "/api/v1/rates/last?titles=BTC", requestID: "client-req-2", wantBody: true},
+		{name: "in problem", url: "/api/v1/rates/last?titles=B$C", requestID: "client-req-3", wantBody: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now()}}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.requestID)
+			}
+
+			resp := srv.do(req)
+			requestID := resp.Header().Get(middleware.RequestIDHeader)
+			require.NotEmpty(t, requestID)
+			if tt.requestID != "" {
+				require.Equal(t, tt.requestID, requestID)
+			}
+
+			if tt.wantBody {
+				require.Equal(t, requestID, decodeJSON[struct {
+					RequestID string `json:"request_id"`
+				}](t, resp).RequestID)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	req, logs := withObservedLogger(httptest.NewRequest(http.MethodGet, "/api/v1/rates/history?title=B$C", nil))
+	req.Header.Set(middleware.RequestIDHeader, "client-req-1")
+	resp := srv.do(req)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Equal(t, "client-req-1", decodeJSON[dto.ProblemDTO](t, resp).RequestID)
+
+	// все записи обработчика несут идентификатор запроса и шаблон маршрута
+	entries := logs.All()
+	require.NotEmpty(t, entries)
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		require.Equal(t, "client-req-1", fields["request_id"], entry.Message)
+		require.Equal(t, http.MethodGet, fields["method"], entry.Message)
+		require.Equal(t, "/api/v1/rates/history", fields["route"], entry.Message)
+	}
+	require.NotEmpty(t, logs.FilterMessage("(server.GetHistoryV1) invalid query parameters").All())
+}
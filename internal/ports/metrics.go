@@ -0,0 +1,36 @@
+package ports
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"kursy-kriptovalyut/internal/metrics"
+)
+
+// observeRequests собирает метрики HTTP-запросов по шаблону маршрута и статусу ответа
+func observeRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+		// запрос учитывается и тогда, когда обработчик оборвал ответ через http.ErrAbortHandler
+		defer func() {
+			// шаблон маршрута известен только после того, как chi разобрал запрос
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			metrics.ObserveHTTPRequest(route, r.Method, status, start)
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,30 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+
+	require.Equal(t, http.StatusBadRequest, srv.do(httptest.NewRequest(http.MethodGet, "/api/v1/rates/history?title=B$C", nil)).Code)
+	require.Equal(t, http.StatusNotFound, srv.do(httptest.NewRequest(http.MethodGet, "/api/v1/unknown/42", nil)).Code)
+	require.Equal(t, http.StatusNotFound, srv.do(httptest.NewRequest(http.MethodGet, "/unknown/42", nil)).Code)
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Contains(t, resp.Header().Get("Content-Type"), "text/plain")
+
+	// запросы учитываются по шаблону маршрута, а пути вне маршрутов - одной меткой
+	body := resp.Body.String()
+	require.Contains(t, body, `cryptorate_http_requests_total{method="GET",route="/api/v1/rates/history",status="400"}`)
+	require.Contains(t, body, `cryptorate_http_request_duration_seconds_count{method="GET",route="/api/v1/rates/history",status="400"}`)
+	require.Contains(t, body, `cryptorate_http_requests_total{method="GET",route="/api/v1/*",status="404"}`)
+	require.Contains(t, body, `cryptorate_http_requests_total{method="GET",route="unmatched",status="404"}`)
+	require.NotContains(t, body, "unknown/42")
+}
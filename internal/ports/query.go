@@ -0,0 +1,95 @@
+package ports
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"kursy-kriptovalyut/internal/entities"
+)
+
+const defaultRangeWindow = 24 * time.Hour
+
+type rangeQuery struct {
+	title    string
+	interval entities.Interval
+	from     time.Time
+	to       time.Time
+	format   exportFormat
+}
+
+// parseRangeQuery разбирает общие параметры запросов по временному ряду монеты:
+// title, interval (по умолчанию 1m), from и to в RFC3339 (по умолчанию последние сутки), формат ответа
+func parseRangeQuery(r *http.Request) (rangeQuery, error) {
+	params := r.URL.Query()
+
+	q := rangeQuery{
+		interval: entities.IntervalMinute,
+		to:       time.Now(),
+	}
+	if strings.TrimSpace(params.Get("title")) == "" {
+		return q, errors.Wrap(entities.ErrInvalidSymbol, "missing 'title' query parameter")
+	}
+
+	title, err := entities.ParseSymbol(params.Get("title"))
+	if err != nil {
+		return q, err
+	}
+	q.title = string(title)
+
+	if raw := params.Get("interval"); raw != "" {
+		interval, err := entities.ParseInterval(raw)
+		if err != nil {
+			return q, err
+		}
+		q.interval = interval
+	}
+
+	if raw := params.Get("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, errors.Wrap(entities.ErrInvalidRange, "invalid 'to' query parameter, expected RFC3339")
+		}
+		q.to = to
+	}
+
+	q.from = q.to.Add(-defaultRangeWindow)
+	if raw := params.Get("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, errors.Wrap(entities.ErrInvalidRange, "invalid 'from' query parameter, expected RFC3339")
+		}
+		q.from = from
+	}
+
+	format, err := parseFormat(r)
+	if err != nil {
+		return q, err
+	}
+	q.format = format
+
+	return q, nil
+}
+
+// SetMaxQueryRange ограничивает период, который отдаётся одним JSON-ответом; 0 снимает ограничение.
+// Безопасно вызывать во время работы сервера - при перезагрузке конфига
+func (s *Server) SetMaxQueryRange(limit time.Duration) {
+	s.maxQueryRange.Store(int64(limit))
+}
+
+// checkQueryRange отклоняет слишком длинный период, пока ответ собирается в памяти целиком;
+// для exportable-эндпоинтов клиенту подсказывается построчная выгрузка
+func (s *Server) checkQueryRange(q rangeQuery, exportable bool) error {
+	limit := time.Duration(s.maxQueryRange.Load())
+	if limit <= 0 || q.to.Sub(q.from) <= limit {
+		return nil
+	}
+
+	if exportable {
+		return errors.Wrapf(entities.ErrInvalidRange, "range is longer than %v, narrow it or request format=csv or format=ndjson to stream it", limit)
+	}
+
+	return errors.Wrapf(entities.ErrInvalidRange, "range is longer than %v, split it into shorter requests", limit)
+}
@@ -0,0 +1,53 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+func TestMaxQueryRange(t *testing.T) {
+	t.Parallel()
+	to := time.Now().UTC().Truncate(time.Second)
+	week := "&from=" + to.Add(-7*24*time.Hour).Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339)
+
+	tests := []struct {
+		name       string
+		url        string
+		limit      time.Duration
+		wantStatus int
+		// wantDetail - подсказка клиенту в тексте ошибки
+		wantDetail string
+	}{
+		{name: "history json", url: "/api/v1/rates/history?title=BTC" + week, limit: 24 * time.Hour, wantStatus: http.StatusBadRequest, wantDetail: "format=csv"},
+		{name: "candles json", url: "/api/v1/rates/candles?title=BTC&interval=1h" + week, limit: 24 * time.Hour, wantStatus: http.StatusBadRequest, wantDetail: "format=ndjson"},
+		{name: "gaps", url: "/admin/gaps?title=BTC" + week, limit: 24 * time.Hour, wantStatus: http.StatusBadRequest, wantDetail: "split it into shorter requests"},
+		{name: "history csv is streamed", url: "/api/v1/rates/history?title=BTC&format=csv" + week, limit: 24 * time.Hour, wantStatus: http.StatusOK},
+		{name: "candles ndjson is streamed", url: "/api/v1/rates/candles?title=BTC&format=ndjson" + week, limit: 24 * time.Hour, wantStatus: http.StatusOK},
+		{name: "range within limit", url: "/api/v1/rates/history?title=BTC" + week, limit: 7 * 24 * time.Hour, wantStatus: http.StatusOK},
+		{name: "no limit", url: "/api/v1/rates/history?title=BTC" + week, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			srv.SetMaxQueryRange(tt.limit)
+
+			resp := srv.do(httptest.NewRequest(http.MethodGet, tt.url, nil))
+			require.Equal(t, tt.wantStatus, resp.Code)
+			if tt.wantDetail == "" {
+				return
+			}
+
+			problem := decodeJSON[dto.ProblemDTO](t, resp)
+			require.Equal(t, "invalid_range", problem.Code)
+			require.Contains(t, problem.Detail, tt.wantDetail)
+		})
+	}
+}
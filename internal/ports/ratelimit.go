@@ -0,0 +1,153 @@
+package ports
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+// RequestLimiter ограничивает частоту запросов одного клиента к одному маршруту
+type RequestLimiter interface {
+	Allow(client, route string) (time.Duration, bool)
+}
+
+// unlimitedRoutes - служебные маршруты, которые опрашивают балансировщик и мониторинг
+var unlimitedRoutes = map[string]bool{
+	"/healthz":   true,
+	"/readyz":    true,
+	"/metrics":   true,
+	"/swagger/*": true,
+}
+
+// SetRateLimiter включает ограничение частоты запросов по IP клиента и маршруту
+func (s *Server) SetRateLimiter(limiter RequestLimiter) {
+	s.limiter = limiter
+}
+
+// SetTrustProxy включает определение IP клиента по X-Forwarded-For; только за собственным прокси,
+// иначе клиент подставит любой адрес и обойдёт лимиты
+func (s *Server) SetTrustProxy(trust bool) {
+	s.trustProxy = trust
+}
+
+// limitRequests помечает запрос клиентом - его IP - и отклоняет запросы сверх бюджета маршрута.
+// Клиент в контексте нужен и сервису: вызовы провайдера ограничены отдельным бюджетом
+func (s *Server) limitRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		client := clientIP(r, s.trustProxy)
+		r = r.WithContext(entities.WithClient(r.Context(), client))
+
+		// несуществующие маршруты делят одну корзину, чтобы случайные пути не заводили новых
+		route, _ := s.matchRoute(r)
+		if s.limiter == nil || unlimitedRoutes[route] {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if retryAfter, ok := s.limiter.Allow(client, route); !ok {
+			log := logger.FromContext(r.Context())
+			log.Warn("(server.limitRequests) rate limit exceeded", zap.Any("client", client))
+			msg := "too many requests"
+			if route != "" {
+				msg += " to " + route
+			}
+			err := entities.NewRetryError(errors.Wrap(entities.ErrRateLimited, msg), retryAfter)
+			// маршруты без версии отвечают ошибкой в прежнем формате
+			if strings.HasPrefix(route, "/rates/") {
+				respondWithError(rw, r, err)
+			} else {
+				respondWithProblem(rw, r, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// clientIP возвращает IP клиента; адреса IPv6 сводятся к сети /64, которую обычно получает один клиент
+func clientIP(r *http.Request, trustProxy bool) string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	// последний адрес в X-Forwarded-For добавлен нашим прокси, предыдущие мог прислать сам клиент
+	if forwarded := r.Header.Get("X-Forwarded-For"); trustProxy && forwarded != "" {
+		parts := strings.Split(forwarded, ",")
+		addr = strings.TrimSpace(parts[len(parts)-1])
+	}
+
+	return normalizeIP(addr)
+}
+
+func normalizeIP(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return addr
+	}
+
+	if ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String()
+	}
+
+	return ip.String()
+}
+
+// UnaryRateLimit и StreamRateLimit - аналог limitRequests для gRPC: маршрутом служит метод;
+// с пустым limiter вызовы только помечаются клиентом
+func UnaryRateLimit(limiter RequestLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := limitGRPC(ctx, limiter, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamRateLimit(limiter RequestLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := limitGRPC(ss.Context(), limiter, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func limitGRPC(ctx context.Context, limiter RequestLimiter, method string) (context.Context, error) {
+	client := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client); err == nil {
+			client = host
+		}
+		client = normalizeIP(client)
+	}
+	ctx = entities.WithClient(ctx, client)
+
+	if limiter == nil || client == "" {
+		return ctx, nil
+	}
+
+	if retryAfter, ok := limiter.Allow(client, method); !ok {
+		log := logger.FromContext(ctx)
+		log.Warn("(grpc.limitGRPC) rate limit exceeded", zap.Any("client", client))
+		return ctx, grpcError(ctx, entities.NewRetryError(errors.Wrapf(entities.ErrRateLimited, "too many requests to %v", method), retryAfter))
+	}
+
+	return ctx, nil
+}
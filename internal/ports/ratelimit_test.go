@@ -0,0 +1,145 @@
+package ports_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/cases"
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+// newLimitedServer - тестовый сервер, где каждый клиент может сделать один запрос к маршруту,
+// а к /api/v1/rates/agg - три
+func newLimitedServer(t *testing.T) *testServer {
+	t.Helper()
+	limiter, err := cases.NewClientLimiter(entities.RateBudget{RPS: 0.01, Burst: 1}, map[string]entities.RateBudget{
+		"/api/v1/rates/agg": {RPS: 0.01, Burst: 3},
+	})
+	require.NoError(t, err)
+
+	srv := newTestServer(t)
+	srv.SetRateLimiter(limiter)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now()}}))
+
+	return srv
+}
+
+func requestFrom(url, remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.RemoteAddr = remoteAddr
+
+	return req
+}
+
+func TestLimitRequests(t *testing.T) {
+	t.Parallel()
+	srv := newLimitedServer(t)
+
+	require.Equal(t, http.StatusOK, srv.do(requestFrom("/api/v1/rates/last?titles=BTC", "10.0.0.1:1000")).Code)
+
+	resp := srv.do(requestFrom("/api/v1/rates/last?titles=BTC", "10.0.0.1:2000"))
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	require.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+	retryAfter, err := strconv.Atoi(resp.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, retryAfter, 1)
+
+	problem := decodeJSON[dto.ProblemDTO](t, resp)
+	require.Equal(t, "rate_limited", problem.Code)
+	require.Contains(t, problem.Detail, "/api/v1/rates/last")
+
+	// у другого клиента и у другого маршрута свои корзины
+	require.Equal(t, http.StatusOK, srv.do(requestFrom("/api/v1/rates/last?titles=BTC", "10.0.0.2:1000")).Code)
+	require.Equal(t, http.StatusOK, srv.do(requestFrom("/api/v1/rates/history?title=BTC", "10.0.0.1:1000")).Code)
+}
+
+func TestLimitRequests_Routes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		urls     []string
+		wantLast int
+	}{
+		{name: "route budget", urls: []string{"/api/v1/rates/agg?titles=BTC&aggFunc=MAX", "/api/v1/rates/agg?titles=BTC&aggFunc=MIN", "/api/v1/rates/agg?titles=BTC&aggFunc=AVG"}, wantLast: http.StatusOK},
+		{name: "route budget exhausted", urls: []string{"/api/v1/rates/agg?titles=BTC&aggFunc=MAX", "/api/v1/rates/agg?titles=BTC&aggFunc=MAX", "/api/v1/rates/agg?titles=BTC&aggFunc=MAX", "/api/v1/rates/agg?titles=BTC&aggFunc=MAX"}, wantLast: http.StatusTooManyRequests},
+		{name: "probes are not limited", urls: []string{"/healthz", "/healthz", "/healthz", "/metrics", "/metrics"}, wantLast: http.StatusOK},
+		// случайные пути не заводят новых корзин
+		{name: "unknown paths share a bucket", urls: []string{"/unknown/1", "/unknown/2"}, wantLast: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newLimitedServer(t)
+
+			var resp *httptest.ResponseRecorder
+			for _, url := range tt.urls {
+				resp = srv.do(requestFrom(url, "10.0.0.1:1000"))
+			}
+			require.Equal(t, tt.wantLast, resp.Code)
+		})
+	}
+}
+
+func TestLimitRequests_LegacyErrors(t *testing.T) {
+	t.Parallel()
+	srv := newLimitedServer(t)
+
+	require.Equal(t, http.StatusOK, srv.do(requestFrom("/rates/last?titles=BTC", "10.0.0.1:1000")).Code)
+
+	resp := srv.do(requestFrom("/rates/last?titles=BTC", "10.0.0.1:1000"))
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	require.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	require.NotEmpty(t, resp.Header().Get("Retry-After"))
+	require.Equal(t, "rate_limited", decodeJSON[dto.ErrRespDTO](t, resp).Code)
+}
+
+func TestLimitRequests_ClientAddress(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		trustProxy bool
+		first      [2]string
+		second     [2]string
+		// wantShared - запросы попадают в одну корзину
+		wantShared bool
+	}{
+		{name: "same ipv4", first: [2]string{"10.0.0.1:1000"}, second: [2]string{"10.0.0.1:2000"}, wantShared: true},
+		{name: "same ipv6 network", first: [2]string{"[2001:db8::1]:1000"}, second: [2]string{"[2001:db8::2]:1000"}, wantShared: true},
+		{name: "other ipv6 network", first: [2]string{"[2001:db8::1]:1000"}, second: [2]string{"[2001:db8:0:1::1]:1000"}},
+		{name: "forwarded for is ignored without a trusted proxy", first: [2]string{"10.0.0.1:1000", "1.1.1.1"}, second: [2]string{"10.0.0.1:1000", "2.2.2.2"}, wantShared: true},
+		{name: "trusted proxy", trustProxy: true, first: [2]string{"10.0.0.1:1000", "1.1.1.1"}, second: [2]string{"10.0.0.1:1000", "2.2.2.2"}},
+		// адрес клиента - последний, его дописал прокси; предыдущие клиент мог подставить сам
+		{name: "spoofed forwarded for", trustProxy: true, first: [2]string{"10.0.0.1:1000", "9.9.9.9, 1.1.1.1"}, second: [2]string{"10.0.0.1:1000", "8.8.8.8, 1.1.1.1"}, wantShared: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newLimitedServer(t)
+			srv.SetTrustProxy(tt.trustProxy)
+
+			var resp *httptest.ResponseRecorder
+			for _, from := range [][2]string{tt.first, tt.second} {
+				req := requestFrom("/api/v1/rates/last?titles=BTC", from[0])
+				if from[1] != "" {
+					req.Header.Set("X-Forwarded-For", from[1])
+				}
+				resp = srv.do(req)
+			}
+
+			want := http.StatusOK
+			if tt.wantShared {
+				want = http.StatusTooManyRequests
+			}
+			require.Equal(t, want, resp.Code)
+		})
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	_ "kursy-kriptovalyut/docs"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/pkg/errors"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
 
 	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/metrics"
 	"kursy-kriptovalyut/pkg/dto"
 	"kursy-kriptovalyut/pkg/logger"
 )
@@ -20,16 +24,41 @@ var log = logger.NewLogger()
 
 type Server struct {
 	service Service
+	admin   AdminService
+	hub     StreamHub
+	health  HealthService
+	auth    AuthService
+	assets  AssetService
+	limiter RequestLimiter
 	server  *chi.Mux
+
+	trustProxy    bool
+	cors          atomic.Pointer[corsPolicy]
+	maxQueryRange atomic.Int64
 }
 
-func NewServer(service Service) (*Server, error) {
+func NewServer(service Service, admin AdminService, hub StreamHub, health HealthService) (*Server, error) {
 	if service == nil || service == Service(nil) {
 		return nil, errors.Wrap(entities.ErrInvalidParam, "service not set")
 	}
 
+	if admin == nil || admin == AdminService(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "admin service not set")
+	}
+
+	if hub == nil || hub == StreamHub(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "stream hub not set")
+	}
+
+	if health == nil || health == HealthService(nil) {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "health service not set")
+	}
+
 	s := &Server{
 		service: service,
+		admin:   admin,
+		hub:     hub,
+		health:  health,
 		server:  chi.NewRouter(),
 	}
 
@@ -44,10 +73,39 @@ func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 // @title Chi Swagger Example
 // @version 1.0
 // @host localhost:8080
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Required when auth-enabled is set; stream endpoints also accept the api_key query parameter
 func (s *Server) routes() {
-	s.server.Get("/rates/last", s.GetLastRates)
-	s.server.Get("/rates/agg", s.GetAggregateRates)
-
+	s.server.Use(traceRequests, middleware.RequestID, requestIDHeader, s.requestLogger, traceRoute, observeRequests, s.handleCORS, s.limitRequests)
+
+	s.server.Route("/api/v1", s.v1Routes)
+
+	// маршруты без версии сохранены для совместимости со старыми клиентами
+	s.server.Group(func(r chi.Router) {
+		r.Use(s.requireScope(entities.ScopeRead, respondWithError))
+		r.Get("/rates/last", s.GetLastRates)
+		r.Get("/rates/agg", s.GetAggregateRates)
+	})
+
+	s.server.Group(func(r chi.Router) {
+		r.Use(s.requireScope(entities.ScopeAdmin, respondWithProblem))
+		r.Get("/admin/gaps", s.GetGaps)
+		r.Post("/admin/gaps/repair", s.RepairGaps)
+		r.Get("/admin/log-level", s.GetLogLevel)
+		r.Put("/admin/log-level", s.SetLogLevel)
+		r.Post("/admin/keys", s.IssueKey)
+		r.Get("/admin/keys", s.ListKeys)
+		r.Delete("/admin/keys/{id}", s.RevokeKey)
+		r.Get("/admin/keys/{id}/usage", s.GetKeyUsage)
+		// статус раскрывает список монет и сведения о сборке, поэтому при auth-enabled требует ключа admin
+		r.Get("/status", s.Status)
+	})
+
+	s.server.Get("/healthz", s.Healthz)
+	s.server.Get("/readyz", s.Readyz)
+	s.server.Handle("/metrics", metrics.Handler())
 	s.server.Mount("/swagger", httpSwagger.WrapHandler)
 	// url := httpSwagger.URL("http://localhost:8080/swagger/doc.json")
 	// s.server.Get("/swagger/*any", httpSwagger.WrapHandler(swaggerFiles.Handler, url))
@@ -60,37 +118,36 @@ func (s *Server) routes() {
 // @Param titles query string true "Comma-separated list of coin titles" example(BTC,ETH)
 // @Success 200 {array} dto.CoinDTO
 // @Failure 400 {object} dto.ErrRespDTO
+// @Failure 401 {object} dto.ErrRespDTO
+// @Failure 403 {object} dto.ErrRespDTO
+// @Failure 404 {object} dto.ErrRespDTO
+// @Failure 429 {object} dto.ErrRespDTO
 // @Failure 500 {object} dto.ErrRespDTO
+// @Deprecated
+// @Security ApiKeyAuth
 // @Router /rates/last [get]
 func (s *Server) GetLastRates(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	log.Info("(server.GetLastRates)")
 	titlesQueryParam := r.URL.Query().Get("titles")
 	if titlesQueryParam == "" {
 		log.Warn("(server.GetLastRates) missing 'titles' query parameter")
-		respondWithJSON(rw, http.StatusBadRequest, dto.ErrRespDTO{
-			StatusCode: http.StatusBadRequest,
-			Msg:        "missing 'titles' query parameter",
-		})
+		respondWithError(rw, r, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles' query parameter"))
 		return
 	}
 
-	coinTitles := strings.Split(strings.ToUpper(strings.TrimSpace(titlesQueryParam)), ",")
+	coinTitles, err := parseTitles(titlesQueryParam)
+	if err != nil {
+		log.Warn("(server.GetLastRates) invalid 'titles' query parameter", zap.Any("err", err.Error()))
+		respondWithError(rw, r, err)
+		return
+	}
 
+	r = withTitles(r, coinTitles...)
 	log.Info("(server.service.GetLastRates)")
 	coins, err := s.service.GetLastRates(r.Context(), coinTitles)
 	if err != nil {
-		if errors.Is(err, entities.ErrNotFound) {
-			respondWithJSON(rw, http.StatusNotFound, dto.ErrRespDTO{
-				StatusCode: http.StatusNotFound,
-				Msg:        err.Error(),
-			})
-			return
-		}
-
-		respondWithJSON(rw, http.StatusInternalServerError, dto.ErrRespDTO{
-			StatusCode: http.StatusInternalServerError,
-			Msg:        err.Error(),
-		})
+		respondWithError(rw, r, err)
 		return
 	}
 
@@ -110,38 +167,38 @@ func (s *Server) GetLastRates(rw http.ResponseWriter, r *http.Request) {
 // @Param aggFunc query string true "Aggregation function (MAX, MIN, AVG)" example(MAX)
 // @Success 200 {array} dto.CoinDTO
 // @Failure 400 {object} dto.ErrRespDTO
+// @Failure 401 {object} dto.ErrRespDTO
+// @Failure 403 {object} dto.ErrRespDTO
+// @Failure 404 {object} dto.ErrRespDTO
+// @Failure 429 {object} dto.ErrRespDTO
 // @Failure 500 {object} dto.ErrRespDTO
+// @Deprecated
+// @Security ApiKeyAuth
 // @Router /rates/agg [get]
 func (s *Server) GetAggregateRates(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	log.Info("(server.GetAggregateRates)")
 	titlesQueryParam := r.URL.Query().Get("titles")
 	aggFuncQueryParam := r.URL.Query().Get("aggFunc")
 	if titlesQueryParam == "" || aggFuncQueryParam == "" {
 		log.Warn("(server.GetAggregateRates) missing 'titles' or 'aggFunc' query parameters")
-		respondWithJSON(rw, http.StatusBadRequest, dto.ErrRespDTO{
-			StatusCode: http.StatusBadRequest,
-			Msg:        "missing 'titles' or 'aggFunc' query parameters"})
+		respondWithError(rw, r, errors.Wrap(entities.ErrInvalidParam, "missing 'titles' or 'aggFunc' query parameters"))
+		return
+	}
+
+	coinTitles, err := parseTitles(titlesQueryParam)
+	if err != nil {
+		log.Warn("(server.GetAggregateRates) invalid 'titles' query parameter", zap.Any("err", err.Error()))
+		respondWithError(rw, r, err)
 		return
 	}
 
-	coinTitles := strings.Split(strings.ToUpper(titlesQueryParam), ",")
 	aggFuncName := strings.ToUpper(aggFuncQueryParam)
 
+	r = withTitles(r, coinTitles...)
 	coins, err := s.service.GetAggRates(r.Context(), coinTitles, aggFuncName)
-
 	if err != nil {
-		if errors.Is(err, entities.ErrNotFound) {
-			respondWithJSON(rw, http.StatusNotFound, dto.ErrRespDTO{
-				StatusCode: http.StatusNotFound,
-				Msg:        err.Error(),
-			})
-			return
-		}
-
-		respondWithJSON(rw, http.StatusBadRequest, dto.ErrRespDTO{
-			StatusCode: http.StatusBadRequest,
-			Msg:        err.Error(),
-		})
+		respondWithError(rw, r, err)
 		return
 	}
 
@@ -157,7 +214,7 @@ func respondWithJSON(rw http.ResponseWriter, status int, data interface{}) {
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(status)
 	if err := json.NewEncoder(rw).Encode(data); err != nil {
-		log.Error("(respondWithJSON) failed to encode response")
+		log.Error("(respondWithJSON) failed to encode response", zap.Any("err", err.Error()))
 		http.Error(rw, `{"error": "internal server error"}`, http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,65 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"kursy-kriptovalyut/internal/adapters/storage"
+	"kursy-kriptovalyut/internal/cases"
+	mock_cases "kursy-kriptovalyut/internal/cases/mocks/gen"
+	"kursy-kriptovalyut/internal/ports"
+)
+
+// testServer - HTTP API поверх настоящего сервиса с хранилищем в памяти; провайдер и хранилище проверок готовности - моки
+type testServer struct {
+	*ports.Server
+	storage  *storage.Memory
+	provider *mock_cases.MockCryptoProvider
+	health   *mock_cases.MockHealthStorage
+	checker  *cases.HealthChecker
+	hub      *cases.Hub
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	memory := storage.NewMemory()
+	provider := mock_cases.NewMockCryptoProvider(ctrl)
+	service, err := cases.NewService(provider, memory)
+	require.NoError(t, err)
+
+	gaps, err := cases.NewGapDetector(memory, mock_cases.NewMockHistoryProvider(ctrl))
+	require.NoError(t, err)
+
+	hub, err := cases.NewHub(16, 16)
+	require.NoError(t, err)
+
+	healthStorage := mock_cases.NewMockHealthStorage(ctrl)
+	health, err := cases.NewHealthChecker(healthStorage, 9, 0)
+	require.NoError(t, err)
+
+	server, err := ports.NewServer(service, gaps, hub, health)
+	require.NoError(t, err)
+
+	return &testServer{
+		Server:   server,
+		storage:  memory,
+		provider: provider,
+		health:   healthStorage,
+		checker:  health,
+		hub:      hub,
+	}
+}
+
+// do выполняет запрос к серверу и возвращает записанный ответ
+func (s *testServer) do(r *http.Request) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, r)
+
+	return rw
+}
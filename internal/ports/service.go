@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"kursy-kriptovalyut/internal/entities"
 )
@@ -9,4 +10,39 @@ import (
 type Service interface {
 	GetLastRates(ctx context.Context, titles []string) ([]entities.Coin, error)
 	GetAggRates(ctx context.Context, titles []string, aggFuncName string) ([]entities.Coin, error)
+	GetHistory(ctx context.Context, title string, from, to time.Time) ([]entities.Coin, error)
+	GetCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Candle, error)
+	StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(entities.Coin) error) error
+	StreamCandles(ctx context.Context, title string, interval entities.Interval, from, to time.Time, fn func(entities.Candle) error) error
+	QueryRates(ctx context.Context, queries []entities.RateQuery) ([]entities.RateResult, error)
+	Quote() string
+}
+
+type AdminService interface {
+	FindGaps(ctx context.Context, title string, interval entities.Interval, from, to time.Time) ([]entities.Gap, error)
+	RepairGaps(ctx context.Context, title string, interval entities.Interval, from, to time.Time) (int, error)
+}
+
+// StreamHub - шина событий о новых ценах; канал закрывается, когда подписка прекращена
+type StreamHub interface {
+	Subscribe(titles []string, lastEventID uint64) (<-chan entities.PriceEvent, func())
+}
+
+// AuthService проверяет API-ключи и управляет ими
+type AuthService interface {
+	Authorize(ctx context.Context, token string, scope entities.Scope) (entities.APIKey, entities.RateLimit, error)
+	IssueKey(ctx context.Context, name string, scopes []entities.Scope, rateLimit int) (entities.APIKey, string, error)
+	ListKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeKey(ctx context.Context, id int64) error
+	GetUsage(ctx context.Context, id int64, from, to time.Time) ([]entities.KeyUsage, error)
+}
+
+// AssetService - каталог известных монет
+type AssetService interface {
+	Search(ctx context.Context, search string, limit int) ([]entities.Asset, error)
+}
+
+type HealthService interface {
+	Ready(ctx context.Context) []entities.Check
+	Status(ctx context.Context) (entities.Status, error)
 }
@@ -0,0 +1,145 @@
+package ports
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	streamHeartbeat  = 15 * time.Second
+	streamRetryMs    = 3000
+	lastEventIDParam = "Last-Event-ID"
+)
+
+// @Summary Stream rates
+// @Description Server-Sent Events stream of new prices of the specified coins.
+// @Description Every event has type "price", an id and a dto.RateDTO as data; a comment line is sent as a heartbeat.
+// @Description The id is the price timestamp in Unix microseconds, the same on every replica, and prices of several coins may share it.
+// @Description Send the Last-Event-ID header (or lastEventId query parameter) on reconnect to receive missed events;
+// @Description events with the same id as Last-Event-ID are sent again.
+// @Description Clients that do not keep up are disconnected and should reconnect with Last-Event-ID.
+// @Tags v1
+// @Produce text/event-stream,application/problem+json
+// @Param titles query string true "Comma-separated list of coin titles" example(BTC,ETH)
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Param lastEventId query string false "Id of the last received event, for clients that cannot set headers"
+// @Success 200 {object} dto.RateDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/stream [get]
+func (s *Server) StreamV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.StreamV1)")
+	titles, err := parseTitles(r.URL.Query().Get("titles"))
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+	r = withTitles(r, titles...)
+	log = logger.FromContext(r.Context())
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInternal, "streaming is not supported by the connection"))
+		return
+	}
+
+	events, unsubscribe := s.hub.Subscribe(titles, lastEventID)
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	fmt.Fprintf(rw, "retry: %d\n\n", streamRetryMs)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(rw, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				log.Info("(server.StreamV1) subscription closed")
+				return
+			}
+
+			if err := writeSSEEvent(rw, event, s.service.Quote()); err != nil {
+				log.Warn("(server.StreamV1) failed to write event", zap.Any("err", err.Error()))
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeSSEEvent(rw http.ResponseWriter, event entities.PriceEvent, quote string) error {
+	data, err := json.Marshal(toRateDTOs([]entities.Coin{event.Coin}, quote)[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(rw, "id: %d\nevent: price\ndata: %s\n\n", event.ID, data)
+	return err
+}
+
+// parseTitles разбирает список монет через запятую; неверные тикеры перечисляются в ошибке
+func parseTitles(raw string) ([]string, error) {
+	titles, err := entities.NormalizeTitles(strings.Split(raw, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(titles) == 0 {
+		return nil, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles' query parameter")
+	}
+
+	return titles, nil
+}
+
+func parseLastEventID(r *http.Request) (uint64, error) {
+	raw := r.Header.Get(lastEventIDParam)
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventId")
+	}
+
+	if raw == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(entities.ErrInvalidParam, "invalid Last-Event-ID, expected a non-negative integer")
+	}
+
+	return id, nil
+}
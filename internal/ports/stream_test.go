@@ -0,0 +1,122 @@
+package ports_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+// sseEvent - событие из потока text/event-stream
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSEEvent читает поток до ближайшего события, пропуская retry и комментарии
+func readSSEEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.event != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamV1(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	missed := now.Add(-time.Minute)
+	srv.hub.Publish([]entities.Coin{{Title: "BTC", Price: 99, CreatedAt: now.Add(-2 * time.Minute)}})
+	srv.hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: missed}, {Title: "ETH", Price: 10, CreatedAt: missed}})
+
+	// клиент уже получил цену ETH с этим id и переподключается
+	req, err := http.NewRequest(http.MethodGet, httpSrv.URL+"/api/v1/stream?titles=btc", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(missed.UnixMicro(), 10))
+
+	resp, err := httpSrv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(resp.Body)
+	retry, err := body.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "retry: 3000\n", retry)
+
+	// пропущенная цена BTC с тем же id отдаётся из буфера, более старая - нет
+	event := readSSEEvent(t, body)
+	require.Equal(t, "price", event.event)
+	require.Equal(t, strconv.FormatInt(missed.UnixMicro(), 10), event.id)
+
+	var rate dto.RateDTO
+	require.NoError(t, json.Unmarshal([]byte(event.data), &rate))
+	require.Equal(t, "BTC", rate.Title)
+	require.Equal(t, 100.0, rate.Price)
+
+	// новые цены приходят после подключения, чужие монеты отфильтрованы
+	srv.hub.Publish([]entities.Coin{{Title: "ETH", Price: 11, CreatedAt: now}, {Title: "BTC", Price: 101, CreatedAt: now}})
+	event = readSSEEvent(t, body)
+	require.Equal(t, strconv.FormatInt(now.UnixMicro(), 10), event.id)
+	require.NoError(t, json.Unmarshal([]byte(event.data), &rate))
+	require.Equal(t, "BTC", rate.Title)
+	require.Equal(t, 101.0, rate.Price)
+}
+
+func TestStreamV1_BadRequest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		url         string
+		lastEventID string
+	}{
+		{name: "missing titles", url: "/api/v1/stream"},
+		{name: "invalid title", url: "/api/v1/stream?titles=B$C"},
+		{name: "invalid last event id header", url: "/api/v1/stream?titles=BTC", lastEventID: "-1"},
+		{name: "invalid last event id query", url: "/api/v1/stream?titles=BTC&lastEventId=abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(t)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+
+			resp := srv.do(req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+			require.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests открывает span на каждый запрос, продолжая трассу из входящего traceparent
+var traceRequests = otelhttp.NewMiddleware("http.server")
+
+// traceRoute называет span запроса по шаблону маршрута и добавляет в него идентификатор запроса
+func traceRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r)
+
+		span := trace.SpanFromContext(r.Context())
+		if route := chi.RouteContext(r.Context()).RoutePattern(); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetAttributes(attribute.String("request.id", middleware.GetReqID(r.Context())))
+	})
+}
@@ -0,0 +1,105 @@
+package ports_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/internal/tracing"
+)
+
+// spanRecorder подключается к глобальному TracerProvider один раз: otel передаёт вызовы только первому установленному
+var spanRecorder = sync.OnceValue(func() *tracetest.SpanRecorder {
+	if _, err := tracing.Setup(tracing.Config{Exporter: tracing.ExporterNone}); err != nil {
+		panic(err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	return recorder
+})
+
+// endedSpans возвращает завершённые span'ы трассы по имени
+func endedSpans(recorder *tracetest.SpanRecorder, traceID string) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	return spans
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+
+	return ""
+}
+
+func TestTraceRequests(t *testing.T) {
+	t.Parallel()
+	recorder := spanRecorder()
+	srv := newTestServer(t)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{{Title: "BTC", Price: 100, CreatedAt: time.Now()}}))
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rates/last?titles=BTC", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+
+	resp := srv.do(req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// span запроса продолжает входящую трассу и назван по шаблону маршрута
+	spans := endedSpans(recorder, traceID)
+	server, ok := spans["GET /api/v1/rates/last"]
+	require.True(t, ok)
+	require.Equal(t, parentID, server.Parent().SpanID().String())
+	require.True(t, server.Parent().IsRemote())
+	require.Equal(t, "/api/v1/rates/last", spanAttribute(server, "http.route"))
+	require.Equal(t, resp.Header().Get(middleware.RequestIDHeader), spanAttribute(server, "request.id"))
+
+	// вызов сервиса - дочерний span запроса
+	service, ok := spans["Service.GetLastRates"]
+	require.True(t, ok)
+	require.Equal(t, server.SpanContext().SpanID(), service.Parent().SpanID())
+}
+
+func TestTraceRequests_NewTrace(t *testing.T) {
+	t.Parallel()
+	recorder := spanRecorder()
+	srv := newTestServer(t)
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// без traceparent запрос начинает новую трассу
+	requestID := resp.Header().Get(middleware.RequestIDHeader)
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /healthz" && spanAttribute(span, "request.id") == requestID {
+			found = true
+			require.False(t, span.Parent().IsValid())
+		}
+	}
+	require.True(t, found)
+}
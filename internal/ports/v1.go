@@ -0,0 +1,270 @@
+package ports
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+func (s *Server) v1Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireScope(entities.ScopeRead, respondWithProblem))
+		r.Get("/rates/last", s.GetLastRatesV1)
+		r.Get("/rates/agg", s.GetAggregateRatesV1)
+		r.Post("/rates/query", s.QueryRatesV1)
+		r.Get("/rates/history", s.GetHistoryV1)
+		r.Get("/rates/candles", s.GetCandlesV1)
+		r.Get("/assets", s.GetAssetsV1)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireScope(entities.ScopeStream, respondWithProblem))
+		r.Get("/stream", s.StreamV1)
+		r.Get("/ws", s.WebSocketV1)
+	})
+}
+
+// requestIDHeader отдаёт клиенту идентификатор запроса, сгенерированный middleware.RequestID
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// @Summary Get last rates
+// @Description Get the latest rates for specified coins with timestamp, source and staleness of every item
+// @Tags v1
+// @Produce json,application/problem+json
+// @Param titles query string true "Comma-separated list of coin titles" example(BTC,ETH)
+// @Success 200 {object} dto.EnvelopeDTO{data=[]dto.RateDTO}
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Failure 502 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/rates/last [get]
+func (s *Server) GetLastRatesV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.GetLastRatesV1)")
+	titlesQueryParam := r.URL.Query().Get("titles")
+	if titlesQueryParam == "" {
+		log.Warn("(server.GetLastRatesV1) missing 'titles' query parameter")
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles' query parameter"))
+		return
+	}
+
+	coinTitles, err := parseTitles(titlesQueryParam)
+	if err != nil {
+		log.Warn("(server.GetLastRatesV1) invalid 'titles' query parameter", zap.Any("err", err.Error()))
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	r = withTitles(r, coinTitles...)
+	coins, err := s.service.GetLastRates(r.Context(), coinTitles)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	respondWithEnvelope(rw, r, toRateDTOs(coins, s.service.Quote()))
+}
+
+// @Summary Get aggregated rates
+// @Description Get aggregated rates for specified coins using an aggregation function
+// @Tags v1
+// @Produce json,application/problem+json
+// @Param titles query string true "Comma-separated list of coin titles" example(BTC,ETH)
+// @Param aggFunc query string true "Aggregation function (MAX, MIN, AVG)" example(MAX)
+// @Success 200 {object} dto.EnvelopeDTO{data=[]dto.RateDTO}
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Failure 502 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/rates/agg [get]
+func (s *Server) GetAggregateRatesV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.GetAggregateRatesV1)")
+	titlesQueryParam := r.URL.Query().Get("titles")
+	aggFuncQueryParam := r.URL.Query().Get("aggFunc")
+	if titlesQueryParam == "" {
+		log.Warn("(server.GetAggregateRatesV1) missing 'titles' query parameter")
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles' query parameter"))
+		return
+	}
+	if aggFuncQueryParam == "" {
+		log.Warn("(server.GetAggregateRatesV1) missing 'aggFunc' query parameter")
+		respondWithProblem(rw, r, errors.Wrap(entities.ErrUnknownAggregate, "missing 'aggFunc' query parameter"))
+		return
+	}
+
+	coinTitles, err := parseTitles(titlesQueryParam)
+	if err != nil {
+		log.Warn("(server.GetAggregateRatesV1) invalid 'titles' query parameter", zap.Any("err", err.Error()))
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	aggFuncName := strings.ToUpper(aggFuncQueryParam)
+
+	r = withTitles(r, coinTitles...)
+	coins, err := s.service.GetAggRates(r.Context(), coinTitles, aggFuncName)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	respondWithEnvelope(rw, r, toRateDTOs(coins, s.service.Quote()))
+}
+
+// @Summary Get rate history
+// @Description Get stored price points of a coin; old periods are served from hourly/daily rollups
+// @Description A json response covers at most the max-query-range setting (31 days by default); longer ranges are rejected with 400
+// @Tags v1
+// @Produce json,text/csv,application/x-ndjson,application/problem+json
+// @Param title query string true "Coin title" example(BTC)
+// @Param from query string false "Range start, RFC3339 (default: 24h before 'to')"
+// @Param to query string false "Range end, RFC3339 (default: now)"
+// @Param format query string false "Response format (json, csv, ndjson); overrides the Accept header. csv and ndjson are streamed row by row and are not subject to the json range limit"
+// @Success 200 {object} dto.EnvelopeDTO{data=dto.HistoryRespDTO}
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Failure 502 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/rates/history [get]
+func (s *Server) GetHistoryV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.GetHistoryV1)")
+	q, err := parseRangeQuery(r)
+	if err != nil {
+		log.Warn("(server.GetHistoryV1) invalid query parameters", zap.Any("err", err.Error()))
+		respondWithProblem(rw, r, err)
+		return
+	}
+	r = withTitles(r, q.title)
+
+	if q.format != formatJSON {
+		if err := s.exportHistory(rw, r, q); err != nil {
+			respondWithProblem(rw, r, err)
+		}
+		return
+	}
+
+	if err := s.checkQueryRange(q, true); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	coins, err := s.service.GetHistory(r.Context(), q.title, q.from, q.to)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	respondWithEnvelope(rw, r, toHistoryDTO(q.title, coins))
+}
+
+// @Summary Get candles
+// @Description Get OHLC candles of a coin; the best available resolution is used for old periods
+// @Description A json response covers at most the max-query-range setting (31 days by default); longer ranges are rejected with 400
+// @Tags v1
+// @Produce json,text/csv,application/x-ndjson,application/problem+json
+// @Param title query string true "Coin title" example(BTC)
+// @Param interval query string false "Candle interval (1m, 1h, 1d)" default(1m)
+// @Param from query string false "Range start, RFC3339 (default: 24h before 'to')"
+// @Param to query string false "Range end, RFC3339 (default: now)"
+// @Param format query string false "Response format (json, csv, ndjson); overrides the Accept header. csv and ndjson are streamed row by row and are not subject to the json range limit"
+// @Success 200 {object} dto.EnvelopeDTO{data=dto.CandlesRespDTO}
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Failure 502 {object} dto.ProblemDTO
+// @Failure 503 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/rates/candles [get]
+func (s *Server) GetCandlesV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.GetCandlesV1)")
+	q, err := parseRangeQuery(r)
+	if err != nil {
+		log.Warn("(server.GetCandlesV1) invalid query parameters", zap.Any("err", err.Error()))
+		respondWithProblem(rw, r, err)
+		return
+	}
+	r = withTitles(r, q.title)
+
+	if q.format != formatJSON {
+		if err := s.exportCandles(rw, r, q); err != nil {
+			respondWithProblem(rw, r, err)
+		}
+		return
+	}
+
+	if err := s.checkQueryRange(q, true); err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	candles, err := s.service.GetCandles(r.Context(), q.title, q.interval, q.from, q.to)
+	if err != nil {
+		respondWithProblem(rw, r, err)
+		return
+	}
+
+	respondWithEnvelope(rw, r, toCandlesDTO(q.title, q.interval, candles))
+}
+
+func toRateDTOs(coins []entities.Coin, quote string) []dto.RateDTO {
+	now := time.Now()
+	rates := make([]dto.RateDTO, 0, len(coins))
+	for _, coin := range coins {
+		rate := dto.RateDTO{
+			Title:     coin.Title,
+			Quote:     quote,
+			Price:     coin.Price,
+			Timestamp: coin.CreatedAt,
+			Source:    coin.Source,
+		}
+		if !coin.CreatedAt.IsZero() {
+			rate.StalenessSec = now.Sub(coin.CreatedAt).Seconds()
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates
+}
+
+func respondWithEnvelope(rw http.ResponseWriter, r *http.Request, data interface{}) {
+	respondWithJSON(rw, http.StatusOK, dto.EnvelopeDTO{
+		RequestID: middleware.GetReqID(r.Context()),
+		Data:      data,
+	})
+}
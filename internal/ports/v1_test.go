@@ -0,0 +1,97 @@
+package ports_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+// envelope - EnvelopeDTO с типизированными данными
+type envelope[T any] struct {
+	RequestID string `json:"request_id"`
+	Data      T      `json:"data"`
+}
+
+func decodeJSON[T any](t *testing.T, resp *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+
+	return v
+}
+
+func TestGetLastRatesV1(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	updated := time.Now().Add(-time.Minute)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: updated},
+		{Title: "ETH", Price: 10, CreatedAt: updated},
+	}))
+
+	resp := srv.do(httptest.NewRequest(http.MethodGet, "/api/v1/rates/last?titles=btc,ETH", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	body := decodeJSON[envelope[[]dto.RateDTO]](t, resp)
+	require.NotEmpty(t, body.RequestID)
+	require.Equal(t, resp.Header().Get(middleware.RequestIDHeader), body.RequestID)
+	require.Len(t, body.Data, 2)
+	for _, rate := range body.Data {
+		require.Equal(t, entities.DefaultQuote, rate.Quote)
+		require.Equal(t, entities.SourceStorage, rate.Source)
+		require.True(t, rate.Timestamp.Equal(updated))
+		require.GreaterOrEqual(t, rate.StalenessSec, 60.0)
+	}
+}
+
+func TestGetHistoryV1(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	to := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: to.Add(-2 * time.Minute)},
+		{Title: "BTC", Price: 101, CreatedAt: to.Add(-time.Minute)},
+	}))
+
+	url := "/api/v1/rates/history?title=BTC&from=" + to.Add(-time.Hour).Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339)
+	resp := srv.do(httptest.NewRequest(http.MethodGet, url, nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := decodeJSON[envelope[dto.HistoryRespDTO]](t, resp)
+	require.NotEmpty(t, body.RequestID)
+	require.Equal(t, "BTC", body.Data.Title)
+	require.Len(t, body.Data.Points, 2)
+	require.Equal(t, 100.0, body.Data.Points[0].Price)
+	require.Equal(t, 101.0, body.Data.Points[1].Price)
+}
+
+func TestGetCandlesV1(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	to := time.Now().UTC().Truncate(time.Minute)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: to.Add(-2*time.Minute + time.Second)},
+		{Title: "BTC", Price: 104, CreatedAt: to.Add(-2*time.Minute + 2*time.Second)},
+	}))
+
+	url := "/api/v1/rates/candles?title=BTC&interval=1m&from=" + to.Add(-time.Hour).Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339)
+	resp := srv.do(httptest.NewRequest(http.MethodGet, url, nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := decodeJSON[envelope[dto.CandlesRespDTO]](t, resp)
+	require.Equal(t, "1m", body.Data.Interval)
+	require.Len(t, body.Data.Candles, 1)
+	require.Equal(t, 100.0, body.Data.Candles[0].Open)
+	require.Equal(t, 104.0, body.Data.Candles[0].Close)
+	require.Equal(t, 2, body.Data.Candles[0].Count)
+}
@@ -0,0 +1,324 @@
+package ports
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsWriteWait    = 10 * time.Second
+	wsReadLimit    = 4096
+	wsMessageRate  = 5
+	wsMessageBurst = 10
+	wsMaxTitles    = 50
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// API отдаёт только публичные курсы и не опирается на cookies, поэтому подключения принимаются с любых источников
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Summary WebSocket subscriptions
+// @Description Bidirectional stream of rates. Client messages are dto.WSRequestDTO, server messages are dto.WSMessageDTO.
+// @Description {"op":"subscribe","titles":["BTC"]} answers with a "snapshot" of the latest rates, followed by "update" messages with new prices.
+// @Description {"op":"unsubscribe","titles":["BTC"]} answers with "unsubscribed"; {"op":"ping"} answers with "pong".
+// @Description Failed messages are answered with "error" carrying a problem description. The server sends ping frames every 30s
+// @Description and closes connections that do not answer within 60s. A client may send up to 5 messages per second (burst 10)
+// @Description and follow up to 50 titles. If the client does not keep up with updates, the connection is closed with code 1013 and should be re-established.
+// @Tags v1
+// @Param request body dto.WSRequestDTO false "Client message (sent over the socket)"
+// @Success 101 {object} dto.WSMessageDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 429 {object} dto.ProblemDTO
+// @Security ApiKeyAuth
+// @Router /api/v1/ws [get]
+func (s *Server) WebSocketV1(rw http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	log.Info("(server.WebSocketV1)")
+	conn, err := wsUpgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		// Upgrade уже ответил клиенту ошибкой
+		log.Warn("(server.WebSocketV1) failed to upgrade connection", zap.Any("err", err.Error()))
+		return
+	}
+
+	c := &wsClient{
+		server: s,
+		conn:   conn,
+		r:      r,
+		titles: make(map[string]struct{}),
+		seen:   make(map[string]time.Time),
+	}
+	c.run()
+}
+
+type wsInbound struct {
+	req dto.WSRequestDTO
+	err error
+}
+
+// wsClient - состояние одного WebSocket-соединения; пишет в соединение только горутина run
+type wsClient struct {
+	server *Server
+	conn   *websocket.Conn
+	r      *http.Request
+
+	titles map[string]struct{}
+	// время последней отправленной клиенту цены по монете, чтобы не присылать обновления старше snapshot
+	seen        map[string]time.Time
+	events      <-chan entities.PriceEvent
+	unsubscribe func()
+	lastEventID uint64
+}
+
+func (c *wsClient) run() {
+	log := logger.FromContext(c.r.Context())
+	defer c.conn.Close()
+	defer func() {
+		if c.unsubscribe != nil {
+			c.unsubscribe()
+		}
+	}()
+
+	inbound := make(chan wsInbound)
+	done := make(chan struct{})
+	defer close(done)
+	go c.readLoop(inbound, done)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case in, ok := <-inbound:
+			if !ok {
+				return
+			}
+			err = c.handle(in)
+		case event, ok := <-c.events:
+			if !ok {
+				log.Info("(wsClient.run) subscription closed")
+				c.closeWith(websocket.CloseTryAgainLater, "subscription closed, reconnect")
+				return
+			}
+			err = c.sendUpdate(event)
+		case <-ping.C:
+			err = c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		}
+
+		if err != nil {
+			log.Warn("(wsClient.run) failed to write message", zap.Any("err", err.Error()))
+			return
+		}
+	}
+}
+
+// readLoop читает сообщения клиента и ограничивает их частоту; закрывает inbound при обрыве соединения
+func (c *wsClient) readLoop(inbound chan<- wsInbound, done <-chan struct{}) {
+	defer close(inbound)
+
+	c.conn.SetReadLimit(wsReadLimit)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	limiter := rate.NewLimiter(wsMessageRate, wsMessageBurst)
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var in wsInbound
+		if !limiter.Allow() {
+			in.err = errors.Wrap(entities.ErrRateLimited, "too many messages, slow down")
+		} else if err := json.Unmarshal(data, &in.req); err != nil {
+			in.err = errors.Wrap(entities.ErrInvalidParam, "message must be a JSON object")
+		}
+
+		select {
+		case inbound <- in:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *wsClient) handle(in wsInbound) error {
+	if in.err != nil {
+		return c.sendError(in.req.ID, in.err)
+	}
+
+	switch strings.ToLower(in.req.Op) {
+	case "subscribe":
+		return c.subscribe(in.req)
+	case "unsubscribe":
+		return c.unsubscribeTitles(in.req)
+	case "ping":
+		return c.send(dto.WSMessageDTO{Type: "pong", ID: in.req.ID})
+	default:
+		return c.sendError(in.req.ID, errors.Wrapf(entities.ErrInvalidParam, "unknown op %q", in.req.Op))
+	}
+}
+
+func (c *wsClient) subscribe(req dto.WSRequestDTO) error {
+	titles, err := entities.NormalizeTitles(req.Titles)
+	if err != nil {
+		return c.sendError(req.ID, err)
+	}
+	if len(titles) == 0 {
+		return c.sendError(req.ID, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles'"))
+	}
+
+	if quote := strings.ToUpper(req.Quote); quote != "" && quote != c.server.service.Quote() {
+		return c.sendError(req.ID, errors.Wrapf(entities.ErrInvalidParam, "unsupported quote %q, only %v is available", req.Quote, c.server.service.Quote()))
+	}
+
+	added := make([]string, 0, len(titles))
+	for _, title := range titles {
+		if _, ok := c.titles[title]; !ok {
+			added = append(added, title)
+		}
+	}
+
+	if len(c.titles)+len(added) > wsMaxTitles {
+		return c.sendError(req.ID, errors.Wrapf(entities.ErrInvalidParam, "no more than %d titles per connection", wsMaxTitles))
+	}
+
+	if len(added) == 0 {
+		return c.send(dto.WSMessageDTO{Type: "snapshot", ID: req.ID, Rates: []dto.RateDTO{}})
+	}
+
+	// подписываемся до чтения snapshot, чтобы не потерять цены, записанные между ними;
+	// обновления не новее snapshot отсекаются в sendUpdate
+	for _, title := range added {
+		c.titles[title] = struct{}{}
+	}
+	if err := c.resubscribe(); err != nil {
+		return err
+	}
+
+	coins, err := c.server.service.GetLastRates(c.r.Context(), added)
+	if err != nil {
+		for _, title := range added {
+			delete(c.titles, title)
+		}
+		if err := c.resubscribe(); err != nil {
+			return err
+		}
+
+		return c.sendError(req.ID, err)
+	}
+
+	for _, coin := range coins {
+		if coin.CreatedAt.After(c.seen[coin.Title]) {
+			c.seen[coin.Title] = coin.CreatedAt
+		}
+	}
+
+	return c.send(dto.WSMessageDTO{Type: "snapshot", ID: req.ID, Rates: toRateDTOs(coins, c.server.service.Quote())})
+}
+
+func (c *wsClient) unsubscribeTitles(req dto.WSRequestDTO) error {
+	titles, err := entities.NormalizeTitles(req.Titles)
+	if err != nil {
+		return c.sendError(req.ID, err)
+	}
+	if len(titles) == 0 {
+		return c.sendError(req.ID, errors.Wrap(entities.ErrInvalidSymbol, "missing 'titles'"))
+	}
+
+	for _, title := range titles {
+		delete(c.titles, title)
+		delete(c.seen, title)
+	}
+
+	if err := c.resubscribe(); err != nil {
+		return err
+	}
+
+	return c.send(dto.WSMessageDTO{Type: "unsubscribed", ID: req.ID, Titles: titles})
+}
+
+// resubscribe переоформляет подписку в шине на текущий набор монет. События, уже стоявшие
+// в очереди старой подписки, доставляются, а пропущенные за время переключения дочитываются по lastEventID.
+func (c *wsClient) resubscribe() error {
+	pending := make([]entities.PriceEvent, 0)
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+		for event := range c.events {
+			pending = append(pending, event)
+		}
+		c.events, c.unsubscribe = nil, nil
+	}
+
+	if len(c.titles) > 0 {
+		titles := make([]string, 0, len(c.titles))
+		for title := range c.titles {
+			titles = append(titles, title)
+		}
+		c.events, c.unsubscribe = c.server.hub.Subscribe(titles, c.lastEventID)
+	}
+
+	for _, event := range pending {
+		if err := c.sendUpdate(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *wsClient) sendUpdate(event entities.PriceEvent) error {
+	// у цен разных монет id может совпадать, поэтому равный id не отсекается: повторы отсекает seen
+	title := event.Coin.Title
+	if _, ok := c.titles[title]; !ok || event.ID < c.lastEventID {
+		return nil
+	}
+	c.lastEventID = event.ID
+
+	if !event.Coin.CreatedAt.After(c.seen[title]) {
+		return nil
+	}
+	c.seen[title] = event.Coin.CreatedAt
+
+	rate := toRateDTOs([]entities.Coin{event.Coin}, c.server.service.Quote())[0]
+	return c.send(dto.WSMessageDTO{Type: "update", EventID: event.ID, Rate: &rate})
+}
+
+func (c *wsClient) sendError(id string, err error) error {
+	_, problem := newProblem(err, c.r)
+	return c.send(dto.WSMessageDTO{Type: "error", ID: id, Error: &problem})
+}
+
+func (c *wsClient) send(msg dto.WSMessageDTO) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *wsClient) closeWith(code int, reason string) {
+	log := logger.FromContext(c.r.Context())
+	msg := websocket.FormatCloseMessage(code, reason)
+	if err := c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait)); err != nil {
+		log.Warn("(wsClient.closeWith) failed to send close frame", zap.Any("err", err.Error()))
+	}
+}
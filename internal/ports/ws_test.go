@@ -0,0 +1,135 @@
+package ports_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"kursy-kriptovalyut/internal/entities"
+	"kursy-kriptovalyut/pkg/dto"
+)
+
+// dialWS подключается к WebSocket API тестового сервера
+func dialWS(t *testing.T, srv *testServer) *websocket.Conn {
+	t.Helper()
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSrv.URL, "http")+"/api/v1/ws", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// roundTrip отправляет сообщение клиента и читает ближайшее сообщение сервера
+func roundTrip(t *testing.T, conn *websocket.Conn, req dto.WSRequestDTO) dto.WSMessageDTO {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(req))
+
+	return readWS(t, conn)
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) dto.WSMessageDTO {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg dto.WSMessageDTO
+	require.NoError(t, conn.ReadJSON(&msg))
+
+	return msg
+}
+
+func TestWebSocketV1_SubscribeAndUpdates(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	updated := time.Now().UTC().Add(-time.Minute).Truncate(time.Microsecond)
+	require.NoError(t, srv.storage.Store(context.Background(), []entities.Coin{
+		{Title: "BTC", Price: 100, CreatedAt: updated},
+		{Title: "ETH", Price: 10, CreatedAt: updated},
+	}))
+	conn := dialWS(t, srv)
+
+	snapshot := roundTrip(t, conn, dto.WSRequestDTO{Op: "subscribe", ID: "1", Titles: []string{"btc"}})
+	require.Equal(t, "snapshot", snapshot.Type)
+	require.Equal(t, "1", snapshot.ID)
+	require.Len(t, snapshot.Rates, 1)
+	require.Equal(t, "BTC", snapshot.Rates[0].Title)
+	require.Equal(t, 100.0, snapshot.Rates[0].Price)
+
+	// повторная подписка отвечает пустым snapshot
+	again := roundTrip(t, conn, dto.WSRequestDTO{Op: "subscribe", ID: "2", Titles: []string{"BTC"}})
+	require.Equal(t, "snapshot", again.Type)
+	require.NotNil(t, again.Rates)
+	require.Empty(t, again.Rates)
+
+	// цена не новее snapshot и цены чужих монет не присылаются
+	now := updated.Add(time.Minute)
+	srv.hub.Publish([]entities.Coin{{Title: "BTC", Price: 100, CreatedAt: updated}})
+	srv.hub.Publish([]entities.Coin{{Title: "ETH", Price: 11, CreatedAt: now}, {Title: "BTC", Price: 101, CreatedAt: now}})
+
+	update := readWS(t, conn)
+	require.Equal(t, "update", update.Type)
+	require.Equal(t, uint64(now.UnixMicro()), update.EventID)
+	require.NotNil(t, update.Rate)
+	require.Equal(t, "BTC", update.Rate.Title)
+	require.Equal(t, 101.0, update.Rate.Price)
+
+	unsubscribed := roundTrip(t, conn, dto.WSRequestDTO{Op: "unsubscribe", ID: "3", Titles: []string{"BTC"}})
+	require.Equal(t, "unsubscribed", unsubscribed.Type)
+	require.Equal(t, []string{"BTC"}, unsubscribed.Titles)
+
+	// после отписки обновления не приходят: следующим сообщением будет pong
+	srv.hub.Publish([]entities.Coin{{Title: "BTC", Price: 102, CreatedAt: now.Add(time.Minute)}})
+	pong := roundTrip(t, conn, dto.WSRequestDTO{Op: "ping", ID: "4"})
+	require.Equal(t, "pong", pong.Type)
+	require.Equal(t, "4", pong.ID)
+}
+
+func TestWebSocketV1_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		req      dto.WSRequestDTO
+		wantCode string
+	}{
+		{name: "unknown op", req: dto.WSRequestDTO{Op: "publish", ID: "1"}, wantCode: "invalid_param"},
+		{name: "missing titles", req: dto.WSRequestDTO{Op: "subscribe", ID: "1"}, wantCode: "invalid_symbol"},
+		{name: "invalid title", req: dto.WSRequestDTO{Op: "subscribe", ID: "1", Titles: []string{"B$C"}}, wantCode: "invalid_symbol"},
+		{name: "unsupported quote", req: dto.WSRequestDTO{Op: "subscribe", ID: "1", Titles: []string{"BTC"}, Quote: "EUR"}, wantCode: "invalid_param"},
+		{name: "unsubscribe without titles", req: dto.WSRequestDTO{Op: "unsubscribe", ID: "1"}, wantCode: "invalid_symbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			conn := dialWS(t, newTestServer(t))
+
+			msg := roundTrip(t, conn, tt.req)
+			require.Equal(t, "error", msg.Type)
+			require.Equal(t, tt.req.ID, msg.ID)
+			require.NotNil(t, msg.Error)
+			require.Equal(t, tt.wantCode, msg.Error.Code)
+
+			// после ошибки соединение продолжает работать
+			require.Equal(t, "pong", roundTrip(t, conn, dto.WSRequestDTO{Op: "ping"}).Type)
+		})
+	}
+}
+
+func TestWebSocketV1_MalformedMessage(t *testing.T) {
+	t.Parallel()
+	conn := dialWS(t, newTestServer(t))
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("subscribe BTC")))
+	msg := readWS(t, conn)
+	require.Equal(t, "error", msg.Type)
+	require.NotNil(t, msg.Error)
+	require.Equal(t, http.StatusBadRequest, msg.Error.Status)
+}
@@ -1,15 +1,234 @@
 package dto
 
+import "time"
+
 type CoinDTO struct {
 	Title string  `json:"title"`
 	Price float64 `json:"price"`
 }
 
+// EnvelopeDTO - обёртка ответов /api/v1
+type EnvelopeDTO struct {
+	RequestID string      `json:"request_id"`
+	Data      interface{} `json:"data"`
+}
+
+type RateDTO struct {
+	Title        string    `json:"title"`
+	Quote        string    `json:"quote"`
+	Price        float64   `json:"price"`
+	Timestamp    time.Time `json:"timestamp"`
+	Source       string    `json:"source"`
+	StalenessSec float64   `json:"staleness_sec"`
+}
+
+// WSRequestDTO - сообщение клиента WebSocket API (/api/v1/ws):
+//
+//	{"op": "subscribe", "titles": ["BTC", "ETH"], "quote": "USD"} - подписаться; в ответ приходит snapshot, затем update
+//	{"op": "unsubscribe", "titles": ["BTC"]} - отписаться; в ответ приходит unsubscribed
+//	{"op": "ping"} - проверка связи; в ответ приходит pong
+//
+// Quote необязателен, поддерживается только USD. Id, если передан, возвращается в ответе на сообщение.
+type WSRequestDTO struct {
+	Op     string   `json:"op"`
+	ID     string   `json:"id,omitempty"`
+	Titles []string `json:"titles,omitempty"`
+	Quote  string   `json:"quote,omitempty"`
+}
+
+// WSMessageDTO - сообщение сервера WebSocket API; заполнены только поля, относящиеся к Type:
+//
+//	snapshot - последние цены только что подписанных монет (Rates); пустой snapshot приходит с пустым rates
+//	update - новая цена монеты (EventID, Rate); обновления не старше snapshot не присылаются
+//	unsubscribed - подтверждение отписки (Titles)
+//	pong - ответ на ping
+//	error - ошибка обработки сообщения клиента (Error)
+type WSMessageDTO struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	EventID uint64      `json:"event_id,omitempty"`
+	Rate    *RateDTO    `json:"rate,omitempty"`
+	Rates   []RateDTO   `json:"rates"`
+	Titles  []string    `json:"titles,omitempty"`
+	Error   *ProblemDTO `json:"error,omitempty"`
+}
+
+// RateQueryItemDTO - элемент пакетного запроса; Window и MaxAge - длительности в формате Go (30s, 15m, 24h)
+type RateQueryItemDTO struct {
+	Title  string `json:"title" example:"BTC"`
+	Quote  string `json:"quote,omitempty" example:"USD"`
+	Mode   string `json:"mode,omitempty" example:"last" enums:"last,max,min,avg"`
+	Window string `json:"window,omitempty" example:"6h"`
+	MaxAge string `json:"max_age,omitempty" example:"2m"`
+}
+
+type RateQueryReqDTO struct {
+	Items []RateQueryItemDTO `json:"items"`
+}
+
+// RateQueryResultDTO - ответ на элемент пакетного запроса: заполнено либо Rate, либо Error
+type RateQueryResultDTO struct {
+	Title string      `json:"title"`
+	Quote string      `json:"quote"`
+	Mode  string      `json:"mode"`
+	Rate  *RateDTO    `json:"rate,omitempty"`
+	Stale bool        `json:"stale,omitempty"`
+	Error *ProblemDTO `json:"error,omitempty"`
+}
+
+type HistoryPointDTO struct {
+	Price      float64   `json:"price"`
+	Time       time.Time `json:"time"`
+	Backfilled bool      `json:"backfilled"`
+}
+
+type HistoryRespDTO struct {
+	Title  string            `json:"title"`
+	Points []HistoryPointDTO `json:"points"`
+}
+
+type CandleDTO struct {
+	Time  time.Time `json:"time"`
+	Open  float64   `json:"open"`
+	High  float64   `json:"high"`
+	Low   float64   `json:"low"`
+	Close float64   `json:"close"`
+	Avg   float64   `json:"avg"`
+	Count int       `json:"count"`
+}
+
+type CandlesRespDTO struct {
+	Title    string      `json:"title"`
+	Interval string      `json:"interval"`
+	Candles  []CandleDTO `json:"candles"`
+}
+
 type ErrRespDTO struct {
 	StatusCode int    `json:"status_code"`
+	Code       string `json:"code"`
 	Msg        string `json:"msg"`
 }
 
+// ProblemDTO - описание ошибки в формате RFC 7807 (application/problem+json)
+type ProblemDTO struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// InvalidSymbols - неверные тикеры из запроса, только для code=invalid_symbol
+	InvalidSymbols []string `json:"invalid_symbols,omitempty"`
+}
+
+type GapDTO struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Missing int       `json:"missing"`
+}
+
+type GapsRespDTO struct {
+	Title    string   `json:"title"`
+	Interval string   `json:"interval"`
+	Gaps     []GapDTO `json:"gaps"`
+}
+
+type RepairRespDTO struct {
+	Title    string `json:"title"`
+	Interval string `json:"interval"`
+	Repaired int    `json:"repaired"`
+}
+
+type LogLevelDTO struct {
+	Level string `json:"level" example:"info"`
+}
+
+type IssueKeyReqDTO struct {
+	Name      string   `json:"name" example:"partner-team"`
+	Scopes    []string `json:"scopes" example:"read,stream" enums:"read,stream,admin"`
+	RateLimit int      `json:"rate_limit,omitempty" example:"600"`
+}
+
+// APIKeyDTO - описание ключа; сам ключ отдаётся только при выпуске (Key)
+type APIKeyDTO struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix" example:"cr_1a2b3c4d"`
+	Scopes    []string   `json:"scopes"`
+	RateLimit int        `json:"rate_limit"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Key       string     `json:"key,omitempty"`
+}
+
+type KeyUsageDTO struct {
+	Day      string `json:"day" example:"2024-01-01"`
+	Requests int64  `json:"requests"`
+}
+
+type KeyUsageRespDTO struct {
+	KeyID int64         `json:"key_id"`
+	Usage []KeyUsageDTO `json:"usage"`
+}
+
+// AssetDTO - монета из каталога; ProviderIDs - идентификаторы монеты у провайдеров
+type AssetDTO struct {
+	Symbol      string            `json:"symbol" example:"BTC"`
+	Name        string            `json:"name" example:"Bitcoin"`
+	ProviderIDs map[string]string `json:"provider_ids"`
+	Decimals    int               `json:"decimals" example:"8"`
+	Status      string            `json:"status" example:"active" enums:"active,inactive,delisted"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type HealthDTO struct {
+	Status string `json:"status" example:"ok"`
+}
+
+type CheckDTO struct {
+	Name   string `json:"name" example:"postgres"`
+	Status string `json:"status" example:"ok" enums:"ok,fail"`
+	Code   string `json:"code,omitempty"`
+}
+
+type ReadinessDTO struct {
+	Status string     `json:"status" example:"ok" enums:"ok,fail"`
+	Checks []CheckDTO `json:"checks"`
+}
+
+type ProviderStatusDTO struct {
+	Circuit             string     `json:"circuit" enums:"closed,open,half_open"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+}
+
+// CoinLagDTO - отставание данных по монете; LastUpdate и LagSec не заполнены, если за сегодня данных нет
+type CoinLagDTO struct {
+	Title      string     `json:"title"`
+	LastUpdate *time.Time `json:"last_update,omitempty"`
+	LagSec     *float64   `json:"lag_sec,omitempty"`
+	Lagging    bool       `json:"lagging,omitempty"`
+}
+
+type BuildInfoDTO struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"go_version"`
+}
+
+// StatusDTO - состояние реплики; Provider не заполнен, если реплика работает без провайдера
+type StatusDTO struct {
+	Status        string             `json:"status" example:"ok" enums:"ok,degraded"`
+	Provider      *ProviderStatusDTO `json:"provider,omitempty"`
+	LastActualize *time.Time         `json:"last_actualize,omitempty"`
+	Coins         []CoinLagDTO       `json:"coins"`
+	Build         BuildInfoDTO       `json:"build"`
+}
+
 // to struct ✅
 // error code 400 ✅
 // config file ✅
@@ -3,44 +3,229 @@ package logger
 import (
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// форматы записи и направления вывода логов
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+)
+
+// Options - настройки логгера из конфига
+type Options struct {
+	Level  string
+	Format string
+	Output string
+	// файл и его ротация учитываются, только если вывод идёт в файл
+	File       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+	// сэмплирование: в секунду пишутся первые SamplingInitial одинаковых сообщений,
+	// затем каждое SamplingThereafter-е; сэмплирование включается, только если заданы оба значения
+	SamplingInitial    int
+	SamplingThereafter int
+}
+
 var (
 	logger *zap.Logger
 	once   sync.Once
+
+	level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	root  = &swapCore{}
 )
 
+// NewLogger возвращает общий логгер. До вызова Configure он пишет в stdout на уровне info
 func NewLogger() *zap.Logger {
 	once.Do(func() {
-		stdout := zapcore.AddSync(os.Stdout)
-		file := zapcore.AddSync(&lumberjack.Logger{
-			Filename: "./../../logs/app.log",
-			MaxSize:  10,
-			MaxAge:   7,
-		})
-		level := zap.NewAtomicLevelAt(zap.InfoLevel)
+		root.core.Store(newCore(Options{Format: FormatConsole, Output: OutputStdout}))
+		logger = zap.New(root)
+	})
+
+	return logger
+}
+
+// Configure перенастраивает общий логгер; логгеры, полученные через NewLogger раньше, тоже начинают писать по-новому
+func Configure(opts Options) error {
+	// незаданные формат и вывод оставляют поведение по умолчанию
+	if opts.Format == "" {
+		opts.Format = FormatConsole
+	}
+	if opts.Output == "" {
+		opts.Output = OutputStdout
+	}
+
+	switch opts.Format {
+	case FormatConsole, FormatJSON:
+	default:
+		return errors.Errorf("unknown log format: %q", opts.Format)
+	}
+
+	switch opts.Output {
+	case OutputStdout:
+	case OutputFile, OutputBoth:
+		if opts.File == "" {
+			return errors.New("log file path is empty")
+		}
+	default:
+		return errors.Errorf("unknown log output: %q", opts.Output)
+	}
+
+	if opts.Level != "" {
+		if err := SetLevel(opts.Level); err != nil {
+			return err
+		}
+	}
+
+	NewLogger()
+	old := root.core.Swap(newCore(opts))
+	_ = old.core.Sync()
+	// прежний файл закрывается, иначе каждая перенастройка оставляла бы открытый дескриптор
+	if old.file != nil {
+		_ = old.file.Close()
+	}
+
+	return nil
+}
+
+// Level возвращает текущий уровень логирования
+func Level() string {
+	return level.Level().String()
+}
+
+// SetLevel меняет уровень логирования на лету
+func SetLevel(name string) error {
+	lvl, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return errors.Errorf("unknown log level: %q", name)
+	}
+
+	level.SetLevel(lvl)
+	return nil
+}
+
+func newCore(opts Options) *coreHolder {
+	holder := &coreHolder{}
+	var cores []zapcore.Core
+	if opts.Output == OutputStdout || opts.Output == OutputBoth {
+		cores = append(cores, zapcore.NewCore(newEncoder(opts.Format, true), zapcore.Lock(os.Stdout), level))
+	}
+
+	if opts.Output == OutputFile || opts.Output == OutputBoth {
+		holder.file = &fileWriter{file: &lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSizeMB,
+			MaxAge:     opts.MaxAgeDays,
+			MaxBackups: opts.MaxBackups,
+			Compress:   opts.Compress,
+		}}
+		cores = append(cores, zapcore.NewCore(newEncoder(opts.Format, false), zapcore.AddSync(holder.file), level))
+	}
+
+	core := zapcore.NewTee(cores...)
+	// при SamplingThereafter = 0 сэмплер отбрасывал бы все сообщения после первых SamplingInitial
+	if opts.SamplingInitial > 0 && opts.SamplingThereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, opts.SamplingInitial, opts.SamplingThereafter)
+	}
 
+	holder.core = core
+	return holder
+}
+
+// newEncoder возвращает кодировщик записи; цвет уровня нужен только в терминале
+func newEncoder(format string, color bool) zapcore.Encoder {
+	if format == FormatJSON {
 		prodCfg := zap.NewProductionEncoderConfig()
 		prodCfg.TimeKey = "ts"
 		prodCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(prodCfg)
+	}
 
-		devCfg := zap.NewDevelopmentEncoderConfig()
+	devCfg := zap.NewDevelopmentEncoderConfig()
+	if color {
 		devCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return zapcore.NewConsoleEncoder(devCfg)
+}
 
-		consoleEncoder := zapcore.NewConsoleEncoder(devCfg)
-		fileEncoder := zapcore.NewJSONEncoder(prodCfg)
+type coreHolder struct {
+	core zapcore.Core
+	// file - файл логов, если вывод идёт в файл; закрывается при замене вывода
+	file *fileWriter
+}
 
-		core := zapcore.NewTee(
-			zapcore.NewCore(consoleEncoder, stdout, level),
-			zapcore.NewCore(fileEncoder, file, level),
-		)
+// fileWriter - файл логов, который после закрытия не открывается заново. Логгеры, полученные
+// через With до Configure, держат прежний вывод, и lumberjack пересоздал бы закрытый файл при первой записи
+type fileWriter struct {
+	mu     sync.RWMutex
+	file   *lumberjack.Logger
+	closed bool
+}
 
-		logger = zap.New(core)
-	})
+// Write после закрытия перенаправляет запись в текущий файл логов, а если вывод в файл выключен, отбрасывает её
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	if !w.closed {
+		defer w.mu.RUnlock()
+		return w.file.Write(p)
+	}
+	w.mu.RUnlock()
 
-	return logger
+	if current := root.core.Load().file; current != nil && current != w {
+		return current.Write(p)
+	}
+
+	return len(p), nil
+}
+
+func (w *fileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	return w.file.Close()
+}
+
+// swapCore позволяет подменить вывод уже созданных логгеров: пакеты получают логгер
+// при инициализации, задолго до того, как прочитан конфиг
+type swapCore struct {
+	core atomic.Pointer[coreHolder]
+}
+
+func (s *swapCore) current() zapcore.Core {
+	return s.core.Load().core
+}
+
+func (s *swapCore) Enabled(lvl zapcore.Level) bool {
+	return s.current().Enabled(lvl)
+}
+
+// With фиксирует текущий вывод: логгеры запросов живут недолго и создаются уже после Configure,
+// а запись в закрытый файл fileWriter перенаправляет в текущий
+func (s *swapCore) With(fields []zapcore.Field) zapcore.Core {
+	return s.current().With(fields)
+}
+
+func (s *swapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return s.current().Check(ent, ce)
+}
+
+func (s *swapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return s.current().Write(ent, fields)
+}
+
+func (s *swapCore) Sync() error {
+	return s.current().Sync()
 }
@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"kursy-kriptovalyut/pkg/logger"
+)
+
+func TestConfigure_LoggerWithFieldsAfterSwitch(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	oldFile := filepath.Join(dir, "old.log")
+	newFile := filepath.Join(dir, "new.log")
+
+	require.NoError(t, logger.Configure(logger.Options{Format: logger.FormatJSON, Output: logger.OutputFile, File: oldFile}))
+	// логгер запроса, созданный до перенастройки
+	reqLogger := logger.NewLogger().With(zap.String("request_id", "42"))
+	reqLogger.Info("before switch")
+
+	require.NoError(t, logger.Configure(logger.Options{Format: logger.FormatJSON, Output: logger.OutputFile, File: newFile}))
+	t.Cleanup(func() { _ = logger.Configure(logger.Options{}) })
+	reqLogger.Info("after switch")
+
+	old, err := os.ReadFile(oldFile)
+	require.NoError(t, err)
+	require.Contains(t, string(old), "before switch")
+	require.NotContains(t, string(old), "after switch")
+
+	current, err := os.ReadFile(newFile)
+	require.NoError(t, err)
+	require.Contains(t, string(current), "after switch")
+
+	// после переключения на stdout запись старого логгера не создаёт файл заново
+	require.NoError(t, os.Remove(newFile))
+	require.NoError(t, logger.Configure(logger.Options{}))
+	reqLogger.Info("after stdout")
+	require.NoFileExists(t, newFile)
+}
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: cryptorate/v1/rates.proto
+
+package cryptoratev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RatesService_GetLastRates_FullMethodName = "/cryptorate.v1.RatesService/GetLastRates"
+	RatesService_GetAggRates_FullMethodName  = "/cryptorate.v1.RatesService/GetAggRates"
+	RatesService_GetHistory_FullMethodName   = "/cryptorate.v1.RatesService/GetHistory"
+	RatesService_WatchRates_FullMethodName   = "/cryptorate.v1.RatesService/WatchRates"
+)
+
+// RatesServiceClient is the client API for RatesService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RatesService - курсы криптовалют; ошибки возвращаются со статусом gRPC
+// и google.rpc.ErrorInfo, где reason - код ошибки (invalid_symbol, unknown_aggregate, ...)
+type RatesServiceClient interface {
+	// последние цены монет
+	GetLastRates(ctx context.Context, in *GetLastRatesRequest, opts ...grpc.CallOption) (*RatesResponse, error)
+	// агрегированные цены монет за текущие сутки
+	GetAggRates(ctx context.Context, in *GetAggRatesRequest, opts ...grpc.CallOption) (*RatesResponse, error)
+	// сохранённые цены монеты за период
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
+	// поток новых цен монет; при обрыве переподключайтесь с last_event_id последнего события
+	WatchRates(ctx context.Context, in *WatchRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RateEvent], error)
+}
+
+type ratesServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRatesServiceClient(cc grpc.ClientConnInterface) RatesServiceClient {
+	return &ratesServiceClient{cc}
+}
+
+func (c *ratesServiceClient) GetLastRates(ctx context.Context, in *GetLastRatesRequest, opts ...grpc.CallOption) (*RatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RatesResponse)
+	err := c.cc.Invoke(ctx, RatesService_GetLastRates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ratesServiceClient) GetAggRates(ctx context.Context, in *GetAggRatesRequest, opts ...grpc.CallOption) (*RatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RatesResponse)
+	err := c.cc.Invoke(ctx, RatesService_GetAggRates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ratesServiceClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, RatesService_GetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ratesServiceClient) WatchRates(ctx context.Context, in *WatchRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RateEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RatesService_ServiceDesc.Streams[0], RatesService_WatchRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRatesRequest, RateEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RatesService_WatchRatesClient = grpc.ServerStreamingClient[RateEvent]
+
+// RatesServiceServer is the server API for RatesService service.
+// All implementations must embed UnimplementedRatesServiceServer
+// for forward compatibility.
+//
+// RatesService - курсы криптовалют; ошибки возвращаются со статусом gRPC
+// и google.rpc.ErrorInfo, где reason - код ошибки (invalid_symbol, unknown_aggregate, ...)
+type RatesServiceServer interface {
+	// последние цены монет
+	GetLastRates(context.Context, *GetLastRatesRequest) (*RatesResponse, error)
+	// агрегированные цены монет за текущие сутки
+	GetAggRates(context.Context, *GetAggRatesRequest) (*RatesResponse, error)
+	// сохранённые цены монеты за период
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
+	// поток новых цен монет; при обрыве переподключайтесь с last_event_id последнего события
+	WatchRates(*WatchRatesRequest, grpc.ServerStreamingServer[RateEvent]) error
+	mustEmbedUnimplementedRatesServiceServer()
+}
+
+// UnimplementedRatesServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRatesServiceServer struct{}
+
+func (UnimplementedRatesServiceServer) GetLastRates(context.Context, *GetLastRatesRequest) (*RatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLastRates not implemented")
+}
+func (UnimplementedRatesServiceServer) GetAggRates(context.Context, *GetAggRatesRequest) (*RatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAggRates not implemented")
+}
+func (UnimplementedRatesServiceServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedRatesServiceServer) WatchRates(*WatchRatesRequest, grpc.ServerStreamingServer[RateEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRates not implemented")
+}
+func (UnimplementedRatesServiceServer) mustEmbedUnimplementedRatesServiceServer() {}
+func (UnimplementedRatesServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeRatesServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RatesServiceServer will
+// result in compilation errors.
+type UnsafeRatesServiceServer interface {
+	mustEmbedUnimplementedRatesServiceServer()
+}
+
+func RegisterRatesServiceServer(s grpc.ServiceRegistrar, srv RatesServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRatesServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RatesService_ServiceDesc, srv)
+}
+
+func _RatesService_GetLastRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLastRatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RatesServiceServer).GetLastRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RatesService_GetLastRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RatesServiceServer).GetLastRates(ctx, req.(*GetLastRatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RatesService_GetAggRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAggRatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RatesServiceServer).GetAggRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RatesService_GetAggRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RatesServiceServer).GetAggRates(ctx, req.(*GetAggRatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RatesService_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RatesServiceServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RatesService_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RatesServiceServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RatesService_WatchRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RatesServiceServer).WatchRates(m, &grpc.GenericServerStream[WatchRatesRequest, RateEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RatesService_WatchRatesServer = grpc.ServerStreamingServer[RateEvent]
+
+// RatesService_ServiceDesc is the grpc.ServiceDesc for RatesService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RatesService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cryptorate.v1.RatesService",
+	HandlerType: (*RatesServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLastRates",
+			Handler:    _RatesService_GetLastRates_Handler,
+		},
+		{
+			MethodName: "GetAggRates",
+			Handler:    _RatesService_GetAggRates_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _RatesService_GetHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRates",
+			Handler:       _RatesService_WatchRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cryptorate/v1/rates.proto",
+}